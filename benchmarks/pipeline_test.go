@@ -0,0 +1,107 @@
+// Package benchmarks 衡量 Builder 组装的多层封装带来的耗时与内存分配
+//
+//	go test ./benchmarks -run '^$' -bench . -benchmem
+package benchmarks
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yikakia/cachalot"
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/codec"
+	"github.com/yikakia/cachalot/core/compress"
+	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/envelope"
+	"github.com/yikakia/cachalot/stores/storetests"
+)
+
+type user struct {
+	ID      int64    `json:"id"`
+	Name    string   `json:"name"`
+	Email   string   `json:"email"`
+	Roles   []string `json:"roles"`
+	Bio     string   `json:"bio"`
+	Enabled bool     `json:"enabled"`
+}
+
+var sample = user{
+	ID:      42,
+	Name:    "cachalot",
+	Email:   "cachalot@example.com",
+	Roles:   []string{"reader", "writer", "admin"},
+	Bio:     strings.Repeat("sperm whales dive deep to hunt squid. ", 20),
+	Enabled: true,
+}
+
+var pipelines = []struct {
+	name  string
+	build func(b *cachalot.Builder[user]) *cachalot.Builder[user]
+}{
+	{"json", func(b *cachalot.Builder[user]) *cachalot.Builder[user] {
+		return b.WithCodec(codec.JSONCodec{})
+	}},
+	{"json+gzip", func(b *cachalot.Builder[user]) *cachalot.Builder[user] {
+		return b.WithCodec(codec.JSONCodec{}).WithCompression(compress.GzipCompression{})
+	}},
+	{"json+gzip+checksum", func(b *cachalot.Builder[user]) *cachalot.Builder[user] {
+		return b.WithCodec(codec.JSONCodec{}).
+			WithChecksum(decorator.ChecksumConfig{}).
+			WithCompression(compress.GzipCompression{})
+	}},
+	{"envelope(json+gzip)", func(b *cachalot.Builder[user]) *cachalot.Builder[user] {
+		return b.WithEnvelope(envelope.Config{Write: envelope.Format{Codec: envelope.CodecJSON, Compression: envelope.CompressionGzip}})
+	}},
+	{"logic-expire+json+gzip", func(b *cachalot.Builder[user]) *cachalot.Builder[user] {
+		return b.WithCodec(codec.JSONCodec{}).
+			WithCompression(compress.GzipCompression{}).
+			WithLogicExpireDefaultLogicTTL(time.Hour)
+	}},
+}
+
+func build(b *testing.B, name string, fn func(*cachalot.Builder[user]) *cachalot.Builder[user]) cache.Cache[user] {
+	builder, err := cachalot.NewBuilder[user](name, storetests.NewMemoryStore(storetests.WithStoreName("remote"), storetests.WithCopyBytes()))
+	if err != nil {
+		b.Fatal(err)
+	}
+	c, err := fn(builder).Build()
+	if err != nil {
+		b.Fatal(err)
+	}
+	return c
+}
+
+func BenchmarkPipelineSet(b *testing.B) {
+	ctx := context.Background()
+	for _, p := range pipelines {
+		b.Run(p.name, func(b *testing.B) {
+			c := build(b, "bench", p.build)
+			b.ReportAllocs()
+			for b.Loop() {
+				if err := c.Set(ctx, "k", sample, time.Minute); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkPipelineGet(b *testing.B) {
+	ctx := context.Background()
+	for _, p := range pipelines {
+		b.Run(p.name, func(b *testing.B) {
+			c := build(b, "bench", p.build)
+			if err := c.Set(ctx, "k", sample, time.Minute); err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := c.Get(ctx, "k"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
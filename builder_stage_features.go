@@ -1,6 +1,9 @@
 package cachalot
 
 import (
+	"fmt"
+	"strconv"
+
 	"github.com/yikakia/cachalot/core/cache"
 	"github.com/yikakia/cachalot/core/decorator"
 	"github.com/yikakia/cachalot/core/telemetry"
@@ -20,14 +23,75 @@ type TypeAdapter[T any] func(next cache.Cache[[]byte], ob *telemetry.Observable)
 
 // WithCompression 以 Decorator 风格声明压缩能力，但内部会编译到 byte-stage。
 func (b *Builder[T]) WithCompression(c Compression) *Builder[T] {
-	return b.WithByteTransforms(func(next cache.Cache[[]byte], _ *telemetry.Observable) (cache.Cache[[]byte], error) {
+	return b.withByteStage("compression", []string{"codec", fmt.Sprintf("%T", c)}, func(next cache.Cache[[]byte], _ *telemetry.Observable) (cache.Cache[[]byte], error) {
 		return decorator.NewCompressionDecorator(next, c), nil
 	})
 }
 
+// WithAdaptiveCompression 只压缩超过 cfg.MinSize 且节省至少 cfg.MinSavings 的值，其余原样存储
+//
+// 每个值附加 1 字节标记区分两种形式，与 WithCompression 的存储格式不兼容。
+// ob.Metrics 实现 decorator.CompressionMetrics 时上报每次写入的压缩率
+func (b *Builder[T]) WithAdaptiveCompression(cfg decorator.AdaptiveCompressionConfig) *Builder[T] {
+	if err := cfg.Validate(); err != nil {
+		b.appendErr(err)
+		return b
+	}
+	config := []string{
+		"codec", fmt.Sprintf("%T", cfg.Codec),
+		"min_size", strconv.Itoa(cfg.MinSize),
+		"min_savings", strconv.FormatFloat(cfg.MinSavings, 'g', -1, 64),
+	}
+	return b.withByteStage("adaptive_compression", config, func(next cache.Cache[[]byte], ob *telemetry.Observable) (cache.Cache[[]byte], error) {
+		return decorator.NewAdaptiveCompressionDecorator(next, cfg, ob)
+	})
+}
+
+// WithChecksum 写入时追加校验和，读取时校验，不匹配时返回包裹 cache.ErrCorrupted 的错误
+//
+// 默认使用 CRC32C。配合 WithCacheMissCorruptionAsMiss 可以在读到损坏的值时回源并覆盖。
+// 开启前写入的值没有摘要，同样按损坏处理，在已有数据的存储上开启时需要同时开启 WithCacheMissCorruptionAsMiss
+func (b *Builder[T]) WithChecksum(cfg decorator.ChecksumConfig) *Builder[T] {
+	hash := "crc32c"
+	if cfg.NewHash != nil {
+		hash = "custom"
+	}
+	return b.withByteStage("checksum", []string{"hash", hash}, func(next cache.Cache[[]byte], _ *telemetry.Observable) (cache.Cache[[]byte], error) {
+		return decorator.NewChecksumDecorator(next, cfg), nil
+	})
+}
+
+// WithSizeGuard 限制写入 Store 的值的长度，超过 cfg.MaxSize 时按 cfg.Policy 拒绝、跳过或分块存储
+//
+// 始终位于字节级转换链的最内层，限制的是压缩、加密等转换之后实际写入的长度。
+// ob.Metrics 实现 decorator.SizeGuardMetrics 时上报超限的写入
+func (b *Builder[T]) WithSizeGuard(cfg decorator.SizeGuardConfig) *Builder[T] {
+	if err := cfg.Validate(); err != nil {
+		b.appendErr(err)
+		return b
+	}
+	b.features.sizeGuard = &cfg
+	return b
+}
+
 // WithByteTransforms 追加字节级转换链（按声明顺序执行）。
 func (b *Builder[T]) WithByteTransforms(ts ...ByteTransform) *Builder[T] {
-	b.features.byteTransforms = append(b.features.byteTransforms, ts...)
+	for _, t := range ts {
+		b.withByteStage("byte_transform", nil, t)
+	}
+	return b
+}
+
+// byteStage 带名称的字节级转换，名称与配置用于描述装配计划
+type byteStage struct {
+	name string
+	// 交替排列的配置键值
+	config    []string
+	transform ByteTransform
+}
+
+func (b *Builder[T]) withByteStage(name string, config []string, t ByteTransform) *Builder[T] {
+	b.features.byteTransforms = append(b.features.byteTransforms, byteStage{name: name, config: config, transform: t})
 	return b
 }
 
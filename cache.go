@@ -3,11 +3,17 @@ package cachalot
 import (
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/yikakia/cachalot/core/bloom"
 	"github.com/yikakia/cachalot/core/cache"
 	"github.com/yikakia/cachalot/core/codec"
 	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/envelope"
+	"github.com/yikakia/cachalot/core/hotkey"
+	"github.com/yikakia/cachalot/core/interceptor"
+	"github.com/yikakia/cachalot/core/stats"
 	"github.com/yikakia/cachalot/core/telemetry"
 	"golang.org/x/sync/singleflight"
 )
@@ -21,7 +27,11 @@ type features[T any] struct {
 	// 用户自定义类型适配器（T <-> []byte）
 	typeAdapter TypeAdapter[T]
 	// 字节级转换链，例如压缩/加密
-	byteTransforms []ByteTransform
+	byteTransforms []byteStage
+	// 非空时以信封格式完成 T <-> []byte 的转换，替代 codec
+	envelope *envelope.Config
+	// 非空时限制写入 Store 的值长度，位于字节级转换链的最内层
+	sizeGuard *decorator.SizeGuardConfig
 
 	// 逻辑过期特有的配置项
 	logicExpire struct {
@@ -42,6 +52,12 @@ type features[T any] struct {
 		loadFn decorator.LoaderFn[T]
 		// 回源后写回缓存，默认一小时过期
 		defaultWriteBackTTL time.Duration
+		// 回源确认不存在时写入墓碑的过期时间，默认 0 不开启负缓存
+		negativeTTL time.Duration
+		// 回源失败退避与 stale-if-error 配置，默认不开启
+		failureMemo *decorator.LoaderFailureMemoConfig
+		// 读到损坏的值时按未命中回源并覆盖，默认不开启
+		corruptionAsMiss bool
 	}
 
 	// 防缓存击穿功能配置
@@ -50,6 +66,34 @@ type features[T any] struct {
 		// 防护值写回缓存时的TTL 默认一小时
 		defaultWriteBackTTL time.Duration
 	}
+
+	// 布隆过滤器防穿透功能配置
+	bloomGuard struct {
+		filter bloom.KeyFilter
+		// 过滤器查询失败时是否放行 默认不放行
+		failOpen bool
+	}
+
+	// 超过软截止时间时告警
+	watchdog struct {
+		store  *decorator.WatchdogConfig
+		loader *decorator.WatchdogConfig
+	}
+
+	// 按声明顺序作用于全部操作
+	interceptors []interceptor.Interceptor
+	// 非空时开启访问日志
+	logging *interceptor.LoggingConfig
+
+	// 开启后聚合进程内统计 通过 StatsOf 获取
+	stats bool
+
+	// 热点 key 探测配置
+	hotKey struct {
+		detector *hotkey.Detector
+		// 热点 key 本地提升的存活时间 <= 0 表示只探测不提升
+		promoteTTL time.Duration
+	}
 }
 
 func NewBuilder[T any](name string, store cache.Store, opts ...cache.Option[T]) (*Builder[T], error) {
@@ -100,42 +144,101 @@ type Builder[T any] struct {
 	metrics telemetry.Metrics
 	// telemetry.SlogLogger by default
 	logger telemetry.Logger
+	// 开启访问日志时对 logger 的限流包裹，Build 时创建
+	throttledLogger *telemetry.ThrottledLogger
+	// 为空时事件中不记录 key
+	keyRedactor telemetry.KeyRedactor
 	// decorator.NewObservableDecorator by default
 	obDecorators cache.Option[T]
 
-	features   features[T]
+	features features[T]
+	// WithDecorators 传入的装饰器，紧邻工厂
 	decorators []cache.Option[T]
-	options    []cache.Option[T]
+	// WithDecoratorAt 传入的装饰器
+	placed []placedDecorator[T]
+	// 内置阶段的装饰器，Build 时创建
+	stageOptions map[Stage]cache.Option[T]
+	options      []cache.Option[T]
+	// 非空时 Build 成功后注册到 Manager
+	manager *Manager
 
 	factoryCustomized bool
+	observeCustomized bool
 }
 
 func (b *Builder[T]) Build() (cache.Cache[T], error) {
 	b.compileStages()
 	b.decorateCacheMissedLoader()
 	b.decoratePenetrationProtection()
+	var bloomGuard *decorator.BloomGuardDecorator[T]
+	b.decorateBloomGuard(&bloomGuard)
 	b.decorateSingleflight()
+	b.decorateHotKey()
+	b.decorateInterceptors()
+	stages, err := b.resolveStages()
+	if err != nil {
+		b.appendErr(err)
+	}
 	if b.err != nil {
 		return nil, fmt.Errorf("builder configs wrong: %w", b.err)
 	}
 
-	c, err := cache.New[T](b.cacheName, b.store,
-		cache.WithObservable[T](&telemetry.Observable{
-			Metrics: b.metrics,
-			Logger:  b.logger,
-		}),
+	logger := b.buildLogger()
+	metrics := b.metrics
+	var collector *stats.Collector
+	if b.features.stats {
+		collector = stats.NewCollector()
+		metrics = teeMetrics{b.metrics, collector}
+	}
+
+	ob := &telemetry.Observable{
+		Metrics:     metrics,
+		Logger:      logger,
+		KeyRedactor: b.keyRedactor,
+	}
+
+	c, err := cache.New[T](b.cacheName, b.buildStore(ob),
+		cache.WithObservable[T](ob),
 		b.factory,
-		cache.WithOptions(b.decorators...),
-		b.obDecorators,
+		cache.WithOptions(b.stageDecorators(stages)...),
 		cache.WithOptions(b.options...),
 	)
 
 	if err != nil {
 		return nil, fmt.Errorf("build cache [%s] failed: %w", b.cacheName, err)
 	}
+	// 快照当前配置，Build 之后继续修改 Builder 不影响已构建缓存的描述
+	snapshot := *b
+	described := &describedCache[T]{Cache: c, describe: sync.OnceValue(snapshot.plan), bloomGuard: bloomGuard}
+	c = described
+	if collector != nil {
+		c = &statsCache[T]{describedCache: described, collector: collector}
+	}
+	if b.manager != nil {
+		if err := registerCache(b.manager, b, c); err != nil {
+			return nil, fmt.Errorf("register cache [%s] failed: %w", b.cacheName, err)
+		}
+	}
 	return c, nil
 }
 
+// 开启负缓存时，需要在最内层包裹 Store 以支持墓碑的读写
+// 开启存储 watchdog 时，watchdog 直接包裹实际的 Store
+func (b *Builder[T]) buildStore(ob *telemetry.Observable) cache.Store {
+	store := b.store
+	if cfg := b.features.watchdog.store; cfg != nil {
+		store = decorator.NewWatchdogStore(store, decorator.NewWatchdog(*cfg, ob))
+	}
+	if b.negativeCacheEnabled() {
+		return decorator.NewNegativeCacheStore(store)
+	}
+	return store
+}
+
+func (b *Builder[T]) negativeCacheEnabled() bool {
+	return b.features.missLoader.loadFn != nil && b.features.missLoader.negativeTTL > 0
+}
+
 // 在 missedLoader 装饰器之后注入防缓存击穿装饰器
 func (b *Builder[T]) decoratePenetrationProtection() {
 	if b.features.nilCache.protectionFn == nil {
@@ -146,7 +249,7 @@ func (b *Builder[T]) decoratePenetrationProtection() {
 		b.appendErr(fmt.Errorf("nilCache.writeBackTTL require >= 0, but got: %v", writeBackTTL))
 		return
 	}
-	b.decorators = append(b.decorators, cache.WithDecorator(func(c cache.Cache[T], ob *telemetry.Observable) (cache.Cache[T], error) {
+	b.setStage(StageNilCache, cache.WithDecorator(func(c cache.Cache[T], ob *telemetry.Observable) (cache.Cache[T], error) {
 		return decorator.NewNilCacheDecorator(decorator.NilCacheConfig[T]{
 			Cache:        c,
 			ProtectionFn: b.features.nilCache.protectionFn,
@@ -156,15 +259,33 @@ func (b *Builder[T]) decoratePenetrationProtection() {
 	}))
 }
 
+// 在 missedLoader nilCache 装饰器之后注入布隆过滤器，保证被拦截的 key 不会触发回源
+// 构建出的装饰器写入 guard，供 BloomFalsePositiveRateOf 读取
+func (b *Builder[T]) decorateBloomGuard(guard **decorator.BloomGuardDecorator[T]) {
+	filter := b.features.bloomGuard.filter
+	if filter == nil {
+		return
+	}
+	failOpen := b.features.bloomGuard.failOpen
+	b.setStage(StageBloomGuard, cache.WithDecorator(func(c cache.Cache[T], ob *telemetry.Observable) (cache.Cache[T], error) {
+		*guard = decorator.NewBloomGuardDecorator(decorator.BloomGuardConfig[T]{
+			Cache:    c,
+			Filter:   filter,
+			FailOpen: failOpen,
+			Observer: ob,
+		})
+		return *guard, nil
+	}))
+}
+
 func (b *Builder[T]) appendErr(err error) {
 	b.err = errors.Join(b.err, err)
 }
 
-// 需要在 []decorator 最后一层 在最后 build 的时候才可以调用
+// 在最后 build 的时候才可以调用
 func (b *Builder[T]) decorateSingleflight() {
 	if b.features.singleFlight {
-		// 插入最后
-		b.decorators = append(b.decorators, cache.WithDecorator(func(cache cache.Cache[T], ob *telemetry.Observable) (cache.Cache[T], error) {
+		b.setStage(StageSingleflight, cache.WithDecorator(func(cache cache.Cache[T], ob *telemetry.Observable) (cache.Cache[T], error) {
 			return &decorator.SingleflightDecorator[T]{
 				Cache: cache,
 				Group: &singleflight.Group{},
@@ -173,6 +294,56 @@ func (b *Builder[T]) decorateSingleflight() {
 	}
 }
 
+// 在 singleflight 的外层注入，本地提升命中时不再进入 singleflight 与回源
+func (b *Builder[T]) decorateHotKey() {
+	detector := b.features.hotKey.detector
+	if detector == nil {
+		return
+	}
+	promoteTTL := b.features.hotKey.promoteTTL
+	b.setStage(StageHotKey, cache.WithDecorator(func(c cache.Cache[T], ob *telemetry.Observable) (cache.Cache[T], error) {
+		return decorator.NewHotKeyDecorator(decorator.HotKeyConfig[T]{
+			Cache:      c,
+			Detector:   detector,
+			PromoteTTL: promoteTTL,
+			CacheName:  b.cacheName,
+			Observer:   ob,
+		}), nil
+	}))
+}
+
+// 开启访问日志时，核心装饰器的日志按消息限流，限流配置与访问日志相同
+func (b *Builder[T]) buildLogger() telemetry.Logger {
+	if b.features.logging == nil {
+		return b.logger
+	}
+	if b.throttledLogger == nil {
+		b.throttledLogger = telemetry.NewThrottledLogger(b.logger, b.features.logging.Throttle)
+	}
+	return b.throttledLogger
+}
+
+// 位于所有特性装饰器的外层、观测层的内层，拦截器看到的是完整特性处理后的结果
+func (b *Builder[T]) decorateInterceptors() {
+	interceptors := b.features.interceptors
+	if b.features.logging != nil {
+		cfg := *b.features.logging
+		// 访问日志按 (操作, 级别, 结果) 自行限流，不经过核心装饰器共用的按消息限流
+		cfg.Logger = b.logger
+		if b.keyRedactor != nil {
+			cfg.KeyRedactor = b.keyRedactor
+		}
+		// 访问日志位于最外层，记录的耗时包含其他拦截器
+		interceptors = append([]interceptor.Interceptor{interceptor.Logging(cfg)}, interceptors...)
+	}
+	if len(interceptors) == 0 {
+		return
+	}
+	b.setStage(StageInterceptors, cache.WithSimpleDecorator(func(c cache.Cache[T]) (cache.Cache[T], error) {
+		return interceptor.NewDecorator(c, b.cacheName, interceptors...), nil
+	}))
+}
+
 func (b *Builder[T]) decorateCacheMissedLoader() {
 	loadFn := b.features.missLoader.loadFn
 	if loadFn == nil {
@@ -185,14 +356,36 @@ func (b *Builder[T]) decorateCacheMissedLoader() {
 		return
 	}
 
-	wrappedFn := decorator.SingleflightWrapper[T](loadFn)
-	b.decorators = append(b.decorators, cache.WithDecorator(func(c cache.Cache[T], ob *telemetry.Observable) (cache.Cache[T], error) {
+	negativeTTL := b.features.missLoader.negativeTTL
+	if negativeTTL < 0 {
+		b.appendErr(fmt.Errorf("negativeTTL require >= 0, but got: %v", negativeTTL))
+		return
+	}
+
+	watchdog := b.features.watchdog.loader
+	failureMemo := b.features.missLoader.failureMemo
+	corruptionAsMiss := b.features.missLoader.corruptionAsMiss
+	b.setStage(StageMissedLoader, cache.WithDecorator(func(c cache.Cache[T], ob *telemetry.Observable) (cache.Cache[T], error) {
+		// 由内到外：watchdog 监控实际的回源调用，失败退避，singleflight
+		loadFn := loadFn
+		if watchdog != nil {
+			loadFn = decorator.WatchLoader(decorator.NewWatchdog(*watchdog, ob), b.cacheName, loadFn)
+		}
+		var memo *decorator.LoaderFailureMemo[T]
+		if failureMemo != nil {
+			memo = decorator.NewLoaderFailureMemo[T](*failureMemo)
+			loadFn = memo.Wrap(loadFn)
+		}
+		wrappedFn := decorator.SingleflightWrapper[T](loadFn)
 
 		return decorator.NewMissedLoaderDecorator(decorator.MissedLoaderDecoratorConfig[T]{
-			Cache:        c,
-			LoadFn:       wrappedFn,
-			WriteBackTTL: writeBackTTL,
-			Observer:     ob,
+			Cache:            c,
+			LoadFn:           wrappedFn,
+			WriteBackTTL:     writeBackTTL,
+			NegativeTTL:      negativeTTL,
+			FailureMemo:      memo,
+			CorruptionAsMiss: corruptionAsMiss,
+			Observer:         ob,
 		}), nil
 	}))
 }
@@ -1,16 +1,31 @@
 package cachalot
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot/core/bloom"
 	"github.com/yikakia/cachalot/core/cache"
 	"github.com/yikakia/cachalot/core/codec"
 	"github.com/yikakia/cachalot/core/compress"
+	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/encrypt"
+	"github.com/yikakia/cachalot/core/envelope"
+	"github.com/yikakia/cachalot/core/interceptor"
+	"github.com/yikakia/cachalot/core/multicache"
+	"github.com/yikakia/cachalot/core/multicache/write_back"
+	"github.com/yikakia/cachalot/core/stats"
 	"github.com/yikakia/cachalot/core/telemetry"
 	"github.com/yikakia/cachalot/internal/mocks"
+	"github.com/yikakia/cachalot/stores/storetests"
 	"go.uber.org/mock/gomock"
 )
 
@@ -330,3 +345,1235 @@ func (s *stringByteAdapter) Clear(ctx context.Context) error {
 }
 
 var _ cache.Cache[string] = (*stringByteAdapter)(nil)
+
+func TestBuilderBloomGuardRejectsAbsentKeyBeforeLoader(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	ctx := context.Background()
+	store := mocks.NewMockStore(ctrl)
+	store.EXPECT().StoreName().Return("mock-store").Times(1)
+	store.EXPECT().Set(gomock.Any(), "k", "v", time.Minute).Return(nil)
+	store.EXPECT().Get(gomock.Any(), "k").Return("v", nil)
+
+	filter, err := bloom.NewLocalFilter(100, 0.001)
+	require.NoError(t, err)
+
+	loaderCalled := false
+	builder, err := NewBuilder[string]("bloom-guard", store)
+	require.NoError(t, err)
+	c, err := builder.
+		WithCacheMissLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			loaderCalled = true
+			return "", nil
+		}).
+		WithBloomGuard(filter).
+		Build()
+	require.NoError(t, err)
+
+	_, err = c.Get(ctx, "absent")
+	require.ErrorIs(t, err, cache.ErrNotFound)
+	require.False(t, loaderCalled)
+
+	require.NoError(t, c.Set(ctx, "k", "v", time.Minute))
+	got, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	require.Equal(t, "v", got)
+}
+
+func TestBloomFalsePositiveRateOf(t *testing.T) {
+	ctx := context.Background()
+	filter, err := bloom.NewLocalFilter(100, 0.001)
+	require.NoError(t, err)
+
+	builder, err := NewBuilder[string]("bloom-fpr", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	c, err := builder.WithBloomGuard(filter).WithStats(true).Build()
+	require.NoError(t, err)
+
+	require.NoError(t, c.Set(ctx, "k", "v", time.Minute))
+	_, err = c.Get(ctx, "k")
+	require.NoError(t, err)
+	// 过滤器中存在但存储中不存在，计为误判
+	require.NoError(t, filter.Add(ctx, "ghost"))
+	_, err = c.Get(ctx, "ghost")
+	require.ErrorIs(t, err, cache.ErrNotFound)
+
+	rate, ok := BloomFalsePositiveRateOf(c)
+	require.True(t, ok)
+	require.Equal(t, 0.5, rate)
+
+	plainBuilder, err := NewBuilder[string]("plain", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	plain, err := plainBuilder.Build()
+	require.NoError(t, err)
+	_, ok = BloomFalsePositiveRateOf(plain)
+	require.False(t, ok)
+}
+
+func TestBuilderNegativeCache(t *testing.T) {
+	ctx := context.Background()
+
+	type payload struct {
+		Name string
+	}
+
+	cases := []struct {
+		name  string
+		build func(b *Builder[payload]) *Builder[payload]
+	}{
+		{name: "plain", build: func(b *Builder[payload]) *Builder[payload] { return b }},
+		{name: "codec and compression", build: func(b *Builder[payload]) *Builder[payload] {
+			return b.WithCodec(codec.JSONCodec{}).WithCompression(compress.GzipCompression{})
+		}},
+		{name: "logic expire", build: func(b *Builder[payload]) *Builder[payload] {
+			return b.WithCodec(codec.JSONCodec{}).WithLogicExpireEnabled(true)
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := storetests.NewMemoryStore()
+			loads := 0
+			builder, err := NewBuilder[payload]("negative-cache", store)
+			require.NoError(t, err)
+			c, err := tc.build(builder).
+				WithCacheMissLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (payload, error) {
+					loads++
+					if key == "absent" {
+						return payload{}, fmt.Errorf("row not found: %w", cache.ErrNotFound)
+					}
+					return payload{Name: key}, nil
+				}).
+				WithCacheMissNegativeTTL(time.Minute).
+				Build()
+			require.NoError(t, err)
+
+			for i := 0; i < 3; i++ {
+				_, err = c.Get(ctx, "absent")
+				require.ErrorIs(t, err, cache.ErrNegativeCached)
+				require.ErrorIs(t, err, cache.ErrNotFound)
+			}
+			require.Equal(t, 1, loads)
+
+			for i := 0; i < 2; i++ {
+				got, err := c.Get(ctx, "exist")
+				require.NoError(t, err)
+				require.Equal(t, payload{Name: "exist"}, got)
+			}
+			require.Equal(t, 2, loads)
+
+			// 墓碑可以被正常写入覆盖
+			require.NoError(t, c.Set(ctx, "absent", payload{Name: "now"}, time.Minute))
+			got, err := c.Get(ctx, "absent")
+			require.NoError(t, err)
+			require.Equal(t, payload{Name: "now"}, got)
+		})
+	}
+}
+
+type countingBloomMetrics struct {
+	rejected int
+}
+
+func (m *countingBloomMetrics) Record(context.Context, *telemetry.Event) error        { return nil }
+func (m *countingBloomMetrics) RecordBloomRejected(context.Context)                   { m.rejected++ }
+func (m *countingBloomMetrics) RecordBloomFalsePositive(context.Context)              {}
+func (m *countingBloomMetrics) RecordBloomFalsePositiveRate(context.Context, float64) {}
+
+func TestBuilderStats(t *testing.T) {
+	ctx := context.Background()
+
+	filter, err := bloom.NewLocalFilter(100, 0.001)
+	require.NoError(t, err)
+	require.NoError(t, filter.Add(ctx, "k"))
+	metrics := &countingBloomMetrics{}
+
+	builder, err := NewBuilder[string]("stats", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	c, err := builder.
+		WithCacheMissLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			return "loaded-" + key, nil
+		}).
+		WithBloomGuard(filter).
+		WithMetrics(metrics).
+		WithStats(true).
+		Build()
+	require.NoError(t, err)
+
+	_, err = c.Get(ctx, "k")
+	require.NoError(t, err)
+	_, err = c.Get(ctx, "k")
+	require.NoError(t, err)
+	_, err = c.Get(ctx, "absent")
+	require.ErrorIs(t, err, cache.ErrNotFound)
+
+	s, ok := StatsOf(c)
+	require.True(t, ok)
+	require.Equal(t, uint64(2), s.Hits)
+	require.Equal(t, uint64(1), s.Misses)
+	require.Equal(t, uint64(1), s.LoaderCalls)
+	require.Equal(t, uint64(3), s.Ops[telemetry.OpGet].Count)
+	require.Equal(t, uint64(1), s.BloomRejected)
+	// 开启统计后，用户指标的可选接口依旧生效
+	require.Equal(t, 1, metrics.rejected)
+
+	c.(stats.Reporter).ResetStats()
+	s, _ = StatsOf(c)
+	require.Zero(t, s.Hits)
+
+	plainBuilder, err := NewBuilder[string]("no-stats", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	plain, err := plainBuilder.Build()
+	require.NoError(t, err)
+	_, ok = StatsOf(plain)
+	require.False(t, ok)
+}
+
+func TestMultiBuilderStats(t *testing.T) {
+	ctx := context.Background()
+
+	builder, err := NewBuilder[string]("l1", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	l1, err := builder.Build()
+	require.NoError(t, err)
+
+	mc, err := NewMultiBuilder[string]("multi-stats", l1).
+		WithLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			return "loaded-" + key, nil
+		}).
+		WithStats(true).
+		Build()
+	require.NoError(t, err)
+
+	_, err = mc.Get(ctx, "k")
+	require.NoError(t, err)
+	_, err = mc.Get(ctx, "k")
+	require.NoError(t, err)
+
+	s, ok := StatsOfMulti(mc)
+	require.True(t, ok)
+	require.Equal(t, uint64(2), s.Hits)
+	require.Equal(t, uint64(1), s.LoaderCalls)
+	// 统计位于最外层，不影响装配计划
+	plan, ok := PlanOf(mc)
+	require.True(t, ok)
+	require.Equal(t, "multi-stats", plan.Name)
+
+	mc.(stats.Reporter).ResetStats()
+	s, _ = StatsOfMulti(mc)
+	require.Zero(t, s.Hits)
+
+	plain, err := NewMultiBuilder[string]("multi-plain", l1).
+		WithLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			return "loaded-" + key, nil
+		}).
+		Build()
+	require.NoError(t, err)
+	_, ok = StatsOfMulti(plain)
+	require.False(t, ok)
+}
+
+// eventRecorder 记录所有观测事件，用于断言事件明细
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []*telemetry.Event
+}
+
+func (r *eventRecorder) Record(_ context.Context, evt *telemetry.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, evt)
+	return nil
+}
+
+func (r *eventRecorder) last() *telemetry.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.events[len(r.events)-1]
+}
+
+func TestBuilderEventDetails(t *testing.T) {
+	ctx := context.Background()
+	recorder := &eventRecorder{}
+
+	builder, err := NewBuilder[string]("details", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	c, err := builder.
+		WithCodec(codec.JSONCodec{}).
+		WithCompression(compress.GzipCompression{}).
+		WithCacheMissLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			return strings.Repeat("v", 512), nil
+		}).
+		WithKeyRedactor(telemetry.KeyFamily(":")).
+		WithMetrics(recorder).
+		Build()
+	require.NoError(t, err)
+
+	_, err = c.Get(ctx, "user:1")
+	require.NoError(t, err)
+	d := recorder.last().Details()
+	require.Equal(t, "user", d.Key)
+	require.True(t, d.LoaderRan)
+	require.Equal(t, 514, d.EncodedBytes)
+	require.Positive(t, d.CompressedBytes)
+	require.Less(t, d.CompressedBytes, d.EncodedBytes)
+
+	_, err = c.Get(ctx, "user:1")
+	require.NoError(t, err)
+	d = recorder.last().Details()
+	require.False(t, d.LoaderRan)
+	require.Equal(t, 514, d.EncodedBytes)
+}
+
+func TestMultiBuilderEventTier(t *testing.T) {
+	ctx := context.Background()
+	recorder := &eventRecorder{}
+
+	newLevel := func(name string) cache.Cache[string] {
+		b, err := NewBuilder[string](name, storetests.NewMemoryStore())
+		require.NoError(t, err)
+		c, err := b.Build()
+		require.NoError(t, err)
+		return c
+	}
+	l1, l2 := newLevel("l1"), newLevel("l2")
+	require.NoError(t, l2.Set(ctx, "k", "v", time.Minute))
+
+	mc, err := NewMultiBuilder[string]("multi-tier", l1, l2).
+		WithLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			return "loaded", nil
+		}).
+		WithKeyRedactor(telemetry.PlainKey()).
+		WithMetrics(recorder).
+		Build()
+	require.NoError(t, err)
+
+	_, err = mc.Get(ctx, "k")
+	require.NoError(t, err)
+	d := recorder.last().Details()
+	require.Equal(t, "k", d.Key)
+	require.True(t, d.HasTier)
+	require.Equal(t, 1, d.Tier)
+
+	_, err = mc.Get(ctx, "absent")
+	require.NoError(t, err)
+	d = recorder.last().Details()
+	require.False(t, d.HasTier)
+	require.True(t, d.LoaderRan)
+}
+
+func TestBuilderAndMultiBuilderInterceptors(t *testing.T) {
+	ctx := context.Background()
+
+	var ops []string
+	logOps := func(ctx context.Context, info *interceptor.OpInfo, next interceptor.Invoker) error {
+		err := next(ctx, info)
+		// 拦截器位于观测层内侧，可以读取当前事件
+		_, inEvent := telemetry.EventFromContext(ctx)
+		ops = append(ops, fmt.Sprintf("%s:%s:%s:%v:%v", info.CacheName, info.Op, info.Key, info.Value, inEvent))
+		return err
+	}
+
+	builder, err := NewBuilder[string]("l1", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	l1, err := builder.
+		WithCacheMissLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			return "loaded", nil
+		}).
+		WithInterceptors(logOps).
+		Build()
+	require.NoError(t, err)
+
+	_, err = l1.Get(ctx, "k")
+	require.NoError(t, err)
+	// 回源与回写发生在拦截器内侧，只会看到一次 Get
+	require.Equal(t, []string{"l1:get:k:loaded:true"}, ops)
+
+	ops = nil
+	mc, err := NewMultiBuilder[string]("multi", l1).
+		WithLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			return "from-loader", nil
+		}).
+		WithInterceptors(logOps).
+		Build()
+	require.NoError(t, err)
+
+	require.NoError(t, mc.Delete(ctx, "k"))
+	require.Equal(t, []string{"l1:delete:k:<nil>:true", "multi:delete:k:<nil>:true"}, ops)
+
+	// Get 中的回源属于 Get 的一部分，与单级缓存一样只经过一次拦截链
+	builder, err = NewBuilder[string]("l2", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	l2, err := builder.Build()
+	require.NoError(t, err)
+	mc, err = NewMultiBuilder[string]("multi", l2).
+		WithLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			return "from-loader", nil
+		}).
+		WithInterceptors(logOps).
+		Build()
+	require.NoError(t, err)
+	ops = nil
+	_, err = mc.Get(ctx, "absent")
+	require.NoError(t, err)
+	require.Equal(t, []string{"multi:get:absent:from-loader:true"}, ops)
+
+	ops = nil
+	_, err = mc.FetchByLoader(ctx, "direct")
+	require.NoError(t, err)
+	require.Equal(t, []string{"multi:fetch_by_loader:direct:from-loader:true"}, ops)
+}
+
+type failingSetStore struct {
+	*storetests.MemoryStore
+}
+
+func (s failingSetStore) Set(context.Context, string, any, time.Duration, ...cache.CallOption) error {
+	return errors.New("redis down")
+}
+
+type countingLogger struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (l *countingLogger) add(msg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.counts == nil {
+		l.counts = map[string]int{}
+	}
+	l.counts[msg]++
+}
+
+func (l *countingLogger) DebugContext(_ context.Context, msg string, _ ...any) { l.add(msg) }
+func (l *countingLogger) InfoContext(_ context.Context, msg string, _ ...any)  { l.add(msg) }
+func (l *countingLogger) WarnContext(_ context.Context, msg string, _ ...any)  { l.add(msg) }
+func (l *countingLogger) ErrorContext(_ context.Context, msg string, _ ...any) { l.add(msg) }
+
+func TestBuilderLoggingThrottlesDecoratorErrors(t *testing.T) {
+	ctx := context.Background()
+	logger := &countingLogger{}
+
+	cfg := interceptor.DefaultLoggingConfig()
+	cfg.Throttle = telemetry.ThrottleConfig{Interval: time.Hour, Burst: 3}
+
+	builder, err := NewBuilder[string]("throttled", failingSetStore{storetests.NewMemoryStore()})
+	require.NoError(t, err)
+	c, err := builder.
+		WithCacheMissLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			return "loaded", nil
+		}).
+		WithLogger(logger).
+		WithLogging(cfg).
+		Build()
+	require.NoError(t, err)
+
+	for i := 0; i < 20; i++ {
+		_, err := c.Get(ctx, "k")
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 3, logger.counts["[MissedLoaderDecorator] write back failed."])
+	require.Equal(t, 3, logger.counts["[cachalot] cache op."])
+}
+
+// keyLogger 记录访问日志中的 key 字段
+type keyLogger struct {
+	mu   sync.Mutex
+	keys []any
+}
+
+func (l *keyLogger) add(msg string, args []any) {
+	if msg != "[cachalot] cache op." {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := 0; i+1 < len(args); i += 2 {
+		if args[i] == "key" {
+			l.keys = append(l.keys, args[i+1])
+		}
+	}
+}
+
+func (l *keyLogger) DebugContext(_ context.Context, msg string, args ...any) { l.add(msg, args) }
+func (l *keyLogger) InfoContext(_ context.Context, msg string, args ...any)  { l.add(msg, args) }
+func (l *keyLogger) WarnContext(_ context.Context, msg string, args ...any)  { l.add(msg, args) }
+func (l *keyLogger) ErrorContext(_ context.Context, msg string, args ...any) { l.add(msg, args) }
+
+func TestBuilderLoggingUsesKeyRedactor(t *testing.T) {
+	ctx := context.Background()
+	redactor := telemetry.SampleKeys(0, telemetry.PlainKey())
+
+	logger := &keyLogger{}
+	builder, err := NewBuilder[string]("redacted", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	c, err := builder.
+		WithLogger(logger).
+		WithLogging(interceptor.DefaultLoggingConfig()).
+		WithKeyRedactor(redactor).
+		Build()
+	require.NoError(t, err)
+	require.NoError(t, c.Set(ctx, "user:1", "v", time.Minute))
+
+	multiLogger := &keyLogger{}
+	mc, err := NewMultiBuilder[string]("multi-redacted", c).
+		WithLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			return "loaded", nil
+		}).
+		WithLogger(multiLogger).
+		WithLogging(interceptor.DefaultLoggingConfig()).
+		WithKeyRedactor(telemetry.KeyFamily(":")).
+		Build()
+	require.NoError(t, err)
+	require.NoError(t, mc.Set(ctx, "user:2", "v", time.Minute))
+
+	// 未被采样的 key 不出现在访问日志中，按 key 族记录时只输出前缀
+	require.Empty(t, logger.keys)
+	require.Equal(t, []any{"user"}, multiLogger.keys)
+}
+
+type slowGetStore struct {
+	*storetests.MemoryStore
+	delay time.Duration
+}
+
+func (s slowGetStore) Get(ctx context.Context, key string, opts ...cache.CallOption) (any, error) {
+	time.Sleep(s.delay)
+	return s.MemoryStore.Get(ctx, key, opts...)
+}
+
+type watchdogCounter struct {
+	telemetry.Metrics
+	mu      sync.Mutex
+	targets []decorator.WatchdogTarget
+}
+
+func (w *watchdogCounter) RecordSlowInFlight(context.Context, decorator.WatchdogAlert) {}
+
+func (w *watchdogCounter) RecordOverrun(_ context.Context, alert decorator.WatchdogAlert) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.targets = append(w.targets, alert.Target)
+}
+
+func TestBuilderAndMultiBuilderWatchdog(t *testing.T) {
+	ctx := context.Background()
+	loadFn := func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+		time.Sleep(20 * time.Millisecond)
+		return "loaded", nil
+	}
+	cfg := decorator.WatchdogConfig{SoftDeadline: 5 * time.Millisecond}
+
+	metrics := &watchdogCounter{Metrics: telemetry.NoopMetrics()}
+	builder, err := NewBuilder[string]("watched", slowGetStore{storetests.NewMemoryStore(), 20 * time.Millisecond})
+	require.NoError(t, err)
+	c, err := builder.
+		WithCacheMissLoader(loadFn).
+		WithStoreWatchdog(cfg).
+		WithLoaderWatchdog(cfg).
+		WithMetrics(metrics).
+		WithStats(true).
+		Build()
+	require.NoError(t, err)
+
+	v, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	require.Equal(t, "loaded", v)
+	// 写回的 Set 不慢，只有存储的 Get 和回源超时
+	require.Equal(t, []decorator.WatchdogTarget{decorator.WatchdogTargetStore, decorator.WatchdogTargetLoader}, metrics.targets)
+
+	b, err := NewBuilder[string]("invalid", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	_, err = b.WithStoreWatchdog(decorator.WatchdogConfig{}).Build()
+	require.Error(t, err)
+
+	multiMetrics := &watchdogCounter{Metrics: telemetry.NoopMetrics()}
+	l1, err := NewBuilder[string]("l1", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	c1, err := l1.Build()
+	require.NoError(t, err)
+	mc, err := NewMultiBuilder[string]("multi", c1).
+		WithLoader(loadFn).
+		WithLoaderWatchdog(cfg).
+		WithMetrics(multiMetrics).
+		Build()
+	require.NoError(t, err)
+
+	v, err = mc.Get(ctx, "k")
+	require.NoError(t, err)
+	require.Equal(t, "loaded", v)
+	require.Equal(t, []decorator.WatchdogTarget{decorator.WatchdogTargetLoader}, multiMetrics.targets)
+}
+
+func TestBuilderEnvelope(t *testing.T) {
+	ctx := context.Background()
+	store := storetests.NewMemoryStore()
+
+	// 旧实例：JSON，未使用信封
+	oldBuilder, err := NewBuilder[string]("envelope", store)
+	require.NoError(t, err)
+	old, err := oldBuilder.WithCodec(codec.JSONCodec{}).Build()
+	require.NoError(t, err)
+	require.NoError(t, old.Set(ctx, "old", "legacy", time.Minute))
+
+	// 新实例：开启信封，写入 gob + gzip
+	legacy := envelope.Format{Codec: envelope.CodecJSON}
+	newBuilder, err := NewBuilder[string]("envelope", store)
+	require.NoError(t, err)
+	c, err := newBuilder.
+		WithEnvelope(envelope.Config{
+			Write:  envelope.Format{Codec: envelope.CodecGob, Compression: envelope.CompressionGzip},
+			Legacy: &legacy,
+		}).
+		Build()
+	require.NoError(t, err)
+
+	got, err := c.Get(ctx, "old")
+	require.NoError(t, err)
+	require.Equal(t, "legacy", got)
+
+	require.NoError(t, c.Set(ctx, "new", "enveloped", time.Minute))
+	got, err = c.Get(ctx, "new")
+	require.NoError(t, err)
+	require.Equal(t, "enveloped", got)
+
+	logicBuilder, err := NewBuilder[string]("envelope-logic", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	logic, err := logicBuilder.
+		WithLogicExpireEnabled(true).
+		WithEnvelope(envelope.Config{Write: envelope.Format{Codec: envelope.CodecJSON, Compression: envelope.CompressionZlib}}).
+		Build()
+	require.NoError(t, err)
+	require.NoError(t, logic.Set(ctx, "k", "v", time.Minute))
+	got, err = logic.Get(ctx, "k")
+	require.NoError(t, err)
+	require.Equal(t, "v", got)
+
+	conflictBuilder, err := NewBuilder[string]("envelope-conflict", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	_, err = conflictBuilder.
+		WithCodec(codec.JSONCodec{}).
+		WithEnvelope(envelope.Config{Write: envelope.Format{Codec: envelope.CodecJSON}}).
+		Build()
+	require.ErrorContains(t, err, "WithEnvelope cannot be combined with WithCodec")
+
+	rawBuilder, err := NewBuilder[string]("envelope-raw", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	_, err = rawBuilder.WithEnvelope(envelope.Config{}).Build()
+	require.ErrorContains(t, err, "raw codec requires []byte")
+}
+
+func TestBuilderSchemaVersion(t *testing.T) {
+	ctx := context.Background()
+	store := storetests.NewMemoryStore()
+
+	type user struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+
+	oldBuilder, err := NewBuilder[user]("schema", store)
+	require.NoError(t, err)
+	old, err := oldBuilder.WithCodec(codec.VersionedCodec{Codec: codec.JSONCodec{}, Version: 1}).Build()
+	require.NoError(t, err)
+	require.NoError(t, old.Set(ctx, "upgrade", user{Name: "whale"}, time.Minute))
+	require.NoError(t, old.Set(ctx, "discard", user{Name: "orca"}, time.Minute))
+
+	loads := 0
+	newCache := func(upgrades map[uint32]codec.UpgradeFunc) cache.Cache[user] {
+		builder, err := NewBuilder[user]("schema", store)
+		require.NoError(t, err)
+		c, err := builder.
+			WithCodec(codec.VersionedCodec{Codec: codec.JSONCodec{}, Version: 2, Upgrades: upgrades}).
+			WithCacheMissLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (user, error) {
+				loads++
+				return user{Name: key, Age: 2}, nil
+			}).
+			WithStats(true).
+			Build()
+		require.NoError(t, err)
+		return c
+	}
+
+	// 可以升级：不回源
+	upgrading := newCache(map[uint32]codec.UpgradeFunc{
+		1: func(data []byte) ([]byte, error) {
+			return bytes.Replace(data, []byte(`"age":0`), []byte(`"age":1`), 1), nil
+		},
+	})
+	got, err := upgrading.Get(ctx, "upgrade")
+	require.NoError(t, err)
+	require.Equal(t, user{Name: "whale", Age: 1}, got)
+	require.Equal(t, 0, loads)
+
+	// 无法升级：按未命中回源，并以当前版本写回
+	discarding := newCache(nil)
+	got, err = discarding.Get(ctx, "discard")
+	require.NoError(t, err)
+	require.Equal(t, user{Name: "discard", Age: 2}, got)
+	require.Equal(t, 1, loads)
+	got, err = discarding.Get(ctx, "discard")
+	require.NoError(t, err)
+	require.Equal(t, user{Name: "discard", Age: 2}, got)
+	require.Equal(t, 1, loads)
+
+	// 版本高于当前版本（滚动发布中的旧实例）：按未命中回源，但不回写，不覆盖新版本的值
+	oldBuilder, err = NewBuilder[user]("schema-old", store)
+	require.NoError(t, err)
+	stale, err := oldBuilder.
+		WithCodec(codec.VersionedCodec{Codec: codec.JSONCodec{}, Version: 1}).
+		WithCacheMissLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (user, error) {
+			loads++
+			return user{Name: key, Age: 1}, nil
+		}).
+		Build()
+	require.NoError(t, err)
+	got, err = stale.Get(ctx, "discard")
+	require.NoError(t, err)
+	require.Equal(t, user{Name: "discard", Age: 1}, got)
+	require.Equal(t, 2, loads)
+	got, err = discarding.Get(ctx, "discard")
+	require.NoError(t, err)
+	require.Equal(t, user{Name: "discard", Age: 2}, got)
+	require.Equal(t, 2, loads)
+
+	upgradeStats, ok := StatsOf(upgrading)
+	require.True(t, ok)
+	require.Equal(t, uint64(1), upgradeStats.SchemaUpgraded)
+	discardStats, ok := StatsOf(discarding)
+	require.True(t, ok)
+	require.Equal(t, uint64(1), discardStats.SchemaDiscarded)
+}
+
+func TestBuilderAdaptiveCompression(t *testing.T) {
+	ctx := context.Background()
+	cfg := decorator.AdaptiveCompressionConfig{
+		Codec:      compress.GzipCompression{},
+		MinSize:    64,
+		MinSavings: 0.1,
+	}
+	small := []byte("tiny")
+	large := bytes.Repeat([]byte("cachalot "), 100)
+
+	// 普通字节路径
+	plainStore := storetests.NewMemoryStore()
+	builder, err := NewBuilder[[]byte]("adaptive", plainStore)
+	require.NoError(t, err)
+	plain, err := builder.WithAdaptiveCompression(cfg).WithStats(true).Build()
+	require.NoError(t, err)
+
+	for _, v := range [][]byte{small, large} {
+		require.NoError(t, plain.Set(ctx, string(v[:4]), v, time.Minute))
+		got, err := plain.Get(ctx, string(v[:4]))
+		require.NoError(t, err)
+		require.Equal(t, v, got)
+	}
+	s, ok := StatsOf(plain)
+	require.True(t, ok)
+	require.Equal(t, uint64(1), s.CompressionApplied)
+	require.Equal(t, uint64(1), s.CompressionSkipped)
+	require.Less(t, s.CompressionRatio, 0.5)
+
+	// 逻辑过期路径
+	logicBuilder, err := NewBuilder[[]byte]("adaptive-logic", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	logic, err := logicBuilder.
+		WithLogicExpireBytesAdapter(true).
+		WithAdaptiveCompression(cfg).
+		Build()
+	require.NoError(t, err)
+	for _, v := range [][]byte{small, large} {
+		require.NoError(t, logic.Set(ctx, string(v[:4]), v, time.Minute))
+		got, err := logic.Get(ctx, string(v[:4]))
+		require.NoError(t, err)
+		require.Equal(t, v, got)
+	}
+
+	// 非法配置在 Build 时报错
+	badBuilder, err := NewBuilder[[]byte]("adaptive-bad", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	_, err = badBuilder.WithAdaptiveCompression(decorator.AdaptiveCompressionConfig{MinSavings: 2}).Build()
+	require.ErrorContains(t, err, "codec is required")
+	require.ErrorContains(t, err, "min savings")
+}
+
+func TestBuilderEncryption(t *testing.T) {
+	ctx := context.Background()
+	store := storetests.NewMemoryStore()
+
+	key1, err := encrypt.NewAESGCM(1, bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+	ring, err := encrypt.NewKeyring(key1)
+	require.NoError(t, err)
+
+	type user struct {
+		Email string `json:"email"`
+	}
+	loads := 0
+	builder, err := NewBuilder[user]("encrypted", store)
+	require.NoError(t, err)
+	c, err := builder.
+		WithCodec(codec.JSONCodec{}).
+		WithByteTransforms(encrypt.Transform(ring)).
+		WithCacheMissLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (user, error) {
+			loads++
+			return user{Email: key + "@example.com"}, nil
+		}).
+		WithStats(true).
+		Build()
+	require.NoError(t, err)
+
+	require.NoError(t, c.Set(ctx, "whale", user{Email: "whale@example.com"}, time.Minute))
+	stored, _ := store.Raw("whale")
+	raw := stored.([]byte)
+	require.NotContains(t, string(raw), "example.com")
+
+	got, err := c.Get(ctx, "whale")
+	require.NoError(t, err)
+	require.Equal(t, "whale@example.com", got.Email)
+	require.Equal(t, 0, loads)
+
+	// 篡改后按未命中回源，并以当前密钥写回
+	raw[len(raw)-1] ^= 1
+	got, err = c.Get(ctx, "whale")
+	require.NoError(t, err)
+	require.Equal(t, "whale@example.com", got.Email)
+	require.Equal(t, 1, loads)
+	_, err = c.Get(ctx, "whale")
+	require.NoError(t, err)
+	require.Equal(t, 1, loads)
+
+	s, ok := StatsOf(c)
+	require.True(t, ok)
+	require.Equal(t, uint64(1), s.DecryptTampered)
+}
+
+func TestBuilderChecksumCorruptionAsMiss(t *testing.T) {
+	ctx := context.Background()
+	store := storetests.NewMemoryStore()
+
+	type user struct {
+		Name string `json:"name"`
+	}
+	build := func(corruptionAsMiss bool) cache.Cache[user] {
+		builder, err := NewBuilder[user]("checksum", store)
+		require.NoError(t, err)
+		c, err := builder.
+			WithCodec(codec.JSONCodec{}).
+			WithChecksum(decorator.ChecksumConfig{}).
+			WithCacheMissLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (user, error) {
+				return user{Name: "loaded"}, nil
+			}).
+			WithCacheMissCorruptionAsMiss(corruptionAsMiss).
+			Build()
+		require.NoError(t, err)
+		return c
+	}
+
+	strict := build(false)
+	require.NoError(t, strict.Set(ctx, "k", user{Name: "whale"}, time.Minute))
+	got, err := strict.Get(ctx, "k")
+	require.NoError(t, err)
+	require.Equal(t, "whale", got.Name)
+
+	// 截断后不再是 codec 错误，而是 cache.ErrCorrupted
+	stored, _ := store.Raw("k")
+	raw := stored.([]byte)
+	require.NoError(t, store.Set(ctx, "k", raw[:len(raw)-6], time.Minute))
+	_, err = strict.Get(ctx, "k")
+	require.ErrorIs(t, err, cache.ErrCorrupted)
+
+	// 按未命中回源并覆盖损坏的值
+	tolerant := build(true)
+	got, err = tolerant.Get(ctx, "k")
+	require.NoError(t, err)
+	require.Equal(t, "loaded", got.Name)
+	got, err = strict.Get(ctx, "k")
+	require.NoError(t, err)
+	require.Equal(t, "loaded", got.Name)
+}
+
+func TestBuilderChecksumMigration(t *testing.T) {
+	ctx := context.Background()
+	store := storetests.NewMemoryStore()
+
+	type user struct {
+		Name string `json:"name"`
+	}
+	legacyBuilder, err := NewBuilder[user]("checksum-legacy", store)
+	require.NoError(t, err)
+	legacy, err := legacyBuilder.WithCodec(codec.JSONCodec{}).Build()
+	require.NoError(t, err)
+	require.NoError(t, legacy.Set(ctx, "strict", user{Name: "whale"}, time.Minute))
+	require.NoError(t, legacy.Set(ctx, "tolerant", user{Name: "orca"}, time.Minute))
+
+	loads := 0
+	build := func(corruptionAsMiss bool) cache.Cache[user] {
+		builder, err := NewBuilder[user]("checksum", store)
+		require.NoError(t, err)
+		c, err := builder.
+			WithCodec(codec.JSONCodec{}).
+			WithChecksum(decorator.ChecksumConfig{}).
+			WithCacheMissLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (user, error) {
+				loads++
+				return user{Name: "loaded-" + key}, nil
+			}).
+			WithCacheMissCorruptionAsMiss(corruptionAsMiss).
+			Build()
+		require.NoError(t, err)
+		return c
+	}
+
+	// 未开启 CorruptionAsMiss 时，开启校验前写入的值全部报错
+	_, err = build(false).Get(ctx, "strict")
+	require.ErrorIs(t, err, cache.ErrCorrupted)
+	require.Equal(t, 0, loads)
+
+	// 开启后旧值各回源一次，并以带摘要的格式覆盖
+	tolerant := build(true)
+	for range 2 {
+		got, err := tolerant.Get(ctx, "tolerant")
+		require.NoError(t, err)
+		require.Equal(t, "loaded-tolerant", got.Name)
+	}
+	require.Equal(t, 1, loads)
+}
+
+func TestBuilderSizeGuard(t *testing.T) {
+	ctx := context.Background()
+	large := bytes.Repeat([]byte("0123456789"), 100)
+
+	rejectBuilder, err := NewBuilder[[]byte]("size-reject", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	reject, err := rejectBuilder.
+		WithSizeGuard(decorator.SizeGuardConfig{MaxSize: 256}).
+		WithStats(true).
+		Build()
+	require.NoError(t, err)
+	require.ErrorIs(t, reject.Set(ctx, "k", large, time.Minute), cache.ErrValueTooLarge)
+	s, ok := StatsOf(reject)
+	require.True(t, ok)
+	require.Equal(t, uint64(1), s.OversizeRejected)
+
+	// 限制的是压缩之后的长度，可压缩的值不会超限
+	compressedBuilder, err := NewBuilder[[]byte]("size-compressed", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	compressed, err := compressedBuilder.
+		WithCompression(compress.GzipCompression{}).
+		WithSizeGuard(decorator.SizeGuardConfig{MaxSize: 256}).
+		Build()
+	require.NoError(t, err)
+	require.NoError(t, compressed.Set(ctx, "k", large, time.Minute))
+
+	// 分块写入，逻辑过期路径与校验和同样适用
+	store := storetests.NewMemoryStore()
+	chunkBuilder, err := NewBuilder[[]byte]("size-chunk", store)
+	require.NoError(t, err)
+	chunked, err := chunkBuilder.
+		WithLogicExpireBytesAdapter(true).
+		WithChecksum(decorator.ChecksumConfig{}).
+		WithSizeGuard(decorator.SizeGuardConfig{MaxSize: 256, Policy: decorator.OversizeChunk}).
+		Build()
+	require.NoError(t, err)
+	require.NoError(t, chunked.Set(ctx, "k", large, time.Minute))
+	got, err := chunked.Get(ctx, "k")
+	require.NoError(t, err)
+	require.Equal(t, large, got)
+	_, ok = store.Raw("k:4")
+	require.True(t, ok)
+
+	badBuilder, err := NewBuilder[[]byte]("size-bad", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	_, err = badBuilder.WithSizeGuard(decorator.SizeGuardConfig{}).Build()
+	require.ErrorContains(t, err, "max size")
+}
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager()
+
+	builder, err := NewBuilder[string]("l1", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	l1, err := builder.
+		WithCodec(codec.JSONCodec{}).
+		WithCompression(compress.GzipCompression{}).
+		WithCacheMissLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			return "l1-" + key, nil
+		}).
+		WithStats(true).
+		WithManager(m).
+		Build()
+	require.NoError(t, err)
+
+	builder, err = NewBuilder[string]("l2", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	l2, err := builder.Build()
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	var written atomic.Bool
+	mc, err := NewMultiBuilder[string]("multi", l1, l2).
+		WithLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			return "loaded-" + key, nil
+		}).
+		// 未指定 Group，WithManager 会将异步写回提交到 m.Background()
+		WithWriteBack(write_back.Builder[string]{
+			Async: true,
+			CustomWriteBack: func(ctx context.Context, getCtx *multicache.FetchContext[string], caches []cache.Cache[string]) error {
+				<-release
+				written.Store(true)
+				return nil
+			},
+		}.Build()).
+		WithManager(m).
+		Build()
+	require.NoError(t, err)
+
+	infos := m.List()
+	require.Len(t, infos, 2)
+	require.Equal(t, "l1", infos[0].Name)
+	require.Equal(t, KindCache, infos[0].Kind)
+	require.True(t, infos[0].StatsEnabled)
+	require.Equal(t, []string{"stats", "observable", "singleflight", "missed_loader", "codec", "compression", "base", "store"}, infos[0].Plan.Names())
+	require.Equal(t, KindMultiCache, infos[1].Kind)
+	require.Equal(t, []string{"l1", "tier[1]"}, infos[1].Tiers)
+	require.Equal(t, []string{"observable", "multicache", "singleflight", "loader"}, infos[1].Plan.Names())
+
+	got, ok := Lookup[string](m, "l1")
+	require.True(t, ok)
+	_, err = got.Get(ctx, "k")
+	require.NoError(t, err)
+	s, ok := m.Stats("l1")
+	require.True(t, ok)
+	require.Equal(t, uint64(1), s.LoaderCalls)
+	_, ok = m.Stats("multi")
+	require.False(t, ok)
+	_, ok = Lookup[int](m, "l1")
+	require.False(t, ok)
+	_, ok = Lookup[string](m, "multi")
+	require.False(t, ok)
+
+	dup, err := NewBuilder[string]("l1", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	_, err = dup.WithManager(m).Build()
+	require.ErrorContains(t, err, "already registered")
+
+	// 读取后触发的异步写回被阻塞，Close 需要等待其结束后再执行关闭函数
+	gotMulti, ok := LookupMulti[string](m, "multi")
+	require.True(t, ok)
+	require.Equal(t, mc, gotMulti)
+	_, err = gotMulti.Get(ctx, "missing")
+	require.NoError(t, err)
+
+	var order []string
+	m.OnClose(func(context.Context) error {
+		order = append(order, "first")
+		return nil
+	})
+	m.OnClose(func(context.Context) error {
+		order = append(order, "second")
+		return errors.New("close failed")
+	})
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	go func() {
+		<-timeout.Done()
+		close(release)
+	}()
+	err = m.Close(ctx)
+	require.ErrorContains(t, err, "close failed")
+	require.True(t, written.Load())
+	require.Equal(t, []string{"second", "first"}, order)
+
+	require.ErrorIs(t, m.Close(ctx), ErrManagerClosed)
+	_, err = NewMultiBuilder[string]("late", l1).
+		WithLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			return key, nil
+		}).
+		WithManager(m).
+		Build()
+	require.ErrorIs(t, err, ErrManagerClosed)
+}
+
+func TestBuilderExplain(t *testing.T) {
+	builder, err := NewBuilder[string]("explain", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	builder.
+		WithCodec(codec.JSONCodec{}).
+		WithCompression(compress.GzipCompression{}).
+		WithChecksum(decorator.ChecksumConfig{}).
+		WithSizeGuard(decorator.SizeGuardConfig{MaxSize: 1024, Policy: decorator.OversizeReject}).
+		WithCacheMissLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			return key, nil
+		}).
+		WithCacheMissNegativeTTL(time.Minute).
+		WithStats(true)
+
+	plan, err := builder.Explain()
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"stats", "observable", "singleflight", "missed_loader",
+		"codec", "checksum", "compression", "size_guard", "base",
+		"negative_cache_store", "store",
+	}, plan.Names())
+	require.Less(t, plan.Index("singleflight"), plan.Index("missed_loader"))
+	require.Equal(t, map[string]string{"write_back_ttl": "1h0m0s", "negative_ttl": "1m0s"}, plan.Layers[plan.Index("missed_loader")].Config)
+	require.Equal(t, LayerAdapter, plan.Layers[plan.Index("codec")].Kind)
+	require.Contains(t, plan.String(), "codec=codec.JSONCodec type=string")
+
+	c, err := builder.Build()
+	require.NoError(t, err)
+	builder.WithSingleflight(false)
+	described, ok := PlanOf(c)
+	require.True(t, ok)
+	require.Equal(t, plan, described)
+
+	mc, err := NewMultiBuilder[string]("explain-multi", c).
+		WithLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			return key, nil
+		}).
+		WithStaleIfError(time.Minute, 10).
+		Build()
+	require.NoError(t, err)
+	described, ok = PlanOf(mc)
+	require.True(t, ok)
+	require.Equal(t, []string{"observable", "multicache", "stale_if_error", "singleflight", "loader"}, described.Names())
+
+	builder, err = NewBuilder[string]("explain-invalid", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	plan, err = builder.
+		WithFactory(func(store cache.Store, ob *telemetry.Observable) (cache.Cache[string], error) {
+			return cache.NewBaseCache[string](store), nil
+		}).
+		WithCodec(codec.JSONCodec{}).
+		Explain()
+	require.ErrorContains(t, err, "WithFactory cannot be combined")
+	require.Equal(t, []string{"observable", "singleflight", "custom_factory", "store"}, plan.Names())
+}
+
+// orderRecorder 记录 Get 依次经过的装饰器
+type orderRecorder struct {
+	cache.Cache[string]
+	name  string
+	order *[]string
+}
+
+func (r *orderRecorder) Get(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+	*r.order = append(*r.order, r.name)
+	return r.Cache.Get(ctx, key, opts...)
+}
+
+func recordOrder(name string, order *[]string) cache.Decorator[string] {
+	return func(c cache.Cache[string], _ *telemetry.Observable) (cache.Cache[string], error) {
+		return &orderRecorder{Cache: c, name: name, order: order}, nil
+	}
+}
+
+func TestBuilderDecoratorPlacement(t *testing.T) {
+	ctx := context.Background()
+	var order []string
+	loader := func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+		order = append(order, "loader")
+		return key, nil
+	}
+
+	builder, err := NewBuilder[string]("placement", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	builder.
+		WithCacheMissLoader(loader).
+		WithDecorators(recordOrder("legacy", &order)).
+		WithDecoratorAt("chained", After("between"), recordOrder("chained", &order)).
+		WithDecoratorAt("between", After(StageSingleflight), recordOrder("between", &order)).
+		WithDecoratorAt("outer", Before(StageObservable), recordOrder("outer", &order)).
+		WithDecoratorAt("inner", Before(StageFactory), recordOrder("inner", &order))
+
+	plan, err := builder.Explain()
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"outer", "observable", "singleflight", "between", "chained", "missed_loader",
+		"decorator", "inner", "base", "store",
+	}, plan.Names())
+	require.Equal(t, map[string]string{"placement": "after singleflight"}, plan.Layers[plan.Index("between")].Config)
+
+	c, err := builder.Build()
+	require.NoError(t, err)
+	_, err = c.Get(ctx, "k")
+	require.NoError(t, err)
+	require.Equal(t, []string{"outer", "between", "chained", "legacy", "inner", "loader"}, order)
+
+	// 锚定同一阶段的装饰器按声明顺序由外到内排列
+	builder, err = NewBuilder[string]("placement-same-anchor", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	plan, err = builder.
+		WithDecoratorAt("a", After(StageObservable), recordOrder("a", &order)).
+		WithDecoratorAt("b", After(StageObservable), recordOrder("b", &order)).
+		WithDecoratorAt("c", Before(StageSingleflight), recordOrder("c", &order)).
+		Explain()
+	require.NoError(t, err)
+	require.Equal(t, []string{"observable", "a", "b", "c", "singleflight", "base", "store"}, plan.Names())
+
+	tests := []struct {
+		name    string
+		setup   func(b *Builder[string])
+		wantErr string
+	}{
+		{
+			name: "disabled stage",
+			setup: func(b *Builder[string]) {
+				b.WithDecoratorAt("d", After(StageBloomGuard), recordOrder("d", &order))
+			},
+			wantErr: "stage [bloom_guard] is not enabled",
+		},
+		{
+			name: "unknown stage",
+			setup: func(b *Builder[string]) {
+				b.WithDecoratorAt("d", Before("missing"), recordOrder("d", &order))
+			},
+			wantErr: "stage [missing] is unknown",
+		},
+		{
+			name: "cycle",
+			setup: func(b *Builder[string]) {
+				b.WithDecoratorAt("a", After("b"), recordOrder("a", &order)).
+					WithDecoratorAt("b", Before("a"), recordOrder("b", &order))
+			},
+			wantErr: "forms a cycle",
+		},
+		{
+			name: "duplicate name",
+			setup: func(b *Builder[string]) {
+				b.WithDecoratorAt("d", After(StageObservable), recordOrder("d", &order)).
+					WithDecoratorAt("d", After(StageObservable), recordOrder("d", &order))
+			},
+			wantErr: "decorator [d] already declared",
+		},
+		{
+			name: "builtin name",
+			setup: func(b *Builder[string]) {
+				b.WithDecoratorAt(StageSingleflight, After(StageObservable), recordOrder("d", &order))
+			},
+			wantErr: "conflicts with builtin stage",
+		},
+		{
+			name: "inside factory",
+			setup: func(b *Builder[string]) {
+				b.WithDecoratorAt("d", After(StageFactory), recordOrder("d", &order))
+			},
+			wantErr: "cannot be placed after factory",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := NewBuilder[string]("placement-invalid", storetests.NewMemoryStore())
+			require.NoError(t, err)
+			tt.setup(b)
+			_, err = b.Explain()
+			require.ErrorContains(t, err, tt.wantErr)
+			_, err = b.Build()
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
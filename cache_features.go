@@ -4,9 +4,13 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/yikakia/cachalot/core/bloom"
 	"github.com/yikakia/cachalot/core/cache"
 	"github.com/yikakia/cachalot/core/codec"
 	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/envelope"
+	"github.com/yikakia/cachalot/core/hotkey"
+	"github.com/yikakia/cachalot/core/interceptor"
 	"github.com/yikakia/cachalot/core/telemetry"
 )
 
@@ -22,6 +26,15 @@ func (b *Builder[T]) WithCodec(codec codec.Codec) *Builder[T] {
 	return b
 }
 
+// WithEnvelope 以自描述的信封格式存储，值头部记录编解码与压缩方式
+//
+// 写入使用 cfg.Write，读取时按信封头解码任意已注册的格式，切换格式无需清空缓存。
+// 信封已包含编解码，不能与 WithCodec、WithTypeAdapter 同时使用
+func (b *Builder[T]) WithEnvelope(cfg envelope.Config) *Builder[T] {
+	b.features.envelope = &cfg
+	return b
+}
+
 // 开启逻辑过期功能
 func (b *Builder[T]) WithLogicExpireEnabled(enabled bool) *Builder[T] {
 	b.features.logicExpire.enabled = enabled
@@ -79,6 +92,35 @@ func (b *Builder[T]) WithCacheMissDefaultWriteBackTTL(d time.Duration) *Builder[
 	return b
 }
 
+// WithCacheMissNegativeTTL 开启负缓存
+//
+// 回源函数返回 cache.ErrNotFound（可包裹）时，写入一个墓碑，墓碑的过期时间为 d。
+// 墓碑过期前 Get 返回 cache.ErrNegativeCached（同时满足 errors.Is(err, cache.ErrNotFound)），不会再次回源。
+// 墓碑通过最内层 Store 的 wire 格式区分，可以和 codec、压缩、逻辑过期一起使用。
+// 如果不调用 WithCacheMissLoader 传入回源函数的话 此设置无效
+func (b *Builder[T]) WithCacheMissNegativeTTL(d time.Duration) *Builder[T] {
+	b.features.missLoader.negativeTTL = d
+	return b
+}
+
+// WithCacheMissLoaderBackoff 开启回源失败退避与 stale-if-error
+//
+// 回源失败后，同一个 key 在退避窗口内不会再调用回源函数，窗口随连续失败次数指数增长。
+// cfg.MaxStale > 0 时会在影子槽中保留最后一次成功回源的值，回源失败时返回该旧值（不会写回缓存）。
+// 如果不调用 WithCacheMissLoader 传入回源函数的话 此设置无效
+func (b *Builder[T]) WithCacheMissLoaderBackoff(cfg decorator.LoaderFailureMemoConfig) *Builder[T] {
+	b.features.missLoader.failureMemo = &cfg
+	return b
+}
+
+// WithCacheMissCorruptionAsMiss 读到损坏的值（cache.ErrCorrupted，如 WithChecksum 校验失败）时按未命中回源，
+// 并以回源结果覆盖损坏的值，事件中写入自定义字段 corrupted=reloaded
+// 如果不调用 WithCacheMissLoader 传入回源函数的话 此设置无效
+func (b *Builder[T]) WithCacheMissCorruptionAsMiss(enabled bool) *Builder[T] {
+	b.features.missLoader.corruptionAsMiss = enabled
+	return b
+}
+
 // WithNilCacheFn 启用防缓存击穿功能
 func (b *Builder[T]) WithNilCacheFn(fn decorator.ProtectionFn[T]) *Builder[T] {
 	b.features.nilCache.protectionFn = fn
@@ -90,6 +132,79 @@ func (b *Builder[T]) WithNilCacheWriteBackTTL(ttl time.Duration) *Builder[T] {
 	return b
 }
 
+// WithBloomGuard 启用布隆过滤器防穿透功能
+//
+// 过滤器判定一定不存在的 key 直接返回 cache.ErrNotFound，不会访问存储与回源函数。
+// 过滤器需要通过 bloom.KeyEnumerator 预先灌入存在的 key，Set 时会自动写入。
+func (b *Builder[T]) WithBloomGuard(filter bloom.KeyFilter) *Builder[T] {
+	b.features.bloomGuard.filter = filter
+	return b
+}
+
+// WithBloomGuardFailOpen 过滤器查询失败时是否放行到下游，默认不放行直接返回错误
+func (b *Builder[T]) WithBloomGuardFailOpen(enable bool) *Builder[T] {
+	b.features.bloomGuard.failOpen = enable
+	return b
+}
+
+// WithHotKeyDetection 开启热点 key 探测
+//
+// detector 记录滑动窗口内的访问频率，并通过 hotkey.TopKMetrics 上报 TopK。
+// promoteTTL > 0 时会将热点 key 提升到进程内的本地缓存，存活 promoteTTL，热点集合变化后会自动清理。
+func (b *Builder[T]) WithHotKeyDetection(detector *hotkey.Detector, promoteTTL time.Duration) *Builder[T] {
+	b.features.hotKey.detector = detector
+	b.features.hotKey.promoteTTL = promoteTTL
+	return b
+}
+
+// WithInterceptors 追加操作拦截器，按声明顺序串联，先声明的位于外层
+//
+// 拦截器位于所有特性装饰器的外层、观测层的内层
+func (b *Builder[T]) WithInterceptors(interceptors ...interceptor.Interceptor) *Builder[T] {
+	b.features.interceptors = append(b.features.interceptors, interceptors...)
+	return b
+}
+
+// WithLogging 开启访问日志，位于所有拦截器外层
+//
+// WithLogger 传入的 Logger 会按 cfg.Throttle 限流，核心装饰器的错误日志与访问日志共用同一个限流器
+func (b *Builder[T]) WithLogging(cfg interceptor.LoggingConfig) *Builder[T] {
+	b.features.logging = &cfg
+	return b
+}
+
+// WithStats 开启进程内统计，与 WithMetrics 传入的指标同时上报
+//
+// 开启后构建出的缓存实现 stats.Reporter，可通过 StatsOf 获取统计快照
+func (b *Builder[T]) WithStats(enabled bool) *Builder[T] {
+	b.features.stats = enabled
+	return b
+}
+
+// WithStoreWatchdog 监控存储调用，超过 cfg.SoftDeadline 仍未返回时告警，不会取消调用
+//
+// watchdog 直接包裹传入的 Store，告警通过 cfg.OnSlow 与 decorator.WatchdogMetrics 上报
+func (b *Builder[T]) WithStoreWatchdog(cfg decorator.WatchdogConfig) *Builder[T] {
+	if cfg.SoftDeadline <= 0 {
+		b.appendErr(fmt.Errorf("watchdog soft deadline require > 0, but got: %v", cfg.SoftDeadline))
+		return b
+	}
+	b.features.watchdog.store = &cfg
+	return b
+}
+
+// WithLoaderWatchdog 监控回源调用，超过 cfg.SoftDeadline 仍未返回时告警，不会取消调用
+//
+// watchdog 位于 singleflight 内层，只对实际执行的回源告警
+func (b *Builder[T]) WithLoaderWatchdog(cfg decorator.WatchdogConfig) *Builder[T] {
+	if cfg.SoftDeadline <= 0 {
+		b.appendErr(fmt.Errorf("watchdog soft deadline require > 0, but got: %v", cfg.SoftDeadline))
+		return b
+	}
+	b.features.watchdog.loader = &cfg
+	return b
+}
+
 // WithFactory 显式声明使用自定义装配计划，与 staged features 互斥。
 func (b *Builder[T]) WithFactory(factory cache.CacheFactory[T]) *Builder[T] {
 	b.factoryCustomized = true
@@ -107,13 +222,24 @@ func (b *Builder[T]) WithMetrics(metrics telemetry.Metrics) *Builder[T] {
 	return b
 }
 
+// WithKeyRedactor 在观测事件中记录 key，redactor 决定记录的内容，如 telemetry.HashKey、telemetry.KeyFamily
+func (b *Builder[T]) WithKeyRedactor(redactor telemetry.KeyRedactor) *Builder[T] {
+	b.keyRedactor = redactor
+	return b
+}
+
 // WithObserveDecorator 自定义最外层进行观测的 observer 装饰层
+//
+// 替换的是 StageObservable 阶段，通过 WithDecoratorAt 锚定该阶段的装饰器不受影响
 func (b *Builder[T]) WithObserveDecorator(d cache.Decorator[T]) *Builder[T] {
 	b.obDecorators = cache.WithDecorator(d)
+	b.observeCustomized = true
 	return b
 }
 
 // WithDecorators 如果用 WithOptions 也传入了装饰器，则 WithOptions 传入的更靠外层
+//
+// 传入的装饰器紧邻工厂，位于所有内置装饰器的内层，后声明的更靠外层。需要指定位置时使用 WithDecoratorAt
 func (b *Builder[T]) WithDecorators(decorators ...cache.Decorator[T]) *Builder[T] {
 	for _, d := range decorators {
 		b.decorators = append(b.decorators, cache.WithDecorator(d))
@@ -121,6 +247,12 @@ func (b *Builder[T]) WithDecorators(decorators ...cache.Decorator[T]) *Builder[T
 	return b
 }
 
+// WithManager Build 成功后以缓存名称注册到 m，名称已存在或 m 已关闭时 Build 返回错误
+func (b *Builder[T]) WithManager(m *Manager) *Builder[T] {
+	b.manager = m
+	return b
+}
+
 // WithOptions 自定义的可选项，优先级最高
 //
 // 如果传入 cache.WithFactory 则会覆盖 Builder 的装配计划
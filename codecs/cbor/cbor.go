@@ -0,0 +1,62 @@
+package cbor
+
+import (
+	"github.com/fxamacker/cbor/v2"
+	"github.com/yikakia/cachalot/core/codec"
+)
+
+var (
+	_ codec.AppendMarshaler = Codec{}
+	_ codec.NoRetain        = Codec{}
+)
+
+// Codec CBOR（RFC 8949）序列化，零值可用
+type Codec struct {
+	// 为空时使用 cbor 包的默认编码模式，可通过 cbor.EncOptions.EncMode 构建，如 cbor.CoreDetEncOptions 确定性编码
+	EncMode cbor.EncMode
+	// 为空时使用 cbor 包的默认解码模式
+	DecMode cbor.DecMode
+}
+
+func (c Codec) Marshal(v any) ([]byte, error) {
+	if c.EncMode == nil {
+		return cbor.Marshal(v)
+	}
+	return c.EncMode.Marshal(v)
+}
+
+func (c Codec) AppendMarshal(dst []byte, v any) ([]byte, error) {
+	w := appendWriter{b: dst}
+	var enc *cbor.Encoder
+	if c.EncMode == nil {
+		enc = cbor.NewEncoder(&w)
+	} else {
+		enc = c.EncMode.NewEncoder(&w)
+	}
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return w.b, nil
+}
+
+func (c Codec) Unmarshal(data []byte, v any) error {
+	if c.DecMode == nil {
+		return cbor.Unmarshal(data, v)
+	}
+	return c.DecMode.Unmarshal(data, v)
+}
+
+// NoRetain 解码结果中的字节串、RawMessage 均从输入复制
+func (c Codec) NoRetain() bool {
+	return true
+}
+
+// appendWriter 将编码结果追加到 b
+type appendWriter struct {
+	b []byte
+}
+
+func (w *appendWriter) Write(p []byte) (int, error) {
+	w.b = append(w.b, p...)
+	return len(p), nil
+}
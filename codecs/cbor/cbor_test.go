@@ -0,0 +1,86 @@
+package cbor
+
+import (
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	ID        int64    `json:"id"`
+	Name      string   `json:"name"`
+	Email     string   `json:"email"`
+	Tags      []string `json:"tags"`
+	Score     float64  `json:"score"`
+	CreatedAt int64    `json:"created_at"`
+}
+
+func newUser() user {
+	return user{
+		ID:        42,
+		Name:      "cachalot",
+		Email:     "cachalot@example.com",
+		Tags:      []string{"whale", "cache"},
+		Score:     99.5,
+		CreatedAt: 1700000000,
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	c := Codec{}
+	data, err := c.Marshal(newUser())
+	require.NoError(t, err)
+
+	var got user
+	require.NoError(t, c.Unmarshal(data, &got))
+	require.Equal(t, newUser(), got)
+
+	// 指针类型与 CodecDecorator[*T] 的用法一致
+	u := newUser()
+	data, err = c.Marshal(&u)
+	require.NoError(t, err)
+	var ptr *user
+	require.NoError(t, c.Unmarshal(data, &ptr))
+	require.Equal(t, &u, ptr)
+
+	require.Error(t, c.Unmarshal([]byte{0xc1}, &got))
+}
+
+func TestCodecModes(t *testing.T) {
+	enc, err := cbor.CoreDetEncOptions().EncMode()
+	require.NoError(t, err)
+	dec, err := cbor.DecOptions{DupMapKey: cbor.DupMapKeyEnforcedAPF}.DecMode()
+	require.NoError(t, err)
+	c := Codec{EncMode: enc, DecMode: dec}
+
+	// 确定性编码：map 的 key 有序，多次编码结果一致
+	m := map[string]int{"b": 2, "a": 1, "c": 3}
+	first, err := c.Marshal(m)
+	require.NoError(t, err)
+	for range 10 {
+		data, err := c.Marshal(m)
+		require.NoError(t, err)
+		require.Equal(t, first, data)
+	}
+
+	var got map[string]int
+	require.NoError(t, c.Unmarshal(first, &got))
+	require.Equal(t, m, got)
+}
+
+func TestCodecAppendMarshal(t *testing.T) {
+	enc, err := cbor.CoreDetEncOptions().EncMode()
+	require.NoError(t, err)
+	for _, c := range []Codec{{}, {EncMode: enc}} {
+		want, err := c.Marshal(newUser())
+		require.NoError(t, err)
+
+		// 结果与 Marshal 一致，且保留 dst 中已有的内容
+		data, err := c.AppendMarshal([]byte("prefix"), newUser())
+		require.NoError(t, err)
+		require.Equal(t, "prefix", string(data[:6]))
+		require.Equal(t, want, data[6:])
+	}
+}
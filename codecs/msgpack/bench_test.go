@@ -0,0 +1,39 @@
+package msgpack
+
+import (
+	"testing"
+
+	"github.com/yikakia/cachalot/core/codec"
+)
+
+func benchmarkCodec[T any](b *testing.B, c codec.Codec, v T) {
+	data, err := c.Marshal(v)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("Marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := c.Marshal(v); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(len(data)), "bytes")
+	})
+	b.Run("Unmarshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			var target T
+			if err := c.Unmarshal(data, &target); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkCodec(b *testing.B) {
+	b.Run("MessagePack", func(b *testing.B) { benchmarkCodec(b, Codec{}, newUser()) })
+	b.Run("JSON", func(b *testing.B) { benchmarkCodec(b, codec.JSONCodec{}, newUser()) })
+	b.Run("Gob", func(b *testing.B) { benchmarkCodec(b, codec.GobCodec{}, newUser()) })
+}
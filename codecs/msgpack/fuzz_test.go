@@ -0,0 +1,38 @@
+package msgpack
+
+import (
+	"math"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+func FuzzCodecRoundTrip(f *testing.F) {
+	f.Add(int64(42), "cachalot", "whale", 99.5)
+	f.Add(int64(-1), "", "", 0.0)
+	f.Fuzz(func(t *testing.T, id int64, name, tag string, score float64) {
+		if math.IsNaN(score) || !utf8.ValidString(name) || !utf8.ValidString(tag) {
+			t.Skip()
+		}
+		c := Codec{}
+		want := user{ID: id, Name: name, Tags: []string{tag}, Score: score}
+		data, err := c.Marshal(want)
+		require.NoError(t, err)
+
+		var got user
+		require.NoError(t, c.Unmarshal(data, &got))
+		require.Equal(t, want, got)
+	})
+}
+
+func FuzzCodecUnmarshal(f *testing.F) {
+	data, _ := Codec{}.Marshal(newUser())
+	f.Add(data)
+	f.Add([]byte{0xc1})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// 任意输入只允许返回错误，不允许 panic
+		var got user
+		_ = Codec{}.Unmarshal(data, &got)
+	})
+}
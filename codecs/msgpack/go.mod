@@ -0,0 +1,16 @@
+module github.com/yikakia/cachalot/codecs/msgpack
+
+go 1.25.7
+
+require (
+	github.com/stretchr/testify v1.11.1
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	github.com/yikakia/cachalot v0.0.0-20260304063019-bc71c2911b41
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
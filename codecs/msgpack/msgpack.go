@@ -0,0 +1,71 @@
+package msgpack
+
+import (
+	"bytes"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/yikakia/cachalot/core/codec"
+)
+
+var (
+	_ codec.AppendMarshaler = Codec{}
+	_ codec.NoRetain        = Codec{}
+)
+
+// Codec MessagePack 序列化，零值可用
+type Codec struct {
+	// 非空时使用该结构体标签作为字段名，如 "json"，便于复用已有的 JSON 标签
+	// 为空时使用 msgpack 标签，没有标签时使用字段名
+	StructTag string
+}
+
+func (c Codec) Marshal(v any) ([]byte, error) {
+	if c.StructTag == "" {
+		return msgpack.Marshal(v)
+	}
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag(c.StructTag)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c Codec) AppendMarshal(dst []byte, v any) ([]byte, error) {
+	w := appendWriter{b: dst}
+	enc := msgpack.GetEncoder()
+	defer msgpack.PutEncoder(enc)
+	enc.Reset(&w)
+	if c.StructTag != "" {
+		enc.SetCustomStructTag(c.StructTag)
+	}
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return w.b, nil
+}
+
+func (c Codec) Unmarshal(data []byte, v any) error {
+	if c.StructTag == "" {
+		return msgpack.Unmarshal(data, v)
+	}
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag(c.StructTag)
+	return dec.Decode(v)
+}
+
+// NoRetain 解码结果中的字符串、字节切片均从输入复制
+func (c Codec) NoRetain() bool {
+	return true
+}
+
+// appendWriter 将编码结果追加到 b
+type appendWriter struct {
+	b []byte
+}
+
+func (w *appendWriter) Write(p []byte) (int, error) {
+	w.b = append(w.b, p...)
+	return len(p), nil
+}
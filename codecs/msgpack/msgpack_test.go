@@ -0,0 +1,73 @@
+package msgpack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	ID        int64    `json:"id"`
+	Name      string   `json:"name"`
+	Email     string   `json:"email"`
+	Tags      []string `json:"tags"`
+	Score     float64  `json:"score"`
+	CreatedAt int64    `json:"created_at"`
+}
+
+func newUser() user {
+	return user{
+		ID:        42,
+		Name:      "cachalot",
+		Email:     "cachalot@example.com",
+		Tags:      []string{"whale", "cache"},
+		Score:     99.5,
+		CreatedAt: 1700000000,
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	c := Codec{}
+	data, err := c.Marshal(newUser())
+	require.NoError(t, err)
+
+	var got user
+	require.NoError(t, c.Unmarshal(data, &got))
+	require.Equal(t, newUser(), got)
+
+	// 指针类型与 CodecDecorator[*T] 的用法一致
+	u := newUser()
+	data, err = c.Marshal(&u)
+	require.NoError(t, err)
+	var ptr *user
+	require.NoError(t, c.Unmarshal(data, &ptr))
+	require.Equal(t, &u, ptr)
+
+	require.Error(t, c.Unmarshal([]byte{0xc1}, &got))
+}
+
+func TestCodecStructTag(t *testing.T) {
+	data, err := Codec{StructTag: "json"}.Marshal(newUser())
+	require.NoError(t, err)
+
+	var m map[string]any
+	require.NoError(t, Codec{}.Unmarshal(data, &m))
+	require.Contains(t, m, "created_at")
+
+	var got user
+	require.NoError(t, Codec{StructTag: "json"}.Unmarshal(data, &got))
+	require.Equal(t, newUser(), got)
+}
+
+func TestCodecAppendMarshal(t *testing.T) {
+	for _, c := range []Codec{{}, {StructTag: "json"}} {
+		want, err := c.Marshal(newUser())
+		require.NoError(t, err)
+
+		// 结果与 Marshal 一致，且保留 dst 中已有的内容
+		data, err := c.AppendMarshal([]byte("prefix"), newUser())
+		require.NoError(t, err)
+		require.Equal(t, "prefix", string(data[:6]))
+		require.Equal(t, want, data[6:])
+	}
+}
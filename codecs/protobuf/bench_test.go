@@ -0,0 +1,54 @@
+package protobuf
+
+import (
+	"testing"
+
+	"github.com/yikakia/cachalot/core/codec"
+)
+
+// benchUser 与 testpb.User 字段一致，用于 JSON/Gob 对比
+type benchUser struct {
+	ID        int64    `json:"id"`
+	Name      string   `json:"name"`
+	Email     string   `json:"email"`
+	Tags      []string `json:"tags"`
+	Score     float64  `json:"score"`
+	CreatedAt int64    `json:"created_at"`
+}
+
+func newBenchUser() benchUser {
+	u := newUser()
+	return benchUser{ID: u.Id, Name: u.Name, Email: u.Email, Tags: u.Tags, Score: u.Score, CreatedAt: u.CreatedAt}
+}
+
+func benchmarkCodec[T any](b *testing.B, c codec.Codec, v T) {
+	data, err := c.Marshal(v)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("Marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := c.Marshal(v); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(len(data)), "bytes")
+	})
+	b.Run("Unmarshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			var target T
+			if err := c.Unmarshal(data, &target); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkCodec(b *testing.B) {
+	b.Run("Protobuf", func(b *testing.B) { benchmarkCodec(b, Codec{}, newUser()) })
+	b.Run("JSON", func(b *testing.B) { benchmarkCodec(b, codec.JSONCodec{}, newBenchUser()) })
+	b.Run("Gob", func(b *testing.B) { benchmarkCodec(b, codec.GobCodec{}, newBenchUser()) })
+}
@@ -0,0 +1,4 @@
+// Package testpb 测试与基准使用的 protobuf 消息
+package testpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative user.proto
@@ -0,0 +1,71 @@
+package protobuf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/yikakia/cachalot/core/codec"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	_ codec.AppendMarshaler = Codec{}
+	_ codec.NoRetain        = Codec{}
+)
+
+// ErrNotProtoMessage 序列化的值不是 proto.Message
+var ErrNotProtoMessage = errors.New("value is not a proto.Message")
+
+var messageType = reflect.TypeFor[proto.Message]()
+
+// Codec Protocol Buffers 序列化，零值可用
+//
+// 缓存的类型参数需要是生成的消息指针，如 Cache[*pb.User]
+// 反序列化时目标既可以是 proto.Message，也可以是指向消息指针的指针（CodecDecorator 传入的 *T），
+// 后者为 nil 时会新建消息
+type Codec struct {
+	MarshalOptions   proto.MarshalOptions
+	UnmarshalOptions proto.UnmarshalOptions
+}
+
+func (c Codec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrNotProtoMessage, v)
+	}
+	return c.MarshalOptions.Marshal(m)
+}
+
+func (c Codec) AppendMarshal(dst []byte, v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrNotProtoMessage, v)
+	}
+	return c.MarshalOptions.MarshalAppend(dst, m)
+}
+
+func (c Codec) Unmarshal(data []byte, v any) error {
+	if m, ok := v.(proto.Message); ok {
+		return c.UnmarshalOptions.Unmarshal(data, m)
+	}
+
+	// *T 且 T 为消息指针
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("%w: %T", ErrNotProtoMessage, v)
+	}
+	elem := rv.Elem()
+	if elem.Kind() != reflect.Pointer || !elem.Type().Implements(messageType) {
+		return fmt.Errorf("%w: %T", ErrNotProtoMessage, v)
+	}
+	if elem.IsNil() {
+		elem.Set(reflect.New(elem.Type().Elem()))
+	}
+	return c.UnmarshalOptions.Unmarshal(data, elem.Interface().(proto.Message))
+}
+
+// NoRetain proto.Unmarshal 会复制 bytes 字段
+func (c Codec) NoRetain() bool {
+	return true
+}
@@ -0,0 +1,108 @@
+package protobuf
+
+import (
+	"context"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot/codecs/protobuf/internal/testpb"
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/stores/storetests"
+	"google.golang.org/protobuf/proto"
+)
+
+func newUser() *testpb.User {
+	return &testpb.User{
+		Id:        42,
+		Name:      "cachalot",
+		Email:     "cachalot@example.com",
+		Tags:      []string{"whale", "cache"},
+		Score:     99.5,
+		CreatedAt: 1700000000,
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	c := Codec{}
+	data, err := c.Marshal(newUser())
+	require.NoError(t, err)
+
+	// 直接传入消息
+	got := &testpb.User{Name: "stale"}
+	require.NoError(t, c.Unmarshal(data, got))
+	require.True(t, proto.Equal(newUser(), got))
+
+	// CodecDecorator 传入的 *T，T 为 nil 的消息指针
+	var target *testpb.User
+	require.NoError(t, c.Unmarshal(data, &target))
+	require.True(t, proto.Equal(newUser(), target))
+}
+
+func TestCodecAppendMarshal(t *testing.T) {
+	for _, c := range []Codec{{}, {MarshalOptions: proto.MarshalOptions{Deterministic: true}}} {
+		want, err := c.Marshal(newUser())
+		require.NoError(t, err)
+
+		// 结果与 Marshal 一致，且保留 dst 中已有的内容
+		data, err := c.AppendMarshal([]byte("prefix"), newUser())
+		require.NoError(t, err)
+		require.Equal(t, "prefix", string(data[:6]))
+		require.Equal(t, want, data[6:])
+	}
+}
+
+func TestCodecRejectsNonMessage(t *testing.T) {
+	c := Codec{}
+	_, err := c.Marshal(struct{ Name string }{"x"})
+	require.ErrorIs(t, err, ErrNotProtoMessage)
+
+	var s string
+	require.ErrorIs(t, c.Unmarshal(nil, &s), ErrNotProtoMessage)
+	require.ErrorIs(t, c.Unmarshal(nil, nil), ErrNotProtoMessage)
+}
+
+func TestCodecDecorator(t *testing.T) {
+	ctx := context.Background()
+	c := &decorator.CodecDecorator[*testpb.User]{
+		Cache: cache.NewBaseCache[[]byte](storetests.NewMemoryStore()),
+		Codec: Codec{},
+	}
+
+	require.NoError(t, c.Set(ctx, "u", newUser(), time.Minute))
+	got, err := c.Get(ctx, "u")
+	require.NoError(t, err)
+	require.True(t, proto.Equal(newUser(), got))
+}
+
+func FuzzCodecRoundTrip(f *testing.F) {
+	f.Add(int64(42), "cachalot", "whale", 99.5)
+	f.Add(int64(-1), "", "", 0.0)
+	f.Fuzz(func(t *testing.T, id int64, name, tag string, score float64) {
+		if !utf8.ValidString(name) || !utf8.ValidString(tag) {
+			// proto3 的 string 字段要求合法的 UTF-8
+			t.Skip()
+		}
+		c := Codec{}
+		want := &testpb.User{Id: id, Name: name, Tags: []string{tag}, Score: score}
+		data, err := c.Marshal(want)
+		require.NoError(t, err)
+
+		var got *testpb.User
+		require.NoError(t, c.Unmarshal(data, &got))
+		require.True(t, proto.Equal(want, got))
+	})
+}
+
+func FuzzCodecUnmarshal(f *testing.F) {
+	data, _ := Codec{}.Marshal(newUser())
+	f.Add(data)
+	f.Add([]byte{0xff})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// 任意输入只允许返回错误，不允许 panic
+		var got *testpb.User
+		_ = Codec{}.Unmarshal(data, &got)
+	})
+}
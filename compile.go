@@ -10,20 +10,18 @@ import (
 	"github.com/yikakia/cachalot/core/cache"
 	"github.com/yikakia/cachalot/core/codec"
 	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/envelope"
 	"github.com/yikakia/cachalot/core/telemetry"
 	"github.com/yikakia/cachalot/internal"
 	"github.com/yikakia/cachalot/internal/adapter"
 )
 
 func (b *Builder[T]) compileStages() {
-	if b.factoryCustomized {
-		if b.hasStagedFeaturesEnabled() {
-			b.appendErr(errors.New("WithFactory cannot be combined with staged features (codec/logic-expire/compression/type-adapter), use WithCustomPlan or disable staged features"))
-		}
+	if err := b.validateStages(); err != nil {
+		b.appendErr(err)
 		return
 	}
-
-	if b.features.logicExpire.enabled && !b.checkTTLConfigValid() {
+	if b.factoryCustomized {
 		return
 	}
 
@@ -51,7 +49,9 @@ func (b *Builder[T]) compileStages() {
 func (b *Builder[T]) hasStagedFeaturesEnabled() bool {
 	return b.features.logicExpire.enabled ||
 		b.features.codec != nil ||
+		b.features.envelope != nil ||
 		b.features.typeAdapter != nil ||
+		b.features.sizeGuard != nil ||
 		len(b.features.byteTransforms) > 0
 }
 
@@ -78,14 +78,20 @@ func (b *Builder[T]) buildLogicWireCache(store cache.Store, ob *telemetry.Observ
 		return nil, err
 	}
 
-	return b.adaptBytesToLogicWire(byteCache)
+	return b.adaptBytesToLogicWire(byteCache, ob)
 }
 
 func (b *Builder[T]) buildByteCache(store cache.Store, ob *telemetry.Observable) (cache.Cache[[]byte], error) {
 	var current cache.Cache[[]byte] = cache.NewBaseCache[[]byte](store)
 	var err error
-	for _, transform := range b.features.byteTransforms {
-		current, err = transform(current, ob)
+	if b.features.sizeGuard != nil {
+		current, err = decorator.NewSizeGuardDecorator(current, *b.features.sizeGuard, ob)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, stage := range b.features.byteTransforms {
+		current, err = stage.transform(current, ob)
 		if err != nil {
 			return nil, err
 		}
@@ -97,8 +103,11 @@ func (b *Builder[T]) adaptBytesToType(next cache.Cache[[]byte], ob *telemetry.Ob
 	if b.features.typeAdapter != nil {
 		return b.features.typeAdapter(next, ob)
 	}
+	if b.features.envelope != nil {
+		return envelope.NewDecorator[T](next, *b.features.envelope, ob)
+	}
 	if b.features.codec != nil {
-		return newCodecDecorator[T](next, b.features.codec), nil
+		return newCodecDecorator[T](next, b.features.codec, ob), nil
 	}
 	if internal.IsBytesType[T]() {
 		return newBytesPassThroughCache[T](next), nil
@@ -106,9 +115,12 @@ func (b *Builder[T]) adaptBytesToType(next cache.Cache[[]byte], ob *telemetry.Ob
 	return nil, fmt.Errorf("byte-stage enabled but no adapter configured for type %s: configure WithCodec or WithTypeAdapter", reflect.TypeFor[T]().String())
 }
 
-func (b *Builder[T]) adaptBytesToLogicWire(next cache.Cache[[]byte]) (cache.Cache[decorator.LogicTTLValue[T]], error) {
+func (b *Builder[T]) adaptBytesToLogicWire(next cache.Cache[[]byte], ob *telemetry.Observable) (cache.Cache[decorator.LogicTTLValue[T]], error) {
+	if b.features.envelope != nil {
+		return envelope.NewDecorator[decorator.LogicTTLValue[T]](next, *b.features.envelope, ob)
+	}
 	if b.features.codec != nil {
-		return newCodecDecorator[decorator.LogicTTLValue[T]](next, b.features.codec), nil
+		return newCodecDecorator[decorator.LogicTTLValue[T]](next, b.features.codec, ob), nil
 	}
 	// 内置支持 T=[]byte 的逻辑过期 wire 适配，避免强制依赖 codec。
 	if internal.IsBytesType[T]() {
@@ -128,23 +140,46 @@ func (b *Builder[T]) requiresBytePath() bool {
 	if b.features.codec != nil {
 		return true
 	}
+	if b.features.envelope != nil {
+		return true
+	}
 	if b.features.typeAdapter != nil {
 		return true
 	}
+	if b.features.sizeGuard != nil {
+		return true
+	}
 	return false
 }
 
-func (b *Builder[T]) checkTTLConfigValid() bool {
+// validateStages 校验装配计划之间的冲突，Build 与 Explain 共用
+func (b *Builder[T]) validateStages() error {
+	if b.factoryCustomized {
+		if b.hasStagedFeaturesEnabled() {
+			return errors.New("WithFactory cannot be combined with staged features (codec/envelope/logic-expire/compression/type-adapter/size-guard), use WithCustomPlan or disable staged features")
+		}
+		return nil
+	}
+
+	if b.features.envelope != nil && (b.features.codec != nil || b.features.typeAdapter != nil) {
+		return errors.New("WithEnvelope cannot be combined with WithCodec or WithTypeAdapter, the envelope already carries the codec")
+	}
+
+	if b.features.logicExpire.enabled {
+		return b.checkTTLConfigValid()
+	}
+	return nil
+}
+
+func (b *Builder[T]) checkTTLConfigValid() error {
 	if ttl := b.features.logicExpire.defaultLogicTTL; ttl < 0 {
-		b.appendErr(fmt.Errorf("logicExpire.defaultLogicTTL require >= 0 but got:%v", ttl))
-		return false
+		return fmt.Errorf("logicExpire.defaultLogicTTL require >= 0 but got:%v", ttl)
 	}
 	if ttl := b.features.logicExpire.defaultWriteBackTTL; ttl < 0 {
-		b.appendErr(fmt.Errorf("logicExpire.defaultWriteBackTTL require >= 0 but got:%v", ttl))
-		return false
+		return fmt.Errorf("logicExpire.defaultWriteBackTTL require >= 0 but got:%v", ttl)
 	}
 
-	return true
+	return nil
 }
 
 func (b *Builder[T]) buildTTLConfig(next cache.Cache[decorator.LogicTTLValue[T]], ob *telemetry.Observable) decorator.LogicTTLDecoratorConfig[T] {
@@ -164,10 +199,11 @@ func (b *Builder[T]) buildTTLConfig(next cache.Cache[decorator.LogicTTLValue[T]]
 	return d
 }
 
-func newCodecDecorator[T any](next cache.Cache[[]byte], codec codec.Codec) *decorator.CodecDecorator[T] {
+func newCodecDecorator[T any](next cache.Cache[[]byte], codec codec.Codec, ob *telemetry.Observable) *decorator.CodecDecorator[T] {
 	return &decorator.CodecDecorator[T]{
-		Cache: next,
-		Codec: codec,
+		Cache:    next,
+		Codec:    codec,
+		Observer: ob,
 	}
 }
 
@@ -0,0 +1,17 @@
+module github.com/yikakia/cachalot/compressions/lz4
+
+go 1.25.7
+
+require (
+	github.com/pierrec/lz4/v4 v4.1.22
+	github.com/stretchr/testify v1.11.1
+	github.com/yikakia/cachalot v0.0.0-20260304063019-bc71c2911b41
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/sourcegraph/conc v0.3.1-0.20240121214520-5f936abd7ae8 // indirect
+	golang.org/x/sync v0.19.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
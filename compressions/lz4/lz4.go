@@ -0,0 +1,169 @@
+package lz4
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/pierrec/lz4/v4"
+	"github.com/yikakia/cachalot/core/decorator"
+)
+
+var _ decorator.CompressionCodec = (*Compression)(nil)
+var _ decorator.CompressionAppender = (*Compression)(nil)
+
+// 格式：| mode | uvarint 原始长度 | 数据 |
+// 不可压缩的数据原样存储，避免压缩后反而变大
+const (
+	modeRaw byte = 0
+	modeLZ4 byte = 1
+)
+
+var errCorrupted = errors.New("lz4: corrupted input")
+
+// Compression 基于 pierrec/lz4 块格式的实现，可并发使用
+//
+// 压缩器与临时缓冲区通过 sync.Pool 复用，每次压缩只为结果分配一次内存
+type Compression struct {
+	level          lz4.CompressionLevel
+	maxDecodedSize int
+	pool           sync.Pool
+}
+
+type compressor struct {
+	fast lz4.Compressor
+	hc   lz4.CompressorHC
+	buf  []byte
+}
+
+type config struct {
+	level          lz4.CompressionLevel
+	maxDecodedSize int
+}
+
+type Option func(*config)
+
+// WithLevel 压缩等级，默认 lz4.Fast；高于 lz4.Fast 时使用 HC 压缩，压缩率更高但更慢
+func WithLevel(level lz4.CompressionLevel) Option {
+	return func(c *config) {
+		c.level = level
+	}
+}
+
+// WithMaxDecodedSize 解压后的最大长度，超过时返回错误，默认 64MB
+func WithMaxDecodedSize(n int) Option {
+	return func(c *config) {
+		c.maxDecodedSize = n
+	}
+}
+
+func New(opts ...Option) *Compression {
+	cfg := &config{
+		level:          lz4.Fast,
+		maxDecodedSize: 64 << 20,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	c := &Compression{
+		level:          cfg.level,
+		maxDecodedSize: cfg.maxDecodedSize,
+	}
+	c.pool.New = func() any {
+		return &compressor{hc: lz4.CompressorHC{Level: cfg.level}}
+	}
+	return c
+}
+
+func (c *Compression) Compress(src []byte) ([]byte, error) {
+	zc := c.pool.Get().(*compressor)
+	defer c.pool.Put(zc)
+
+	bound := lz4.CompressBlockBound(len(src))
+	if cap(zc.buf) < bound {
+		zc.buf = make([]byte, bound)
+	}
+	n, err := c.compressBlock(zc, src, zc.buf[:bound])
+	if err != nil {
+		return nil, err
+	}
+
+	mode, payload := modeLZ4, zc.buf[:n]
+	if n == 0 || n >= len(src) {
+		mode, payload = modeRaw, src
+	}
+	out := make([]byte, 1, 1+binary.MaxVarintLen64+len(payload))
+	out[0] = mode
+	out = binary.AppendUvarint(out, uint64(len(src)))
+	return append(out, payload...), nil
+}
+
+// AppendCompress 直接压缩到 dst 的剩余空间，不经过临时缓冲区
+func (c *Compression) AppendCompress(dst, src []byte) ([]byte, error) {
+	zc := c.pool.Get().(*compressor)
+	defer c.pool.Put(zc)
+
+	start := len(dst)
+	dst = append(dst, modeLZ4)
+	dst = binary.AppendUvarint(dst, uint64(len(src)))
+	header := len(dst)
+	bound := lz4.CompressBlockBound(len(src))
+	dst = slices.Grow(dst, bound)
+	n, err := c.compressBlock(zc, src, dst[header:header+bound])
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 || n >= len(src) {
+		dst[start] = modeRaw
+		return append(dst[:header], src...), nil
+	}
+	return dst[:header+n], nil
+}
+
+func (c *Compression) compressBlock(zc *compressor, src, dst []byte) (int, error) {
+	if c.level == lz4.Fast {
+		return zc.fast.CompressBlock(src, dst)
+	}
+	return zc.hc.CompressBlock(src, dst)
+}
+
+func (c *Compression) Decompress(src []byte) ([]byte, error) {
+	return c.DecompressTo(nil, src)
+}
+
+func (c *Compression) DecompressTo(dst, src []byte) ([]byte, error) {
+	if len(src) < 2 {
+		return nil, errCorrupted
+	}
+	size, n := binary.Uvarint(src[1:])
+	if n <= 0 {
+		return nil, errCorrupted
+	}
+	if size > uint64(c.maxDecodedSize) {
+		return nil, fmt.Errorf("lz4: decoded size %d exceeds limit %d", size, c.maxDecodedSize)
+	}
+	payload := src[1+n:]
+
+	switch src[0] {
+	case modeRaw:
+		if uint64(len(payload)) != size {
+			return nil, errCorrupted
+		}
+		return append(dst, payload...), nil
+	case modeLZ4:
+		start := len(dst)
+		dst = slices.Grow(dst, int(size))
+		got, err := lz4.UncompressBlock(payload, dst[start:start+int(size)])
+		if err != nil {
+			return nil, err
+		}
+		if uint64(got) != size {
+			return nil, errCorrupted
+		}
+		return dst[:start+got], nil
+	default:
+		return nil, errCorrupted
+	}
+}
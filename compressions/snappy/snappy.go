@@ -0,0 +1,66 @@
+package snappy
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/golang/snappy"
+	"github.com/yikakia/cachalot/core/decorator"
+)
+
+var _ decorator.CompressionCodec = Compression{}
+var _ decorator.CompressionAppender = Compression{}
+
+// Compression 基于 golang/snappy 块格式的实现，零值可用，可并发使用
+//
+// 块格式的编解码没有需要复用的状态，每次调用只为结果分配一次内存
+type Compression struct {
+	// 解压后的最大长度，超过时返回错误，默认 64MB
+	MaxDecodedSize int
+}
+
+func (c Compression) Compress(src []byte) ([]byte, error) {
+	return snappy.Encode(nil, src), nil
+}
+
+func (c Compression) Decompress(src []byte) ([]byte, error) {
+	if _, err := c.decodedLen(src); err != nil {
+		return nil, err
+	}
+	return snappy.Decode(nil, src)
+}
+
+func (c Compression) AppendCompress(dst, src []byte) ([]byte, error) {
+	n := len(dst)
+	dst = slices.Grow(dst, snappy.MaxEncodedLen(len(src)))
+	encoded := snappy.Encode(dst[n:cap(dst)], src)
+	return dst[:n+len(encoded)], nil
+}
+
+func (c Compression) DecompressTo(dst, src []byte) ([]byte, error) {
+	size, err := c.decodedLen(src)
+	if err != nil {
+		return nil, err
+	}
+	n := len(dst)
+	dst = slices.Grow(dst, size)
+	if _, err := snappy.Decode(dst[n:n+size], src); err != nil {
+		return nil, err
+	}
+	return dst[:n+size], nil
+}
+
+func (c Compression) decodedLen(src []byte) (int, error) {
+	n, err := snappy.DecodedLen(src)
+	if err != nil {
+		return 0, err
+	}
+	limit := c.MaxDecodedSize
+	if limit <= 0 {
+		limit = 64 << 20
+	}
+	if n > limit {
+		return 0, fmt.Errorf("snappy: decoded size %d exceeds limit %d", n, limit)
+	}
+	return n, nil
+}
@@ -0,0 +1,85 @@
+package snappy
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot"
+)
+
+var _ cachalot.Compression = Compression{}
+
+func TestCompressionRoundTrip(t *testing.T) {
+	c := Compression{}
+	random := make([]byte, 4096)
+	for i := range random {
+		random[i] = byte(rand.IntN(256))
+	}
+
+	for _, src := range [][]byte{nil, []byte("a"), random, bytes.Repeat([]byte("cachalot"), 1000)} {
+		compressed, err := c.Compress(src)
+		require.NoError(t, err)
+		got, err := c.Decompress(compressed)
+		require.NoError(t, err)
+		require.Equal(t, len(src), len(got))
+		require.True(t, bytes.Equal(src, got))
+	}
+
+	compressed, err := c.Compress(bytes.Repeat([]byte("cachalot"), 1000))
+	require.NoError(t, err)
+	require.Less(t, len(compressed), 1000)
+
+	_, err = c.Decompress([]byte{0xff, 0xff, 0xff})
+	require.Error(t, err)
+}
+
+func TestAppendCompress(t *testing.T) {
+	c := Compression{}
+	random := make([]byte, 4096)
+	for i := range random {
+		random[i] = byte(rand.IntN(256))
+	}
+
+	prefix := []byte("prefix")
+	for _, src := range [][]byte{nil, []byte("a"), random, bytes.Repeat([]byte("cachalot"), 1000)} {
+		compressed, err := c.AppendCompress(bytes.Clone(prefix), src)
+		require.NoError(t, err)
+		require.Equal(t, prefix, compressed[:len(prefix)])
+
+		got, err := c.DecompressTo(bytes.Clone(prefix), compressed[len(prefix):])
+		require.NoError(t, err)
+		require.Equal(t, prefix, got[:len(prefix)])
+		require.True(t, bytes.Equal(src, got[len(prefix):]))
+	}
+}
+
+func TestCompressionConcurrent(t *testing.T) {
+	c := Compression{}
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 50 {
+				src := fmt.Appendf(nil, `{"id":%d,"name":"user-%d","roles":["reader","writer","reader","writer"]}`, i, j)
+				compressed, err := c.Compress(src)
+				require.NoError(t, err)
+				got, err := c.Decompress(compressed)
+				require.NoError(t, err)
+				require.Equal(t, src, got)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMaxDecodedSize(t *testing.T) {
+	compressed, err := Compression{}.Compress(bytes.Repeat([]byte("a"), 4096))
+	require.NoError(t, err)
+	_, err = Compression{MaxDecodedSize: 1024}.Decompress(compressed)
+	require.ErrorContains(t, err, "exceeds limit")
+}
@@ -0,0 +1,88 @@
+// zstd-dict 从样本文件训练 zstd 字典
+//
+//	go run github.com/yikakia/cachalot/compressions/zstd/cmd/zstd-dict -o user.dict samples/
+//
+// 参数为文件或目录，目录下的每个文件作为一个样本；-lines 时按行切分样本，适用于每行一个缓存值的导出文件
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/yikakia/cachalot/compressions/zstd"
+)
+
+func main() {
+	out := flag.String("o", "zstd.dict", "output dictionary file")
+	size := flag.Int("size", 64<<10, "max dictionary size in bytes")
+	id := flag.Uint("id", 0, "dictionary id, random if zero")
+	lines := flag.Bool("lines", false, "treat each line of the input files as a sample")
+	verbose := flag.Bool("v", false, "print training details")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: zstd-dict [flags] <file|dir>...")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	samples, err := readSamples(flag.Args(), *lines)
+	if err != nil {
+		fail(err)
+	}
+
+	opts := zstd.TrainOptions{MaxSize: *size, ID: uint32(*id)}
+	if *verbose {
+		opts.Debug = os.Stderr
+	}
+	dict, err := zstd.TrainDictionary(samples, opts)
+	if err != nil {
+		fail(err)
+	}
+	if err := os.WriteFile(*out, dict, 0o644); err != nil {
+		fail(err)
+	}
+	fmt.Printf("trained %d bytes dictionary from %d samples: %s\n", len(dict), len(samples), *out)
+}
+
+func readSamples(paths []string, lines bool) ([][]byte, error) {
+	var samples [][]byte
+	add := func(data []byte) {
+		if !lines {
+			samples = append(samples, data)
+			return
+		}
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			if len(line) > 0 {
+				samples = append(samples, line)
+			}
+		}
+	}
+
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			add(data)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return samples, nil
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "zstd-dict:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,33 @@
+package zstd
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/dict"
+)
+
+// TrainOptions 字典训练参数
+type TrainOptions struct {
+	// 字典的最大长度，默认 64KB
+	MaxSize int
+	// 字典 ID，为 0 时随机生成
+	ID uint32
+	// 非空时输出训练过程的调试信息
+	Debug io.Writer
+}
+
+// TrainDictionary 从缓存值的样本中训练 zstd 字典
+//
+// 样本应来自实际写入缓存的编码结果（压缩前），数量越多、越有代表性，小值的压缩率提升越明显。
+// 训练出的字典需要持久化，并在所有实例上通过 WithDictionary 加载
+func TrainDictionary(samples [][]byte, opts TrainOptions) ([]byte, error) {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 64 << 10
+	}
+	return dict.BuildZstdDict(samples, dict.Options{
+		MaxDictSize: opts.MaxSize,
+		HashBytes:   6,
+		Output:      opts.Debug,
+		ZstdDictID:  opts.ID,
+	})
+}
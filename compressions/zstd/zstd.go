@@ -0,0 +1,124 @@
+package zstd
+
+import (
+	"github.com/klauspost/compress/zstd"
+	"github.com/yikakia/cachalot/core/decorator"
+)
+
+var _ decorator.CompressionCodec = (*Compression)(nil)
+var _ decorator.CompressionAppender = (*Compression)(nil)
+
+// Compression 基于 klauspost/compress 的 zstd 实现，可并发使用
+//
+// 内部持有一个 Encoder 与一个 Decoder，EncodeAll / DecodeAll 会从其内部的状态池中取用编解码器，
+// 不会为每次调用新建 writer。不再使用时调用 Close 释放
+type Compression struct {
+	enc *zstd.Encoder
+	dec *zstd.Decoder
+}
+
+type config struct {
+	level          zstd.EncoderLevel
+	dict           []byte
+	decoderDicts   [][]byte
+	concurrency    int
+	maxDecodedSize uint64
+}
+
+type Option func(*config)
+
+// WithLevel 压缩等级，默认 zstd.SpeedDefault
+func WithLevel(level zstd.EncoderLevel) Option {
+	return func(c *config) {
+		c.level = level
+	}
+}
+
+// WithDictionary 使用训练好的字典压缩与解压，字典通常在启动时从文件加载
+//
+// 字典 ID 会写入每个压缩帧，更换字典时通过 WithDecoderDictionaries 保留旧字典即可继续读取旧数据
+func WithDictionary(dict []byte) Option {
+	return func(c *config) {
+		c.dict = dict
+	}
+}
+
+// WithDecoderDictionaries 仅用于解压的额外字典，用于字典轮换
+func WithDecoderDictionaries(dicts ...[]byte) Option {
+	return func(c *config) {
+		c.decoderDicts = append(c.decoderDicts, dicts...)
+	}
+}
+
+// WithConcurrency 编解码器池的大小，默认为 GOMAXPROCS
+func WithConcurrency(n int) Option {
+	return func(c *config) {
+		c.concurrency = n
+	}
+}
+
+// WithMaxDecodedSize 解压后的最大长度，超过时返回错误，默认 64MB
+func WithMaxDecodedSize(n uint64) Option {
+	return func(c *config) {
+		c.maxDecodedSize = n
+	}
+}
+
+func New(opts ...Option) (*Compression, error) {
+	cfg := &config{
+		level:          zstd.SpeedDefault,
+		maxDecodedSize: 64 << 20,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	encOpts := []zstd.EOption{zstd.WithEncoderLevel(cfg.level)}
+	decOpts := []zstd.DOption{zstd.WithDecoderMaxMemory(cfg.maxDecodedSize)}
+	if cfg.concurrency > 0 {
+		encOpts = append(encOpts, zstd.WithEncoderConcurrency(cfg.concurrency))
+		decOpts = append(decOpts, zstd.WithDecoderConcurrency(cfg.concurrency))
+	} else {
+		decOpts = append(decOpts, zstd.WithDecoderConcurrency(0))
+	}
+	if cfg.dict != nil {
+		encOpts = append(encOpts, zstd.WithEncoderDict(cfg.dict))
+		decOpts = append(decOpts, zstd.WithDecoderDicts(cfg.dict))
+	}
+	if len(cfg.decoderDicts) > 0 {
+		decOpts = append(decOpts, zstd.WithDecoderDicts(cfg.decoderDicts...))
+	}
+
+	enc, err := zstd.NewWriter(nil, encOpts...)
+	if err != nil {
+		return nil, err
+	}
+	dec, err := zstd.NewReader(nil, decOpts...)
+	if err != nil {
+		_ = enc.Close()
+		return nil, err
+	}
+	return &Compression{enc: enc, dec: dec}, nil
+}
+
+func (c *Compression) Compress(src []byte) ([]byte, error) {
+	return c.enc.EncodeAll(src, make([]byte, 0, len(src)/2)), nil
+}
+
+func (c *Compression) Decompress(src []byte) ([]byte, error) {
+	return c.dec.DecodeAll(src, nil)
+}
+
+func (c *Compression) AppendCompress(dst, src []byte) ([]byte, error) {
+	return c.enc.EncodeAll(src, dst), nil
+}
+
+func (c *Compression) DecompressTo(dst, src []byte) ([]byte, error) {
+	return c.dec.DecodeAll(src, dst)
+}
+
+// Close 释放编解码器，之后不能再使用
+func (c *Compression) Close() error {
+	c.dec.Close()
+	return c.enc.Close()
+}
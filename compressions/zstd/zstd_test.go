@@ -0,0 +1,129 @@
+package zstd
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot"
+)
+
+var _ cachalot.Compression = (*Compression)(nil)
+
+func sampleValue(i int) []byte {
+	return fmt.Appendf(nil, `{"id":%d,"name":"user-%d","email":"user-%d@example.com","status":"active","roles":["reader","writer"],"region":"ap-southeast-1"}`, i, i, i)
+}
+
+func TestCompressionRoundTrip(t *testing.T) {
+	c, err := New()
+	require.NoError(t, err)
+	defer c.Close()
+
+	for _, src := range [][]byte{nil, []byte("a"), bytes.Repeat([]byte("cachalot"), 1000), sampleValue(1)} {
+		compressed, err := c.Compress(src)
+		require.NoError(t, err)
+		got, err := c.Decompress(compressed)
+		require.NoError(t, err)
+		require.Equal(t, len(src), len(got))
+		require.True(t, bytes.Equal(src, got))
+	}
+
+	_, err = c.Decompress([]byte("not zstd"))
+	require.Error(t, err)
+}
+
+func TestAppendCompress(t *testing.T) {
+	c, err := New()
+	require.NoError(t, err)
+	defer c.Close()
+
+	prefix := []byte("prefix")
+	for _, src := range [][]byte{nil, []byte("a"), sampleValue(1), bytes.Repeat([]byte("cachalot"), 1000)} {
+		compressed, err := c.AppendCompress(bytes.Clone(prefix), src)
+		require.NoError(t, err)
+		require.Equal(t, prefix, compressed[:len(prefix)])
+
+		got, err := c.DecompressTo(bytes.Clone(prefix), compressed[len(prefix):])
+		require.NoError(t, err)
+		require.Equal(t, prefix, got[:len(prefix)])
+		require.True(t, bytes.Equal(src, got[len(prefix):]))
+	}
+}
+
+func TestCompressionConcurrent(t *testing.T) {
+	c, err := New(WithConcurrency(2))
+	require.NoError(t, err)
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 50 {
+				src := sampleValue(i*100 + j)
+				compressed, err := c.Compress(src)
+				require.NoError(t, err)
+				got, err := c.Decompress(compressed)
+				require.NoError(t, err)
+				require.Equal(t, src, got)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDictionary(t *testing.T) {
+	samples := make([][]byte, 0, 200)
+	for i := range 200 {
+		samples = append(samples, sampleValue(i))
+	}
+	dict, err := TrainDictionary(samples, TrainOptions{MaxSize: 4 << 10, ID: 42})
+	require.NoError(t, err)
+	require.NotEmpty(t, dict)
+
+	plain, err := New()
+	require.NoError(t, err)
+	defer plain.Close()
+	withDict, err := New(WithDictionary(dict))
+	require.NoError(t, err)
+	defer withDict.Close()
+
+	src := sampleValue(5000)
+	small, err := withDict.Compress(src)
+	require.NoError(t, err)
+	large, err := plain.Compress(src)
+	require.NoError(t, err)
+	require.Less(t, len(small), len(large))
+
+	got, err := withDict.Decompress(small)
+	require.NoError(t, err)
+	require.Equal(t, src, got)
+
+	// 没有加载字典无法解压
+	_, err = plain.Decompress(small)
+	require.Error(t, err)
+
+	// 字典轮换：新字典写入，旧字典仍可读
+	newDict, err := TrainDictionary(samples[:100], TrainOptions{MaxSize: 4 << 10, ID: 43})
+	require.NoError(t, err)
+	rotated, err := New(WithDictionary(newDict), WithDecoderDictionaries(dict))
+	require.NoError(t, err)
+	defer rotated.Close()
+	got, err = rotated.Decompress(small)
+	require.NoError(t, err)
+	require.Equal(t, src, got)
+}
+
+func TestMaxDecodedSize(t *testing.T) {
+	c, err := New(WithMaxDecodedSize(1 << 10))
+	require.NoError(t, err)
+	defer c.Close()
+
+	compressed, err := c.Compress(bytes.Repeat([]byte("a"), 1<<20))
+	require.NoError(t, err)
+	_, err = c.Decompress(compressed)
+	require.Error(t, err)
+}
@@ -0,0 +1,291 @@
+// Package config 从 YAML / JSON 文档声明式地构建缓存
+//
+// 文档描述具名的缓存与多级缓存，Store、回源函数、编解码与压缩等无法序列化的依赖通过 Registry 按名称引用
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document 配置文档的根节点
+type Document struct {
+	// 单级缓存，key 为缓存名
+	Caches map[string]CacheConfig `json:"caches" yaml:"caches"`
+	// 多级缓存，key 为缓存名，不能与 Caches 重名
+	Multi map[string]MultiConfig `json:"multi" yaml:"multi"`
+}
+
+// CacheConfig 对应一个 cachalot.Builder
+type CacheConfig struct {
+	// Registry 中注册的 Store 名称，必填
+	Store string `json:"store" yaml:"store"`
+	// Registry 中注册的编解码名称，内置 json、gob
+	Codec string `json:"codec" yaml:"codec"`
+	// Registry 中注册的压缩名称，内置 gzip、zlib、flate、lzw
+	Compression string `json:"compression" yaml:"compression"`
+	// 为空时开启
+	Singleflight *bool `json:"singleflight" yaml:"singleflight"`
+	Stats        bool  `json:"stats" yaml:"stats"`
+
+	LogicExpire *LogicExpireConfig `json:"logic_expire" yaml:"logic_expire"`
+	MissLoader  *MissLoaderConfig  `json:"miss_loader" yaml:"miss_loader"`
+	NilCache    *NilCacheConfig    `json:"nil_cache" yaml:"nil_cache"`
+}
+
+// LogicExpireConfig 逻辑过期配置，为 0 的字段使用 Builder 的默认值
+type LogicExpireConfig struct {
+	TTL          Duration `json:"ttl" yaml:"ttl"`
+	WriteBackTTL Duration `json:"write_back_ttl" yaml:"write_back_ttl"`
+	// Registry 中注册的回源函数名称，可选
+	Loader string `json:"loader" yaml:"loader"`
+}
+
+// MissLoaderConfig 未命中回源配置，为 0 的字段使用 Builder 的默认值
+type MissLoaderConfig struct {
+	// Registry 中注册的回源函数名称，必填
+	Loader       string   `json:"loader" yaml:"loader"`
+	WriteBackTTL Duration `json:"write_back_ttl" yaml:"write_back_ttl"`
+	NegativeTTL  Duration `json:"negative_ttl" yaml:"negative_ttl"`
+}
+
+// NilCacheConfig 防缓存击穿配置，为 0 的字段使用 Builder 的默认值
+type NilCacheConfig struct {
+	// Registry 中注册的防护函数名称，必填
+	Fn           string   `json:"fn" yaml:"fn"`
+	WriteBackTTL Duration `json:"write_back_ttl" yaml:"write_back_ttl"`
+}
+
+// MultiConfig 对应一个 cachalot.MultiBuilder
+type MultiConfig struct {
+	// Caches 中的缓存名，优先级从前到后，必填
+	Tiers []string `json:"tiers" yaml:"tiers"`
+	// Registry 中注册的回源函数名称，必填
+	Loader string `json:"loader" yaml:"loader"`
+	// 回源后写回各级缓存的 TTL，为 0 时使用 MultiBuilder 的默认值
+	WriteBackTTL Duration `json:"write_back_ttl" yaml:"write_back_ttl"`
+	// tolerant 或 strict，为空时为 tolerant
+	ErrorHandling string `json:"error_handling" yaml:"error_handling"`
+	// 为空时开启
+	Singleflight *bool               `json:"singleflight" yaml:"singleflight"`
+	Stats        bool                `json:"stats" yaml:"stats"`
+	StaleIfError *StaleIfErrorConfig `json:"stale_if_error" yaml:"stale_if_error"`
+}
+
+// StaleIfErrorConfig 回源失败时返回最近一次读到的值
+type StaleIfErrorConfig struct {
+	Grace      Duration `json:"grace" yaml:"grace"`
+	MaxEntries int      `json:"max_entries" yaml:"max_entries"`
+}
+
+// Duration 以 time.ParseDuration 的格式书写，如 "10m"、"1h30m"
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"10m\": %w", err)
+	}
+	return d.parse(s)
+}
+
+func (d Duration) MarshalYAML() (any, error) {
+	return d.String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return fmt.Errorf("duration must be a string like \"10m\": %w", err)
+	}
+	return d.parse(s)
+}
+
+func (d *Duration) parse(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// ParseYAML 解析 YAML 文档，未知字段视为错误
+func ParseYAML(data []byte) (*Document, error) {
+	var doc Document
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("config: parse yaml: %w", err)
+	}
+	return &doc, nil
+}
+
+// ParseJSON 解析 JSON 文档，未知字段视为错误
+func ParseJSON(data []byte) (*Document, error) {
+	var doc Document
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("config: parse json: %w", err)
+	}
+	return &doc, nil
+}
+
+// Load 读取文件，按扩展名 .json / .yaml / .yml 选择解析方式
+func Load(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		return ParseJSON(data)
+	case ".yaml", ".yml":
+		return ParseYAML(data)
+	default:
+		return nil, fmt.Errorf("config: unsupported file extension %q", ext)
+	}
+}
+
+// Validate 校验文档本身以及对 Registry 的引用，返回所有错误，每条错误带有字段路径
+//
+// 回源函数的值类型在 Cache / MultiCache 构建时才能确定，届时再校验
+func (d *Document) Validate(r *Registry) error {
+	var errs []error
+	fail := func(path, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+	}
+	if len(d.Caches) == 0 && len(d.Multi) == 0 {
+		errs = append(errs, errors.New("no caches defined"))
+	}
+
+	for _, name := range sortedKeys(d.Caches) {
+		c := d.Caches[name]
+		path := "caches." + name
+		if c.Store == "" {
+			fail(path+".store", "required")
+		} else if _, ok := r.stores[c.Store]; !ok {
+			fail(path+".store", "unknown store %q", c.Store)
+		}
+		if c.Codec != "" {
+			if _, ok := r.codecs[c.Codec]; !ok {
+				fail(path+".codec", "unknown codec %q", c.Codec)
+			}
+		}
+		if c.Compression != "" {
+			if _, ok := r.compressions[c.Compression]; !ok {
+				fail(path+".compression", "unknown compression %q", c.Compression)
+			}
+		}
+		if le := c.LogicExpire; le != nil {
+			checkDuration(fail, path+".logic_expire.ttl", le.TTL)
+			checkDuration(fail, path+".logic_expire.write_back_ttl", le.WriteBackTTL)
+			if le.Loader != "" {
+				checkLoader(fail, r, path+".logic_expire.loader", le.Loader)
+			}
+		}
+		if ml := c.MissLoader; ml != nil {
+			if ml.Loader == "" {
+				fail(path+".miss_loader.loader", "required")
+			} else {
+				checkLoader(fail, r, path+".miss_loader.loader", ml.Loader)
+			}
+			checkDuration(fail, path+".miss_loader.write_back_ttl", ml.WriteBackTTL)
+			checkDuration(fail, path+".miss_loader.negative_ttl", ml.NegativeTTL)
+		}
+		if nc := c.NilCache; nc != nil {
+			if nc.Fn == "" {
+				fail(path+".nil_cache.fn", "required")
+			} else if _, ok := r.nilCacheFns[nc.Fn]; !ok {
+				fail(path+".nil_cache.fn", "unknown nil cache fn %q", nc.Fn)
+			}
+			checkDuration(fail, path+".nil_cache.write_back_ttl", nc.WriteBackTTL)
+		}
+	}
+
+	for _, name := range sortedKeys(d.Multi) {
+		m := d.Multi[name]
+		path := "multi." + name
+		if _, ok := d.Caches[name]; ok {
+			fail(path, "name conflicts with caches.%s", name)
+		}
+		if len(m.Tiers) == 0 {
+			fail(path+".tiers", "required")
+		}
+		seen := map[string]bool{}
+		for i, tier := range m.Tiers {
+			tierPath := fmt.Sprintf("%s.tiers[%d]", path, i)
+			if _, ok := d.Caches[tier]; !ok {
+				fail(tierPath, "unknown cache %q", tier)
+			}
+			if seen[tier] {
+				fail(tierPath, "duplicate cache %q", tier)
+			}
+			seen[tier] = true
+		}
+		if m.Loader == "" {
+			fail(path+".loader", "required")
+		} else {
+			checkLoader(fail, r, path+".loader", m.Loader)
+		}
+		checkDuration(fail, path+".write_back_ttl", m.WriteBackTTL)
+		switch m.ErrorHandling {
+		case "", errorHandlingTolerant, errorHandlingStrict:
+		default:
+			fail(path+".error_handling", "must be %q or %q, but got %q", errorHandlingTolerant, errorHandlingStrict, m.ErrorHandling)
+		}
+		if s := m.StaleIfError; s != nil {
+			if s.Grace <= 0 {
+				fail(path+".stale_if_error.grace", "require > 0, but got %s", s.Grace)
+			}
+			if s.MaxEntries < 0 {
+				fail(path+".stale_if_error.max_entries", "require >= 0, but got %d", s.MaxEntries)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+const (
+	errorHandlingTolerant = "tolerant"
+	errorHandlingStrict   = "strict"
+)
+
+func checkDuration(fail func(path, format string, args ...any), path string, d Duration) {
+	if d < 0 {
+		fail(path, "require >= 0, but got %s", d)
+	}
+}
+
+func checkLoader(fail func(path, format string, args ...any), r *Registry, path, name string) {
+	if _, ok := r.loaders[name]; !ok {
+		fail(path, "unknown loader %q", name)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
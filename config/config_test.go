@@ -0,0 +1,179 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot"
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/stores/storetests"
+)
+
+type user struct {
+	Name string `json:"name"`
+}
+
+const document = `
+caches:
+  user-local:
+    store: local
+    singleflight: false
+    miss_loader:
+      loader: user
+      write_back_ttl: 30s
+  user-remote:
+    store: remote
+    codec: json
+    compression: gzip
+    stats: true
+    logic_expire:
+      ttl: 5m
+      write_back_ttl: 1h
+      loader: user
+    nil_cache:
+      fn: empty-user
+      write_back_ttl: 1m
+multi:
+  user:
+    tiers: [user-local, user-remote]
+    loader: user
+    write_back_ttl: 2m
+    error_handling: strict
+    stats: true
+    stale_if_error:
+      grace: 1m
+`
+
+func newRegistry(local, remote cache.Store, loads *int) *Registry {
+	r := NewRegistry().
+		RegisterStore("local", local).
+		RegisterStore("remote", remote)
+	RegisterLoader(r, "user", func(ctx context.Context, key string, opts ...cache.CallOption) (user, error) {
+		*loads++
+		return user{Name: key}, nil
+	})
+	RegisterNilCacheFn(r, "empty-user", func(key string) user { return user{} })
+	return r
+}
+
+func TestFactory(t *testing.T) {
+	ctx := context.Background()
+	doc, err := ParseYAML([]byte(document))
+	require.NoError(t, err)
+	require.Equal(t, Duration(5*time.Minute), doc.Caches["user-remote"].LogicExpire.TTL)
+
+	local, remote := storetests.NewMemoryStore(), storetests.NewMemoryStore()
+	loads := 0
+	f, err := New(doc, newRegistry(local, remote, &loads))
+	require.NoError(t, err)
+
+	c, err := Cache[user](f, "user-remote")
+	require.NoError(t, err)
+	require.NoError(t, c.Set(ctx, "whale", user{Name: "whale"}, time.Minute))
+	// 经过 json + gzip 编码后写入 Store
+	raw, _ := remote.Raw("whale")
+	require.IsType(t, []byte{}, raw)
+	got, err := c.Get(ctx, "whale")
+	require.NoError(t, err)
+	require.Equal(t, "whale", got.Name)
+	_, ok := cachalot.StatsOf(c)
+	require.True(t, ok)
+
+	mc, err := MultiCache[user](f, "user")
+	require.NoError(t, err)
+	got, err = mc.Get(ctx, "orca")
+	require.NoError(t, err)
+	require.Equal(t, "orca", got.Name)
+	require.Equal(t, 1, loads)
+	_, ok = local.Raw("orca")
+	require.True(t, ok)
+
+	// hooks 在 Build 之前调用
+	called := false
+	_, err = Cache(f, "user-local", func(b *cachalot.Builder[user]) { called = true })
+	require.NoError(t, err)
+	require.True(t, called)
+
+	_, err = Cache[user](f, "missing")
+	require.ErrorContains(t, err, `cache "missing" not defined`)
+	// 回源函数的值类型与缓存不一致
+	_, err = Cache[string](f, "user-local")
+	require.ErrorContains(t, err, "caches.user-local: miss_loader.loader")
+}
+
+func TestParse(t *testing.T) {
+	doc, err := ParseJSON([]byte(`{"caches":{"c":{"store":"s","logic_expire":{"ttl":"1m30s"}}}}`))
+	require.NoError(t, err)
+	require.Equal(t, Duration(90*time.Second), doc.Caches["c"].LogicExpire.TTL)
+
+	_, err = ParseJSON([]byte(`{"caches":{"c":{"store":"s","ttl":"1m"}}}`))
+	require.ErrorContains(t, err, "unknown field")
+	_, err = ParseYAML([]byte("caches:\n  c:\n    stroe: s\n"))
+	require.ErrorContains(t, err, "stroe")
+	_, err = ParseYAML([]byte("caches:\n  c:\n    logic_expire:\n      ttl: 10\n"))
+	require.Error(t, err)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "caches.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(document), 0o600))
+	doc, err = Load(path)
+	require.NoError(t, err)
+	require.Len(t, doc.Caches, 2)
+	_, err = Load(filepath.Join(dir, "caches.toml"))
+	require.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	doc, err := ParseYAML([]byte(`
+caches:
+  a:
+    codec: xml
+    compression: brotli
+    miss_loader:
+      loader: nope
+      negative_ttl: -1s
+    nil_cache: {}
+  b:
+    store: remote
+multi:
+  a:
+    tiers: [b, b, c]
+    error_handling: lenient
+    stale_if_error:
+      grace: 0s
+`))
+	require.NoError(t, err)
+
+	loads := 0
+	_, err = New(doc, newRegistry(storetests.NewMemoryStore(), storetests.NewMemoryStore(), &loads))
+	require.Error(t, err)
+	for _, want := range []string{
+		"caches.a.store: required",
+		`caches.a.codec: unknown codec "xml"`,
+		`caches.a.compression: unknown compression "brotli"`,
+		`caches.a.miss_loader.loader: unknown loader "nope"`,
+		"caches.a.miss_loader.negative_ttl: require >= 0",
+		"caches.a.nil_cache.fn: required",
+		"multi.a: name conflicts with caches.a",
+		`multi.a.tiers[1]: duplicate cache "b"`,
+		`multi.a.tiers[2]: unknown cache "c"`,
+		"multi.a.loader: required",
+		"multi.a.error_handling",
+		"multi.a.stale_if_error.grace",
+	} {
+		require.ErrorContains(t, err, want)
+	}
+
+	_, err = New(&Document{}, nil)
+	require.ErrorContains(t, err, "no caches defined")
+
+	r := NewRegistry().RegisterStore("s", storetests.NewMemoryStore()).RegisterStore("s", storetests.NewMemoryStore())
+	RegisterLoader[user](r, "l", nil)
+	_, err = New(&Document{Caches: map[string]CacheConfig{"c": {Store: "s"}}}, r)
+	require.ErrorContains(t, err, `store "s" registered twice`)
+	require.ErrorContains(t, err, `loader "l" is nil`)
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yikakia/cachalot"
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/multicache"
+)
+
+// Factory 按文档中的名称构建缓存，文档与 Registry 在 New 时完成校验
+//
+// 每次调用 Cache / MultiCache 都会构建新的实例，需要共享时由调用方持有
+type Factory struct {
+	doc      *Document
+	registry *Registry
+}
+
+// New 校验文档及其对 Registry 的引用，失败时返回所有错误
+func New(doc *Document, registry *Registry) (*Factory, error) {
+	if doc == nil {
+		return nil, fmt.Errorf("config: document is required")
+	}
+	if registry == nil {
+		registry = NewRegistry()
+	}
+	if registry.err != nil {
+		return nil, fmt.Errorf("config: invalid registry: %w", registry.err)
+	}
+	if err := doc.Validate(registry); err != nil {
+		return nil, fmt.Errorf("config: invalid document: %w", err)
+	}
+	return &Factory{doc: doc, registry: registry}, nil
+}
+
+// Cache 构建 caches.<name>，hooks 在应用文档配置之后、Build 之前调用，用于设置 Metrics 等无法写入文档的选项
+func Cache[T any](f *Factory, name string, hooks ...func(b *cachalot.Builder[T])) (cache.Cache[T], error) {
+	cfg, ok := f.doc.Caches[name]
+	if !ok {
+		return nil, fmt.Errorf("config: cache %q not defined", name)
+	}
+	b, err := newBuilder[T](f.registry, name, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("config: caches.%s: %w", name, err)
+	}
+	for _, hook := range hooks {
+		hook(b)
+	}
+	return b.Build()
+}
+
+// MultiCache 构建 multi.<name>，各级缓存按 caches 中的配置构建
+func MultiCache[T any](f *Factory, name string, hooks ...func(b *cachalot.MultiBuilder[T])) (multicache.MultiCache[T], error) {
+	cfg, ok := f.doc.Multi[name]
+	if !ok {
+		return nil, fmt.Errorf("config: multi cache %q not defined", name)
+	}
+	tiers := make([]cache.Cache[T], 0, len(cfg.Tiers))
+	for _, tier := range cfg.Tiers {
+		c, err := Cache[T](f, tier)
+		if err != nil {
+			return nil, fmt.Errorf("config: multi.%s: %w", name, err)
+		}
+		tiers = append(tiers, c)
+	}
+
+	loader, err := lookup[decorator.LoaderFn[T]](f.registry.loaders, "loader", cfg.Loader)
+	if err != nil {
+		return nil, fmt.Errorf("config: multi.%s.loader: %w", name, err)
+	}
+	b := cachalot.NewMultiBuilder[T](name, tiers...).WithLoader(loader)
+	if cfg.WriteBackTTL > 0 {
+		b.WithWriteBack(multicache.WriteBackParallel[T](time.Duration(cfg.WriteBackTTL)))
+	}
+	if cfg.ErrorHandling == errorHandlingStrict {
+		b.WithErrorHandling(multicache.ErrorHandleStrict)
+	}
+	if cfg.Singleflight != nil {
+		b.WithSingleflight(*cfg.Singleflight)
+	}
+	if cfg.Stats {
+		b.WithStats(true)
+	}
+	if s := cfg.StaleIfError; s != nil {
+		b.WithStaleIfError(time.Duration(s.Grace), s.MaxEntries)
+	}
+	for _, hook := range hooks {
+		hook(b)
+	}
+	return b.Build()
+}
+
+func newBuilder[T any](r *Registry, name string, cfg CacheConfig) (*cachalot.Builder[T], error) {
+	b, err := cachalot.NewBuilder[T](name, r.stores[cfg.Store])
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Codec != "" {
+		b.WithCodec(r.codecs[cfg.Codec])
+	}
+	if cfg.Compression != "" {
+		b.WithCompression(r.compressions[cfg.Compression])
+	}
+	if cfg.Singleflight != nil {
+		b.WithSingleflight(*cfg.Singleflight)
+	}
+	if cfg.Stats {
+		b.WithStats(true)
+	}
+
+	if le := cfg.LogicExpire; le != nil {
+		b.WithLogicExpireEnabled(true)
+		if le.TTL > 0 {
+			b.WithLogicExpireDefaultLogicTTL(time.Duration(le.TTL))
+		}
+		if le.WriteBackTTL > 0 {
+			b.WithLogicExpireDefaultWriteBackTTL(time.Duration(le.WriteBackTTL))
+		}
+		if le.Loader != "" {
+			loader, err := lookup[decorator.LoaderFn[T]](r.loaders, "loader", le.Loader)
+			if err != nil {
+				return nil, fmt.Errorf("logic_expire.loader: %w", err)
+			}
+			b.WithLogicExpireLoader(loader)
+		}
+	}
+
+	if ml := cfg.MissLoader; ml != nil {
+		loader, err := lookup[decorator.LoaderFn[T]](r.loaders, "loader", ml.Loader)
+		if err != nil {
+			return nil, fmt.Errorf("miss_loader.loader: %w", err)
+		}
+		b.WithCacheMissLoader(loader)
+		if ml.WriteBackTTL > 0 {
+			b.WithCacheMissDefaultWriteBackTTL(time.Duration(ml.WriteBackTTL))
+		}
+		if ml.NegativeTTL > 0 {
+			b.WithCacheMissNegativeTTL(time.Duration(ml.NegativeTTL))
+		}
+	}
+
+	if nc := cfg.NilCache; nc != nil {
+		fn, err := lookup[decorator.ProtectionFn[T]](r.nilCacheFns, "nil cache fn", nc.Fn)
+		if err != nil {
+			return nil, fmt.Errorf("nil_cache.fn: %w", err)
+		}
+		b.WithNilCacheFn(fn)
+		if nc.WriteBackTTL > 0 {
+			b.WithNilCacheWriteBackTTL(time.Duration(nc.WriteBackTTL))
+		}
+	}
+	return b, nil
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/yikakia/cachalot"
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/codec"
+	"github.com/yikakia/cachalot/core/compress"
+	"github.com/yikakia/cachalot/core/decorator"
+)
+
+// Registry 文档中按名称引用的依赖，需要在 New 之前注册完毕，不支持并发注册
+//
+// 内置编解码 json、gob 与压缩 gzip、zlib、flate、lzw，可以用同名注册覆盖
+type Registry struct {
+	err          error
+	stores       map[string]cache.Store
+	codecs       map[string]codec.Codec
+	compressions map[string]cachalot.Compression
+	// decorator.LoaderFn[T]，值类型在构建时校验
+	loaders map[string]any
+	// decorator.ProtectionFn[T]，值类型在构建时校验
+	nilCacheFns map[string]any
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		stores: map[string]cache.Store{},
+		codecs: map[string]codec.Codec{
+			"json": codec.JSONCodec{},
+			"gob":  codec.GobCodec{},
+		},
+		compressions: map[string]cachalot.Compression{
+			"gzip":  compress.GzipCompression{},
+			"zlib":  compress.ZlibCompression{},
+			"flate": compress.FlateCompression{},
+			"lzw":   compress.LZWCompression{},
+		},
+		loaders:     map[string]any{},
+		nilCacheFns: map[string]any{},
+	}
+}
+
+// RegisterStore 同一个 Store 可以被多个缓存引用，重复注册同名 Store 时在 New 中报错
+func (r *Registry) RegisterStore(name string, store cache.Store) *Registry {
+	if store == nil {
+		r.appendErr(fmt.Errorf("store %q is nil", name))
+		return r
+	}
+	if _, ok := r.stores[name]; ok {
+		r.appendErr(fmt.Errorf("store %q registered twice", name))
+		return r
+	}
+	r.stores[name] = store
+	return r
+}
+
+// RegisterCodec 注册或覆盖编解码
+func (r *Registry) RegisterCodec(name string, c codec.Codec) *Registry {
+	if c == nil {
+		r.appendErr(fmt.Errorf("codec %q is nil", name))
+		return r
+	}
+	r.codecs[name] = c
+	return r
+}
+
+// RegisterCompression 注册或覆盖压缩方式，如 compressions/zstd 模块
+func (r *Registry) RegisterCompression(name string, c cachalot.Compression) *Registry {
+	if c == nil {
+		r.appendErr(fmt.Errorf("compression %q is nil", name))
+		return r
+	}
+	r.compressions[name] = c
+	return r
+}
+
+// RegisterLoader 注册回源函数，被引用时缓存的值类型必须为 T
+func RegisterLoader[T any](r *Registry, name string, fn decorator.LoaderFn[T]) *Registry {
+	if fn == nil {
+		r.appendErr(fmt.Errorf("loader %q is nil", name))
+		return r
+	}
+	if _, ok := r.loaders[name]; ok {
+		r.appendErr(fmt.Errorf("loader %q registered twice", name))
+		return r
+	}
+	r.loaders[name] = fn
+	return r
+}
+
+// RegisterNilCacheFn 注册防缓存击穿的防护函数，被引用时缓存的值类型必须为 T
+func RegisterNilCacheFn[T any](r *Registry, name string, fn decorator.ProtectionFn[T]) *Registry {
+	if fn == nil {
+		r.appendErr(fmt.Errorf("nil cache fn %q is nil", name))
+		return r
+	}
+	if _, ok := r.nilCacheFns[name]; ok {
+		r.appendErr(fmt.Errorf("nil cache fn %q registered twice", name))
+		return r
+	}
+	r.nilCacheFns[name] = fn
+	return r
+}
+
+func (r *Registry) appendErr(err error) {
+	r.err = errors.Join(r.err, err)
+}
+
+func lookup[V any](entries map[string]any, kind, name string) (V, error) {
+	var zero V
+	v, ok := entries[name]
+	if !ok {
+		return zero, fmt.Errorf("unknown %s %q", kind, name)
+	}
+	typed, ok := v.(V)
+	if !ok {
+		return zero, fmt.Errorf("%s %q is %T, want %T", kind, name, v, zero)
+	}
+	return typed, nil
+}
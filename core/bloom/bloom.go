@@ -0,0 +1,158 @@
+package bloom
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+	"sync/atomic"
+)
+
+// KeyFilter 判断 key 是否可能存在的过滤器抽象
+// MayContain 返回 false 表示 key 一定不存在，返回 true 表示 key 可能存在
+type KeyFilter interface {
+	Add(ctx context.Context, key string) error
+	MayContain(ctx context.Context, key string) (bool, error)
+}
+
+// Generational 可轮换的过滤器实现该接口，返回当前生效的代数，用于按代统计误判率
+type Generational interface {
+	Generation() uint64
+}
+
+// BitSet 布隆过滤器的位存储抽象，可以是本地 bitset，也可以是 redis bitmap 等远端存储
+type BitSet interface {
+	// SetBits 将 positions 上的位全部置 1
+	SetBits(ctx context.Context, positions []uint64) error
+	// TestBits 当 positions 上的位全部为 1 时返回 true
+	TestBits(ctx context.Context, positions []uint64) (bool, error)
+}
+
+// KeyEnumerator 遍历全量存在的 key，用于构建或重建过滤器
+type KeyEnumerator func(ctx context.Context, add func(key string) error) error
+
+var _ KeyFilter = (*Filter)(nil)
+
+// Filter 基于 BitSet 的布隆过滤器
+// m 为位数，k 为哈希函数个数，使用 FNV 双重哈希生成位下标，保证跨进程结果一致
+type Filter struct {
+	bits BitSet
+	m    uint64
+	k    uint64
+}
+
+// NewFilter 使用给定的位存储创建布隆过滤器
+func NewFilter(bits BitSet, m, k uint64) (*Filter, error) {
+	if bits == nil {
+		return nil, errors.New("bloom bitset is required")
+	}
+	if m == 0 || k == 0 {
+		return nil, errors.New("bloom filter requires m > 0 and k > 0")
+	}
+	return &Filter{bits: bits, m: m, k: k}, nil
+}
+
+// NewLocalFilter 根据预期元素个数与期望误判率创建进程内布隆过滤器
+func NewLocalFilter(expectedItems uint64, fpRate float64) (*Filter, error) {
+	m, k, err := EstimateParameters(expectedItems, fpRate)
+	if err != nil {
+		return nil, err
+	}
+	return NewFilter(NewLocalBitSet(m), m, k)
+}
+
+// EstimateParameters 根据预期元素个数 n 与误判率 p 计算位数 m 与哈希函数个数 k
+func EstimateParameters(n uint64, p float64) (m, k uint64, err error) {
+	if n == 0 {
+		return 0, 0, errors.New("bloom expectedItems must > 0")
+	}
+	if p <= 0 || p >= 1 {
+		return 0, 0, errors.New("bloom falsePositiveRate must in (0, 1)")
+	}
+	fm := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	fk := math.Round(fm / float64(n) * math.Ln2)
+	return uint64(fm), uint64(max(fk, 1)), nil
+}
+
+// EstimateFalsePositiveRate 估算插入 n 个元素后 (m, k) 过滤器的理论误判率
+func EstimateFalsePositiveRate(m, k, n uint64) float64 {
+	if m == 0 {
+		return 1
+	}
+	return math.Pow(1-math.Exp(-float64(k)*float64(n)/float64(m)), float64(k))
+}
+
+func (f *Filter) Add(ctx context.Context, key string) error {
+	return f.bits.SetBits(ctx, f.positions(key))
+}
+
+func (f *Filter) MayContain(ctx context.Context, key string) (bool, error) {
+	return f.bits.TestBits(ctx, f.positions(key))
+}
+
+// M 返回位数
+func (f *Filter) M() uint64 { return f.m }
+
+// K 返回哈希函数个数
+func (f *Filter) K() uint64 { return f.k }
+
+func (f *Filter) positions(key string) []uint64 {
+	h1 := fnv.New64a()
+	_, _ = h1.Write([]byte(key))
+	h2 := fnv.New64()
+	_, _ = h2.Write([]byte(key))
+
+	a, b := h1.Sum64(), h2.Sum64()|1
+	ret := make([]uint64, f.k)
+	for i := range ret {
+		ret[i] = (a + uint64(i)*b) % f.m
+	}
+	return ret
+}
+
+var _ BitSet = (*LocalBitSet)(nil)
+
+// LocalBitSet 进程内的无锁 bitset
+type LocalBitSet struct {
+	m     uint64
+	words []atomic.Uint64
+	ones  atomic.Uint64
+}
+
+func NewLocalBitSet(m uint64) *LocalBitSet {
+	return &LocalBitSet{m: m, words: make([]atomic.Uint64, (m+63)/64)}
+}
+
+func (l *LocalBitSet) SetBits(_ context.Context, positions []uint64) error {
+	for _, p := range positions {
+		w, mask := &l.words[p/64], uint64(1)<<(p%64)
+		for {
+			old := w.Load()
+			if old&mask != 0 {
+				break
+			}
+			if w.CompareAndSwap(old, old|mask) {
+				l.ones.Add(1)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (l *LocalBitSet) TestBits(_ context.Context, positions []uint64) (bool, error) {
+	for _, p := range positions {
+		if l.words[p/64].Load()&(uint64(1)<<(p%64)) == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// FillRatio 返回置 1 的位占比，可用于估算当前误判率: FillRatio()^k
+func (l *LocalBitSet) FillRatio() float64 {
+	if l.m == 0 {
+		return 0
+	}
+	return float64(l.ones.Load()) / float64(l.m)
+}
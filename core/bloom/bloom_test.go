@@ -0,0 +1,181 @@
+package bloom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateParameters(t *testing.T) {
+	m, k, err := EstimateParameters(1000, 0.01)
+	require.NoError(t, err)
+	require.Equal(t, uint64(9586), m)
+	require.Equal(t, uint64(7), k)
+
+	_, _, err = EstimateParameters(0, 0.01)
+	require.Error(t, err)
+	_, _, err = EstimateParameters(10, 1)
+	require.Error(t, err)
+}
+
+func TestLocalFilter(t *testing.T) {
+	ctx := context.Background()
+	f, err := NewLocalFilter(1000, 0.01)
+	require.NoError(t, err)
+
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, f.Add(ctx, fmt.Sprintf("key-%d", i)))
+	}
+	for i := 0; i < 1000; i++ {
+		ok, err := f.MayContain(ctx, fmt.Sprintf("key-%d", i))
+		require.NoError(t, err)
+		require.True(t, ok, "no false negative allowed")
+	}
+
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		ok, err := f.MayContain(ctx, fmt.Sprintf("absent-%d", i))
+		require.NoError(t, err)
+		if ok {
+			falsePositives++
+		}
+	}
+	require.Less(t, float64(falsePositives)/10000, 0.03)
+}
+
+func TestRotating(t *testing.T) {
+	ctx := context.Background()
+	keys := []string{"a", "b"}
+	var rotated []uint64
+
+	r, err := NewRotating(ctx, RotatingConfig{
+		NewFilter: func(ctx context.Context, generation uint64) (KeyFilter, error) {
+			return NewLocalFilter(100, 0.001)
+		},
+		Enumerator: func(ctx context.Context, add func(key string) error) error {
+			for _, k := range keys {
+				if err := add(k); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		OnRotated: func(ctx context.Context, generation uint64, previous KeyFilter) {
+			rotated = append(rotated, generation)
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), r.Generation())
+
+	ok, err := r.MayContain(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = r.MayContain(ctx, "c")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, r.Add(ctx, "c"))
+	ok, err = r.MayContain(ctx, "c")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// 重建后只保留枚举出的 key
+	keys = []string{"a"}
+	require.NoError(t, r.Rebuild(ctx))
+	require.Equal(t, uint64(2), r.Generation())
+	require.Equal(t, []uint64{1, 2}, rotated)
+
+	ok, err = r.MayContain(ctx, "b")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestRotatingRebuildFailureKeepsCurrent(t *testing.T) {
+	ctx := context.Background()
+	enumErr := errors.New("enumerate failed")
+	fail := false
+
+	r, err := NewRotating(ctx, RotatingConfig{
+		NewFilter: func(ctx context.Context, generation uint64) (KeyFilter, error) {
+			return NewLocalFilter(100, 0.001)
+		},
+		Enumerator: func(ctx context.Context, add func(key string) error) error {
+			if fail {
+				return enumErr
+			}
+			return add("a")
+		},
+	})
+	require.NoError(t, err)
+
+	fail = true
+	require.ErrorIs(t, r.Rebuild(ctx), enumErr)
+	require.Equal(t, uint64(1), r.Generation())
+
+	ok, err := r.MayContain(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+// blockingFilter 写入 key 时阻塞，直到 release 关闭
+type blockingFilter struct {
+	KeyFilter
+	key     string
+	started chan struct{}
+	release chan struct{}
+	done    atomic.Bool
+}
+
+func (f *blockingFilter) Add(ctx context.Context, key string) error {
+	if key != f.key {
+		return f.KeyFilter.Add(ctx, key)
+	}
+	close(f.started)
+	<-f.release
+	err := f.KeyFilter.Add(ctx, key)
+	f.done.Store(true)
+	return err
+}
+
+func TestRotatingRebuildWaitsForInflightAdd(t *testing.T) {
+	ctx := context.Background()
+	first, err := NewLocalFilter(100, 0.001)
+	require.NoError(t, err)
+	blocking := &blockingFilter{KeyFilter: first, key: "late", started: make(chan struct{}), release: make(chan struct{})}
+
+	creating := make(chan struct{})
+	var rebuilding atomic.Bool
+	r, err := NewRotating(ctx, RotatingConfig{
+		NewFilter: func(ctx context.Context, generation uint64) (KeyFilter, error) {
+			if generation == 1 {
+				return blocking, nil
+			}
+			rebuilding.Store(true)
+			close(creating)
+			return NewLocalFilter(100, 0.001)
+		},
+		Enumerator: func(ctx context.Context, add func(key string) error) error {
+			// 进行中的 Add 只写入了旧一代，灌入开始前必须已经完成
+			if rebuilding.Load() && !blocking.done.Load() {
+				return errors.New("populate started while add in flight")
+			}
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	added := make(chan error, 1)
+	go func() { added <- r.Add(ctx, "late") }()
+	<-blocking.started
+
+	rebuilt := make(chan error, 1)
+	go func() { rebuilt <- r.Rebuild(ctx) }()
+	<-creating
+	close(blocking.release)
+	require.NoError(t, <-added)
+	require.NoError(t, <-rebuilt)
+}
@@ -0,0 +1,154 @@
+package bloom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RotatingConfig 可轮换过滤器的配置
+type RotatingConfig struct {
+	// NewFilter 创建新一代的过滤器，generation 从 1 开始递增
+	// 对于 redis bitmap 这类远端实现，可以用 generation 区分 bitmap 的 key
+	NewFilter func(ctx context.Context, generation uint64) (KeyFilter, error)
+	// Enumerator 用于全量灌入存在的 key
+	Enumerator KeyEnumerator
+	// OnRotated 新一代生效后回调，可用于清理上一代的远端存储 非必须
+	OnRotated func(ctx context.Context, generation uint64, previous KeyFilter)
+}
+
+var _ KeyFilter = (*Rotating)(nil)
+var _ Generational = (*Rotating)(nil)
+
+// Rotating 支持重建与轮换的过滤器
+//
+// 布隆过滤器无法删除元素，随着 key 的删除与新增误判率会逐渐升高。
+// Rebuild 会创建新一代过滤器并通过 Enumerator 全量灌入，完成后原子替换当前过滤器。
+// 重建期间的 Add 会同时写入当前代和构建中的新一代，避免丢失。
+// Add 在读锁内完成写入，发布构建中的新一代时会等待进行中的 Add，因此 Add 要么在 Enumerator 开始之前完成，要么写入新一代；
+// 前者对应的 key 需要在 Add 返回前已能被 Enumerator 枚举到（例如先写入数据源）。
+type Rotating struct {
+	cfg RotatingConfig
+
+	rebuildMu sync.Mutex
+
+	mu         sync.RWMutex
+	current    KeyFilter
+	building   KeyFilter
+	generation uint64
+}
+
+// NewRotating 创建可轮换过滤器，并同步完成第一次构建
+func NewRotating(ctx context.Context, cfg RotatingConfig) (*Rotating, error) {
+	if cfg.NewFilter == nil {
+		return nil, errors.New("rotating bloom filter requires NewFilter")
+	}
+	r := &Rotating{cfg: cfg}
+	if err := r.Rebuild(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Rotating) Add(ctx context.Context, key string) error {
+	// 持有读锁直到写入完成，避免在发布新一代之前取到快照、却在灌入完成后才写入旧一代
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var errs []error
+	if r.current != nil {
+		errs = append(errs, r.current.Add(ctx, key))
+	}
+	if r.building != nil {
+		errs = append(errs, r.building.Add(ctx, key))
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Rotating) MayContain(ctx context.Context, key string) (bool, error) {
+	r.mu.RLock()
+	current := r.current
+	r.mu.RUnlock()
+
+	if current == nil {
+		// 尚未构建完成时不做拦截
+		return true, nil
+	}
+	return current.MayContain(ctx, key)
+}
+
+// Generation 返回当前生效的代数
+func (r *Rotating) Generation() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.generation
+}
+
+// Current 返回当前生效的过滤器
+func (r *Rotating) Current() KeyFilter {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current
+}
+
+// Rebuild 构建新一代过滤器并替换当前过滤器，同一时间只会有一个重建在进行
+func (r *Rotating) Rebuild(ctx context.Context) error {
+	r.rebuildMu.Lock()
+	defer r.rebuildMu.Unlock()
+
+	r.mu.RLock()
+	generation := r.generation + 1
+	r.mu.RUnlock()
+
+	next, err := r.cfg.NewFilter(ctx, generation)
+	if err != nil {
+		return fmt.Errorf("[bloom.Rotating] create generation %d failed: %w", generation, err)
+	}
+
+	r.mu.Lock()
+	r.building = next
+	r.mu.Unlock()
+
+	if r.cfg.Enumerator != nil {
+		err = r.cfg.Enumerator(ctx, func(key string) error {
+			return next.Add(ctx, key)
+		})
+		if err != nil {
+			r.mu.Lock()
+			r.building = nil
+			r.mu.Unlock()
+			return fmt.Errorf("[bloom.Rotating] populate generation %d failed: %w", generation, err)
+		}
+	}
+
+	r.mu.Lock()
+	previous := r.current
+	r.current = next
+	r.building = nil
+	r.generation = generation
+	r.mu.Unlock()
+
+	if r.cfg.OnRotated != nil {
+		r.cfg.OnRotated(ctx, generation, previous)
+	}
+	return nil
+}
+
+// Run 按 interval 周期性轮换，直到 ctx 结束
+// 轮换失败时调用 onErr 并保留当前过滤器继续服务
+func (r *Rotating) Run(ctx context.Context, interval time.Duration, onErr func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Rebuild(ctx); err != nil && onErr != nil {
+				onErr(err)
+			}
+		}
+	}
+}
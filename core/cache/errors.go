@@ -7,3 +7,17 @@ import (
 var ErrNotFound = fmt.Errorf("item not exist")
 var ErrTypeMismatch = fmt.Errorf("type mismatch")
 var ErrInvalidTTL = fmt.Errorf("invalid ttl")
+
+// ErrNegativeCached 表示缓存中存在该 key 的墓碑（已确认源数据不存在）
+// 包裹了 ErrNotFound，errors.Is(err, ErrNotFound) 依旧成立
+var ErrNegativeCached = fmt.Errorf("item known absent: %w", ErrNotFound)
+
+// ErrNewerSchema 表示存储中的值由更高 schema 版本的实例写入，当前实例无法解码
+// 包裹了 ErrNotFound，按未命中回源，但回源结果不会写回，避免滚动发布期间覆盖新版本的值
+var ErrNewerSchema = fmt.Errorf("item written by newer schema: %w", ErrNotFound)
+
+// ErrCorrupted 表示存储中的值已损坏，如校验和不匹配、数据被截断
+var ErrCorrupted = fmt.Errorf("item corrupted")
+
+// ErrValueTooLarge 表示写入的值超过了允许的最大长度
+var ErrValueTooLarge = fmt.Errorf("value too large")
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/gob"
 	"encoding/json"
+
+	"github.com/yikakia/cachalot/internal/bufpool"
 )
 
 // Codec 定义序列化接口
@@ -12,6 +14,37 @@ type Codec interface {
 	Unmarshal([]byte, any) error
 }
 
+// AppendMarshaler 可选接口，将编码结果追加到 dst 之后，调用方可以传入池化的缓冲区
+type AppendMarshaler interface {
+	AppendMarshal(dst []byte, v any) ([]byte, error)
+}
+
+// NoRetain 可选接口
+//
+// NoRetain 返回 true 表示 Unmarshal 返回后不再引用 data，调用方可以从池化的缓冲区解码并在返回后复用。
+// 未实现该接口的 Codec 视为会保留 data（如解码结果直接引用 data）
+type NoRetain interface {
+	NoRetain() bool
+}
+
+// RetainsData c 未实现 NoRetain 时返回 true
+func RetainsData(c Codec) bool {
+	nr, ok := c.(NoRetain)
+	return !ok || !nr.NoRetain()
+}
+
+// AppendMarshal c 实现 AppendMarshaler 时直接追加，否则追加 Marshal 的结果
+func AppendMarshal(c Codec, dst []byte, v any) ([]byte, error) {
+	if am, ok := c.(AppendMarshaler); ok {
+		return am.AppendMarshal(dst, v)
+	}
+	data, err := c.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append(dst, data...), nil
+}
+
 // JSONCodec 默认的 JSON 序列化实现
 type JSONCodec struct{}
 
@@ -19,10 +52,25 @@ func (j JSONCodec) Marshal(v any) ([]byte, error) {
 	return json.Marshal(v)
 }
 
+// AppendMarshal 结果与 Marshal 相同
+func (j JSONCodec) AppendMarshal(dst []byte, v any) ([]byte, error) {
+	w := bufpool.Writer{B: dst}
+	if err := json.NewEncoder(&w).Encode(v); err != nil {
+		return nil, err
+	}
+	// Encode 会在末尾追加换行
+	return w.B[:len(w.B)-1], nil
+}
+
 func (j JSONCodec) Unmarshal(data []byte, v any) error {
 	return json.Unmarshal(data, v)
 }
 
+// NoRetain 解码结果中的字符串、字节切片均为复制
+func (j JSONCodec) NoRetain() bool {
+	return true
+}
+
 type GobCodec struct{}
 
 func (g GobCodec) Marshal(v any) ([]byte, error) {
@@ -34,8 +82,21 @@ func (g GobCodec) Marshal(v any) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+func (g GobCodec) AppendMarshal(dst []byte, v any) ([]byte, error) {
+	w := bufpool.Writer{B: dst}
+	if err := gob.NewEncoder(&w).Encode(v); err != nil {
+		return nil, err
+	}
+	return w.B, nil
+}
+
 func (g GobCodec) Unmarshal(data []byte, v any) error {
 	buf := bytes.NewBuffer(data)
 	dec := gob.NewDecoder(buf)
 	return dec.Decode(v)
 }
+
+// NoRetain 解码结果中的字符串、字节切片均为复制
+func (g GobCodec) NoRetain() bool {
+	return true
+}
@@ -0,0 +1,33 @@
+package codec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// plainCodec 没有实现 AppendMarshaler，用于验证 AppendMarshal 的回退路径
+type plainCodec struct{ JSONCodec }
+
+func TestAppendMarshal(t *testing.T) {
+	v := userV3{FirstName: "<orca>", Age: 3}
+	for name, c := range map[string]Codec{
+		"json":      JSONCodec{},
+		"gob":       GobCodec{},
+		"versioned": VersionedCodec{Codec: JSONCodec{}, Version: 2},
+		"fallback":  plainCodec{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			want, err := c.Marshal(v)
+			require.NoError(t, err)
+
+			data, err := AppendMarshal(c, []byte("prefix"), v)
+			require.NoError(t, err)
+			require.Equal(t, "prefix", string(data[:6]))
+			require.Equal(t, want, data[6:])
+		})
+	}
+
+	_, err := AppendMarshal(VersionedCodec{Codec: JSONCodec{}}, nil, v)
+	require.Error(t, err)
+}
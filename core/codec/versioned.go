@@ -0,0 +1,116 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// 带版本的编码：| magic 0xC1 0x5C | uvarint 版本 | 内层编码 |
+// 没有该头部的数据视为版本 0，即启用版本之前写入的数据
+const (
+	versionMagic0 byte = 0xC1
+	versionMagic1 byte = 0x5C
+)
+
+// ErrSchemaMismatch 值的 schema 版本低于当前版本，且无法升级
+var ErrSchemaMismatch = errors.New("codec: schema version mismatch")
+
+// ErrSchemaNewer 值的 schema 版本高于当前版本，通常是滚动发布中新实例写入的值
+var ErrSchemaNewer = errors.New("codec: schema version newer than current")
+
+// UpgradeFunc 将某个版本的内层编码升级为下一个版本的内层编码
+type UpgradeFunc func(data []byte) ([]byte, error)
+
+// SchemaVersioned 带 schema 版本的 Codec，CodecDecorator 会据此上报升级与丢弃
+type SchemaVersioned interface {
+	Codec
+	// SchemaVersion 当前写入的版本
+	SchemaVersion() uint32
+	// UnmarshalVersion 解码并返回值写入时的版本
+	UnmarshalVersion(data []byte, v any) (uint32, error)
+}
+
+var _ SchemaVersioned = VersionedCodec{}
+
+// VersionedCodec 为内层 Codec 的编码加上 schema 版本
+//
+// 读取旧版本的值时依次执行 Upgrades[v]（v -> v+1）直到当前版本，升级结果不会写回存储，
+// 每次读取都会重新执行升级链，直到该 key 被重新写入或过期。
+// 缺少任意一步时返回 ErrSchemaMismatch，CodecDecorator 会将其作为未命中处理，由回源重新加载并以当前版本写回；
+// 版本高于当前版本时返回 ErrSchemaNewer，同样按未命中处理，但不会写回，避免覆盖新版本的值
+type VersionedCodec struct {
+	Codec Codec
+	// 当前版本，需要 >= 1
+	Version uint32
+	// key 为升级前的版本，Upgrades[0] 用于升级启用版本之前写入的数据
+	Upgrades map[uint32]UpgradeFunc
+}
+
+func (c VersionedCodec) SchemaVersion() uint32 {
+	return c.Version
+}
+
+func (c VersionedCodec) Marshal(v any) ([]byte, error) {
+	if c.Version == 0 {
+		return nil, errors.New("codec: schema version require >= 1")
+	}
+	data, err := c.Codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 2, 2+binary.MaxVarintLen32+len(data))
+	out[0], out[1] = versionMagic0, versionMagic1
+	out = binary.AppendUvarint(out, uint64(c.Version))
+	return append(out, data...), nil
+}
+
+func (c VersionedCodec) AppendMarshal(dst []byte, v any) ([]byte, error) {
+	if c.Version == 0 {
+		return nil, errors.New("codec: schema version require >= 1")
+	}
+	dst = append(dst, versionMagic0, versionMagic1)
+	dst = binary.AppendUvarint(dst, uint64(c.Version))
+	return AppendMarshal(c.Codec, dst, v)
+}
+
+// NoRetain 与内层 Codec 一致
+func (c VersionedCodec) NoRetain() bool {
+	return !RetainsData(c.Codec)
+}
+
+func (c VersionedCodec) Unmarshal(data []byte, v any) error {
+	_, err := c.UnmarshalVersion(data, v)
+	return err
+}
+
+func (c VersionedCodec) UnmarshalVersion(data []byte, v any) (uint32, error) {
+	version, payload, err := splitVersion(data)
+	if err != nil {
+		return version, err
+	}
+	if version > c.Version {
+		return version, fmt.Errorf("%w: got version %d, current %d", ErrSchemaNewer, version, c.Version)
+	}
+	for from := version; from < c.Version; from++ {
+		upgrade, ok := c.Upgrades[from]
+		if !ok {
+			return version, fmt.Errorf("%w: no upgrade from version %d, current %d", ErrSchemaMismatch, from, c.Version)
+		}
+		if payload, err = upgrade(payload); err != nil {
+			return version, fmt.Errorf("upgrade schema from version %d: %w", from, err)
+		}
+	}
+	return version, c.Codec.Unmarshal(payload, v)
+}
+
+func splitVersion(data []byte) (uint32, []byte, error) {
+	if len(data) < 2 || data[0] != versionMagic0 || data[1] != versionMagic1 {
+		return 0, data, nil
+	}
+	version, n := binary.Uvarint(data[2:])
+	if n <= 0 || version > uint64(^uint32(0)) {
+		return 0, nil, errors.New("codec: invalid schema version header")
+	}
+	return uint32(version), data[2+n:], nil
+}
@@ -0,0 +1,74 @@
+package codec
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type userV1 struct {
+	Name string `json:"name"`
+}
+
+type userV3 struct {
+	FirstName string `json:"first_name"`
+	Age       int    `json:"age"`
+}
+
+func TestVersionedCodec(t *testing.T) {
+	// v1 -> v2：name 改名为 first_name
+	// v2 -> v3：新增 age，默认 18
+	upgrades := map[uint32]UpgradeFunc{
+		1: func(data []byte) ([]byte, error) {
+			var m map[string]any
+			if err := json.Unmarshal(data, &m); err != nil {
+				return nil, err
+			}
+			m["first_name"] = m["name"]
+			delete(m, "name")
+			return json.Marshal(m)
+		},
+		2: func(data []byte) ([]byte, error) {
+			var m map[string]any
+			if err := json.Unmarshal(data, &m); err != nil {
+				return nil, err
+			}
+			m["age"] = 18
+			return json.Marshal(m)
+		},
+	}
+	v1 := VersionedCodec{Codec: JSONCodec{}, Version: 1}
+	v3 := VersionedCodec{Codec: JSONCodec{}, Version: 3, Upgrades: upgrades}
+
+	old, err := v1.Marshal(userV1{Name: "whale"})
+	require.NoError(t, err)
+
+	var got userV3
+	version, err := v3.UnmarshalVersion(old, &got)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), version)
+	require.Equal(t, userV3{FirstName: "whale", Age: 18}, got)
+
+	current, err := v3.Marshal(userV3{FirstName: "orca", Age: 3})
+	require.NoError(t, err)
+	version, err = v3.UnmarshalVersion(current, &got)
+	require.NoError(t, err)
+	require.Equal(t, uint32(3), version)
+	require.Equal(t, userV3{FirstName: "orca", Age: 3}, got)
+
+	// 启用版本之前写入的数据为版本 0，没有 Upgrades[0] 时无法升级
+	legacy, err := JSONCodec{}.Marshal(userV1{Name: "legacy"})
+	require.NoError(t, err)
+	version, err = v3.UnmarshalVersion(legacy, &got)
+	require.ErrorIs(t, err, ErrSchemaMismatch)
+	require.Equal(t, uint32(0), version)
+
+	// 新版本写入的数据，旧版本无法读取
+	err = v1.Unmarshal(current, &userV1{})
+	require.ErrorIs(t, err, ErrSchemaNewer)
+	require.NotErrorIs(t, err, ErrSchemaMismatch)
+
+	_, err = VersionedCodec{Codec: JSONCodec{}}.Marshal(userV1{})
+	require.Error(t, err)
+}
@@ -7,40 +7,57 @@ import (
 	"compress/lzw"
 	"compress/zlib"
 	"io"
+	"sync"
+
+	"github.com/yikakia/cachalot/internal/bufpool"
 )
 
+// 以下实现的 writer 与 reader 均通过 sync.Pool 复用，Compress / Decompress 只为结果分配一次内存；
+// AppendCompress / DecompressTo 将结果追加到 dst，调用方传入池化的缓冲区时不产生额外分配
+
 // GzipCompression 是基于标准库 gzip 的压缩实现。
 type GzipCompression struct {
 	Level int
 }
 
+var (
+	gzipWriters = newWriterPools(func(w io.Writer, level int) (resetWriter, error) {
+		return gzip.NewWriterLevel(w, level)
+	})
+	gzipReaders sync.Pool
+)
+
 func (g GzipCompression) Compress(src []byte) ([]byte, error) {
-	var buf bytes.Buffer
-	level := g.Level
-	if level == 0 {
-		level = gzip.DefaultCompression
-	}
-	zw, err := gzip.NewWriterLevel(&buf, level)
-	if err != nil {
-		return nil, err
-	}
-	if _, err = zw.Write(src); err != nil {
-		_ = zw.Close()
-		return nil, err
-	}
-	if err = zw.Close(); err != nil {
-		return nil, err
-	}
-	return buf.Bytes(), nil
+	return cloneResult(src, g.AppendCompress)
+}
+
+func (g GzipCompression) AppendCompress(dst, src []byte) ([]byte, error) {
+	return gzipWriters.compress(dst, src, levelOrDefault(g.Level, gzip.DefaultCompression))
 }
 
 func (g GzipCompression) Decompress(src []byte) ([]byte, error) {
-	zr, err := gzip.NewReader(bytes.NewReader(src))
-	if err != nil {
-		return nil, err
+	return cloneResult(src, g.DecompressTo)
+}
+
+func (g GzipCompression) DecompressTo(dst, src []byte) ([]byte, error) {
+	pr, _ := gzipReaders.Get().(*pooledReader)
+	if pr == nil {
+		pr = &pooledReader{}
+		pr.src.Reset(src)
+		zr, err := gzip.NewReader(&pr.src)
+		if err != nil {
+			return nil, err
+		}
+		pr.zr = zr
+	} else {
+		pr.src.Reset(src)
+		if err := pr.zr.(*gzip.Reader).Reset(&pr.src); err != nil {
+			gzipReaders.Put(pr)
+			return nil, err
+		}
 	}
-	defer func() { _ = zr.Close() }()
-	return io.ReadAll(zr)
+	defer gzipReaders.Put(pr)
+	return pr.readTo(dst)
 }
 
 // ZlibCompression 是基于标准库 zlib 的压缩实现。
@@ -48,33 +65,44 @@ type ZlibCompression struct {
 	Level int
 }
 
+var (
+	zlibWriters = newWriterPools(func(w io.Writer, level int) (resetWriter, error) {
+		return zlib.NewWriterLevel(w, level)
+	})
+	zlibReaders sync.Pool
+)
+
 func (z ZlibCompression) Compress(src []byte) ([]byte, error) {
-	var buf bytes.Buffer
-	level := z.Level
-	if level == 0 {
-		level = zlib.DefaultCompression
-	}
-	zw, err := zlib.NewWriterLevel(&buf, level)
-	if err != nil {
-		return nil, err
-	}
-	if _, err = zw.Write(src); err != nil {
-		_ = zw.Close()
-		return nil, err
-	}
-	if err = zw.Close(); err != nil {
-		return nil, err
-	}
-	return buf.Bytes(), nil
+	return cloneResult(src, z.AppendCompress)
+}
+
+func (z ZlibCompression) AppendCompress(dst, src []byte) ([]byte, error) {
+	return zlibWriters.compress(dst, src, levelOrDefault(z.Level, zlib.DefaultCompression))
 }
 
 func (z ZlibCompression) Decompress(src []byte) ([]byte, error) {
-	zr, err := zlib.NewReader(bytes.NewReader(src))
-	if err != nil {
-		return nil, err
+	return cloneResult(src, z.DecompressTo)
+}
+
+func (z ZlibCompression) DecompressTo(dst, src []byte) ([]byte, error) {
+	pr, _ := zlibReaders.Get().(*pooledReader)
+	if pr == nil {
+		pr = &pooledReader{}
+		pr.src.Reset(src)
+		zr, err := zlib.NewReader(&pr.src)
+		if err != nil {
+			return nil, err
+		}
+		pr.zr = zr
+	} else {
+		pr.src.Reset(src)
+		if err := pr.zr.(zlib.Resetter).Reset(&pr.src, nil); err != nil {
+			zlibReaders.Put(pr)
+			return nil, err
+		}
 	}
-	defer func() { _ = zr.Close() }()
-	return io.ReadAll(zr)
+	defer zlibReaders.Put(pr)
+	return pr.readTo(dst)
 }
 
 // FlateCompression 是基于标准库 flate 的压缩实现。
@@ -82,30 +110,37 @@ type FlateCompression struct {
 	Level int
 }
 
+var (
+	flateWriters = newWriterPools(func(w io.Writer, level int) (resetWriter, error) {
+		return flate.NewWriter(w, level)
+	})
+	flateReaders sync.Pool
+)
+
 func (f FlateCompression) Compress(src []byte) ([]byte, error) {
-	var buf bytes.Buffer
-	level := f.Level
-	if level == 0 {
-		level = flate.DefaultCompression
-	}
-	zw, err := flate.NewWriter(&buf, level)
-	if err != nil {
-		return nil, err
-	}
-	if _, err = zw.Write(src); err != nil {
-		_ = zw.Close()
-		return nil, err
-	}
-	if err = zw.Close(); err != nil {
-		return nil, err
-	}
-	return buf.Bytes(), nil
+	return cloneResult(src, f.AppendCompress)
+}
+
+func (f FlateCompression) AppendCompress(dst, src []byte) ([]byte, error) {
+	return flateWriters.compress(dst, src, levelOrDefault(f.Level, flate.DefaultCompression))
 }
 
 func (f FlateCompression) Decompress(src []byte) ([]byte, error) {
-	zr := flate.NewReader(bytes.NewReader(src))
-	defer func() { _ = zr.Close() }()
-	return io.ReadAll(zr)
+	return cloneResult(src, f.DecompressTo)
+}
+
+func (f FlateCompression) DecompressTo(dst, src []byte) ([]byte, error) {
+	pr, _ := flateReaders.Get().(*pooledReader)
+	if pr == nil {
+		pr = &pooledReader{}
+		pr.src.Reset(src)
+		pr.zr = flate.NewReader(&pr.src)
+	} else {
+		pr.src.Reset(src)
+		_ = pr.zr.(flate.Resetter).Reset(&pr.src, nil)
+	}
+	defer flateReaders.Put(pr)
+	return pr.readTo(dst)
 }
 
 // LZWCompression 是基于标准库 lzw 的压缩实现。
@@ -115,9 +150,12 @@ type LZWCompression struct {
 	LiteralWidth int
 }
 
-func (l LZWCompression) Compress(src []byte) ([]byte, error) {
-	var buf bytes.Buffer
+var (
+	lzwWriters sync.Pool
+	lzwReaders sync.Pool
+)
 
+func (l LZWCompression) config() (lzw.Order, int) {
 	order := l.Order
 	if order != lzw.LSB && order != lzw.MSB {
 		order = lzw.LSB
@@ -127,30 +165,135 @@ func (l LZWCompression) Compress(src []byte) ([]byte, error) {
 	if literalWidth == 0 {
 		literalWidth = 8
 	}
+	return order, literalWidth
+}
 
-	zw := lzw.NewWriter(&buf, order, literalWidth)
-	if _, err := zw.Write(src); err != nil {
-		_ = zw.Close()
-		return nil, err
+func (l LZWCompression) Compress(src []byte) ([]byte, error) {
+	return cloneResult(src, l.AppendCompress)
+}
+
+func (l LZWCompression) AppendCompress(dst, src []byte) ([]byte, error) {
+	order, literalWidth := l.config()
+	pw, _ := lzwWriters.Get().(*pooledWriter)
+	if pw == nil {
+		pw = &pooledWriter{}
+		pw.zw = lzw.NewWriter(&pw.out, order, literalWidth).(*lzw.Writer)
+	} else {
+		pw.zw.(*lzw.Writer).Reset(&pw.out, order, literalWidth)
 	}
-	if err := zw.Close(); err != nil {
+	defer lzwWriters.Put(pw)
+	return pw.writeTo(dst, src)
+}
+
+func (l LZWCompression) Decompress(src []byte) ([]byte, error) {
+	return cloneResult(src, l.DecompressTo)
+}
+
+func (l LZWCompression) DecompressTo(dst, src []byte) ([]byte, error) {
+	order, literalWidth := l.config()
+	pr, _ := lzwReaders.Get().(*pooledReader)
+	if pr == nil {
+		pr = &pooledReader{}
+		pr.src.Reset(src)
+		pr.zr = lzw.NewReader(&pr.src, order, literalWidth)
+	} else {
+		pr.src.Reset(src)
+		pr.zr.(*lzw.Reader).Reset(&pr.src, order, literalWidth)
+	}
+	defer lzwReaders.Put(pr)
+	return pr.readTo(dst)
+}
+
+func levelOrDefault(level, def int) int {
+	if level == 0 {
+		return def
+	}
+	return level
+}
+
+// cloneResult 在池化的缓冲区中完成处理，再复制为长度刚好的结果
+func cloneResult(src []byte, fn func(dst, src []byte) ([]byte, error)) ([]byte, error) {
+	buf := bufpool.Get()
+	defer bufpool.Put(buf)
+	out, err := fn(*buf, src)
+	if err != nil {
 		return nil, err
 	}
-	return buf.Bytes(), nil
+	*buf = out
+	return bytes.Clone(out), nil
 }
 
-func (l LZWCompression) Decompress(src []byte) ([]byte, error) {
-	order := l.Order
-	if order != lzw.LSB && order != lzw.MSB {
-		order = lzw.LSB
+type resetWriter interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+// pooledWriter 与其输出目标一起复用，避免每次 Reset 时重新绑定 writer
+type pooledWriter struct {
+	zw  io.WriteCloser
+	out bufpool.Writer
+}
+
+func (p *pooledWriter) writeTo(dst, src []byte) ([]byte, error) {
+	p.out.B = dst
+	defer func() { p.out.B = nil }()
+	if _, err := p.zw.Write(src); err != nil {
+		_ = p.zw.Close()
+		return nil, err
+	}
+	if err := p.zw.Close(); err != nil {
+		return nil, err
 	}
+	return p.out.B, nil
+}
 
-	literalWidth := l.LiteralWidth
-	if literalWidth == 0 {
-		literalWidth = 8
+// 压缩等级范围为 [HuffmanOnly(-2), BestCompression(9)]，不同等级的 writer 分别复用
+const levelCount = flate.BestCompression - flate.HuffmanOnly + 1
+
+type writerPools struct {
+	pools     [levelCount]sync.Pool
+	newWriter func(w io.Writer, level int) (resetWriter, error)
+}
+
+func newWriterPools(newWriter func(w io.Writer, level int) (resetWriter, error)) *writerPools {
+	return &writerPools{newWriter: newWriter}
+}
+
+func (p *writerPools) compress(dst, src []byte, level int) ([]byte, error) {
+	if level < flate.HuffmanOnly || level > flate.BestCompression {
+		// 交给具体实现返回等级非法的错误
+		_, err := p.newWriter(io.Discard, level)
+		return nil, err
+	}
+	pool := &p.pools[level-flate.HuffmanOnly]
+	pw, _ := pool.Get().(*pooledWriter)
+	if pw == nil {
+		pw = &pooledWriter{}
+		zw, err := p.newWriter(&pw.out, level)
+		if err != nil {
+			return nil, err
+		}
+		pw.zw = zw
+	} else {
+		pw.zw.(resetWriter).Reset(&pw.out)
 	}
+	defer pool.Put(pw)
+	return pw.writeTo(dst, src)
+}
 
-	zr := lzw.NewReader(bytes.NewReader(src), order, literalWidth)
-	defer func() { _ = zr.Close() }()
-	return io.ReadAll(zr)
+type pooledReader struct {
+	zr  io.ReadCloser
+	src bytes.Reader
+}
+
+func (p *pooledReader) readTo(dst []byte) ([]byte, error) {
+	defer p.src.Reset(nil)
+	out := bufpool.Writer{B: dst}
+	if _, err := out.ReadFrom(p.zr); err != nil {
+		return nil, err
+	}
+	if err := p.zr.Close(); err != nil {
+		return nil, err
+	}
+	return out.B, nil
 }
@@ -5,6 +5,7 @@ import (
 	"compress/gzip"
 	"compress/lzw"
 	"compress/zlib"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -77,6 +78,47 @@ func TestLZWCompression_DecompressInvalidData(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestAppendCompress(t *testing.T) {
+	for name, c := range map[string]appendCompression{
+		"gzip":  GzipCompression{},
+		"zlib":  ZlibCompression{Level: zlib.BestSpeed},
+		"flate": FlateCompression{},
+		"lzw":   LZWCompression{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			plain := []byte("hello compression codec roundtrip hello compression codec roundtrip")
+			prefix := []byte("prefix")
+
+			// 复用池中的 writer / reader 时结果保持一致
+			var wg sync.WaitGroup
+			for range 8 {
+				wg.Go(func() {
+					compressed, err := c.AppendCompress(prefix[:len(prefix):len(prefix)], plain)
+					require.NoError(t, err)
+					require.Equal(t, prefix, compressed[:len(prefix)])
+
+					got, err := c.DecompressTo([]byte("prefix"), compressed[len(prefix):])
+					require.NoError(t, err)
+					require.Equal(t, append([]byte("prefix"), plain...), got)
+				})
+			}
+			wg.Wait()
+		})
+	}
+}
+
+func TestCompression_InvalidLevel(t *testing.T) {
+	_, err := GzipCompression{Level: 42}.Compress([]byte("x"))
+	require.Error(t, err)
+	_, err = FlateCompression{Level: -42}.AppendCompress(nil, []byte("x"))
+	require.Error(t, err)
+}
+
+type appendCompression interface {
+	AppendCompress(dst, src []byte) ([]byte, error)
+	DecompressTo(dst, src []byte) ([]byte, error)
+}
+
 func assertRoundTrip(t *testing.T, c interface {
 	Compress([]byte) ([]byte, error)
 	Decompress([]byte) ([]byte, error)
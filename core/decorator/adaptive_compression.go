@@ -0,0 +1,188 @@
+package decorator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/telemetry"
+)
+
+// 自适应压缩的存储格式：| flag | payload |，flag 标记 payload 是否为压缩后的数据
+const (
+	adaptiveFlagRaw        byte = 0
+	adaptiveFlagCompressed byte = 1
+)
+
+// CompressionOutcome 自适应压缩在写入时的决策
+type CompressionOutcome string
+
+const (
+	// CompressionApplied 以压缩形式存储
+	CompressionApplied CompressionOutcome = "applied"
+	// CompressionTooSmall 小于阈值，未尝试压缩
+	CompressionTooSmall CompressionOutcome = "too_small"
+	// CompressionRejected 压缩后节省不足，原样存储
+	CompressionRejected CompressionOutcome = "rejected"
+)
+
+// CompressionMetrics 自适应压缩的写入结果
+type CompressionMetrics interface {
+	// RecordCompression raw 为原始长度，compressed 为压缩后的长度，未尝试压缩时为 0
+	RecordCompression(ctx context.Context, outcome CompressionOutcome, raw, compressed int)
+}
+
+// AdaptiveCompressionConfig 自适应压缩配置
+type AdaptiveCompressionConfig struct {
+	Codec CompressionCodec
+	// 小于 MinSize 字节的值不压缩，为 0 时全部尝试压缩
+	MinSize int
+	// 压缩后至少节省的比例，取值 [0, 1)，如 0.2 表示压缩后不超过原长度的 80% 才保留压缩结果。
+	// 为 0 时只要压缩后更短就保留
+	MinSavings float64
+}
+
+// Validate 校验配置
+func (c AdaptiveCompressionConfig) Validate() error {
+	var errs []error
+	if c.Codec == nil {
+		errs = append(errs, errors.New("adaptive compression codec is required"))
+	}
+	if c.MinSize < 0 {
+		errs = append(errs, fmt.Errorf("adaptive compression min size require >= 0, but got: %d", c.MinSize))
+	}
+	if c.MinSavings < 0 || c.MinSavings >= 1 {
+		errs = append(errs, fmt.Errorf("adaptive compression min savings require in [0, 1), but got: %v", c.MinSavings))
+	}
+	return errors.Join(errs...)
+}
+
+var _ cache.Cache[[]byte] = (*AdaptiveCompressionDecorator)(nil)
+
+// AdaptiveCompressionDecorator 只压缩超过阈值且节省足够的值，避免小值压缩后变大
+//
+// 每个值前附加 1 字节标记，读取时按标记决定是否解压，因此两种形式可以混合存储。
+// 与 CompressionDecorator 的存储格式不兼容
+type AdaptiveCompressionDecorator struct {
+	cache.Cache[[]byte]
+	cfg     AdaptiveCompressionConfig
+	metrics []CompressionMetrics
+}
+
+// NewAdaptiveCompressionDecorator ob 可以为空，用于上报 CompressionMetrics
+func NewAdaptiveCompressionDecorator(next cache.Cache[[]byte], cfg AdaptiveCompressionConfig, ob *telemetry.Observable) (*AdaptiveCompressionDecorator, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	d := &AdaptiveCompressionDecorator{
+		Cache: next,
+		cfg:   cfg,
+	}
+	if ob != nil {
+		d.metrics = telemetry.MetricsAs[CompressionMetrics](ob.Metrics)
+	}
+	return d, nil
+}
+
+func (d *AdaptiveCompressionDecorator) Get(ctx context.Context, key string, opts ...cache.CallOption) ([]byte, error) {
+	raw, err := d.Cache.Get(ctx, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return d.decode(ctx, raw)
+}
+
+func (d *AdaptiveCompressionDecorator) GetWithTTL(ctx context.Context, key string, opts ...cache.CallOption) ([]byte, time.Duration, error) {
+	raw, ttl, err := d.Cache.GetWithTTL(ctx, key, opts...)
+	if err != nil {
+		return nil, 0, err
+	}
+	decoded, err := d.decode(ctx, raw)
+	if err != nil {
+		return nil, 0, err
+	}
+	return decoded, ttl, nil
+}
+
+func (d *AdaptiveCompressionDecorator) Set(ctx context.Context, key string, val []byte, ttl time.Duration, opts ...cache.CallOption) error {
+	encoded, err := d.encode(ctx, val)
+	if err != nil {
+		return err
+	}
+	return d.Cache.Set(ctx, key, encoded, ttl, opts...)
+}
+
+// RetainsValue 写入的总是带标记的新字节，Set 返回后不再引用 val
+func (d *AdaptiveCompressionDecorator) RetainsValue() bool {
+	return false
+}
+
+func (d *AdaptiveCompressionDecorator) encode(ctx context.Context, val []byte) ([]byte, error) {
+	// 按原样存储的长度分配，压缩结果通常更短，可以直接写入同一块内存
+	out := make([]byte, 1, 1+len(val))
+	outcome, compressedLen := CompressionTooSmall, 0
+	if len(val) >= d.cfg.MinSize && len(val) > 0 {
+		compressed, err := d.compress(out, val)
+		if err != nil {
+			return nil, err
+		}
+		compressedLen = len(compressed) - 1
+		outcome = CompressionRejected
+		if float64(compressedLen) <= float64(len(val))*(1-d.cfg.MinSavings) && compressedLen < len(val) {
+			outcome = CompressionApplied
+			d.record(ctx, outcome, len(val), compressedLen)
+			compressed[0] = adaptiveFlagCompressed
+			return compressed, nil
+		}
+	}
+	d.record(ctx, outcome, len(val), compressedLen)
+	out[0] = adaptiveFlagRaw
+	return append(out, val...), nil
+}
+
+// compress 将压缩结果追加到 dst 之后
+func (d *AdaptiveCompressionDecorator) compress(dst, val []byte) ([]byte, error) {
+	if ca, ok := d.cfg.Codec.(CompressionAppender); ok {
+		return ca.AppendCompress(dst, val)
+	}
+	compressed, err := d.cfg.Codec.Compress(val)
+	if err != nil {
+		return nil, err
+	}
+	return append(dst, compressed...), nil
+}
+
+func (d *AdaptiveCompressionDecorator) decode(ctx context.Context, raw []byte) ([]byte, error) {
+	if len(raw) == 0 {
+		return nil, errors.New("adaptive compression: missing flag byte")
+	}
+	payload := raw[1:]
+	switch raw[0] {
+	case adaptiveFlagRaw:
+		RecordPayloadBytes(ctx, len(payload), len(payload))
+		return payload, nil
+	case adaptiveFlagCompressed:
+		decoded, err := d.cfg.Codec.Decompress(payload)
+		if err != nil {
+			return nil, err
+		}
+		RecordPayloadBytes(ctx, len(decoded), len(payload))
+		return decoded, nil
+	default:
+		return nil, fmt.Errorf("adaptive compression: unknown flag %#x", raw[0])
+	}
+}
+
+func (d *AdaptiveCompressionDecorator) record(ctx context.Context, outcome CompressionOutcome, raw, compressed int) {
+	stored := raw
+	if outcome == CompressionApplied {
+		stored = compressed
+	}
+	RecordPayloadBytes(ctx, raw, stored)
+	telemetry.AddCustomFields(ctx, map[string]string{"compression": string(outcome)})
+	for _, m := range d.metrics {
+		m.RecordCompression(ctx, outcome, raw, compressed)
+	}
+}
@@ -0,0 +1,93 @@
+package decorator_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/compress"
+	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/telemetry"
+	"github.com/yikakia/cachalot/internal/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+type compressionRecord struct {
+	outcome         decorator.CompressionOutcome
+	raw, compressed int
+}
+
+type compressionMetrics struct {
+	telemetry.Metrics
+	records []compressionRecord
+}
+
+func (m *compressionMetrics) RecordCompression(_ context.Context, outcome decorator.CompressionOutcome, raw, compressed int) {
+	m.records = append(m.records, compressionRecord{outcome: outcome, raw: raw, compressed: compressed})
+}
+
+func TestAdaptiveCompressionDecorator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	stored := map[string][]byte{}
+	next := mocks.NewMockCache[[]byte](ctrl)
+	next.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), time.Minute).DoAndReturn(
+		func(_ context.Context, key string, val []byte, _ time.Duration, _ ...cache.CallOption) error {
+			stored[key] = val
+			return nil
+		}).AnyTimes()
+	next.EXPECT().Get(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, key string, _ ...cache.CallOption) ([]byte, error) {
+			return stored[key], nil
+		}).AnyTimes()
+
+	metrics := &compressionMetrics{}
+	d, err := decorator.NewAdaptiveCompressionDecorator(next, decorator.AdaptiveCompressionConfig{
+		Codec:      compress.GzipCompression{},
+		MinSize:    64,
+		MinSavings: 0.2,
+	}, &telemetry.Observable{Metrics: metrics})
+	require.NoError(t, err)
+
+	small := []byte("tiny")
+	large := bytes.Repeat([]byte("cachalot "), 100)
+	// gzip 头部开销使得短的随机内容压缩后不会节省 20%
+	noisy := []byte("q8Zt1xV0pLm3Rk7Yw2Hn5Bc9Fd4Gs6Ja0Eo1Iu2Ty3Mr4Ne5Wb6Kc7Xv8Pz9Qa0Ls1Dh2Gf3Jk4")
+
+	for _, v := range [][]byte{small, large, noisy} {
+		require.NoError(t, d.Set(ctx, string(v[:4]), v, time.Minute))
+		got, err := d.Get(ctx, string(v[:4]))
+		require.NoError(t, err)
+		require.Equal(t, v, got)
+	}
+
+	require.Equal(t, append([]byte{0}, small...), stored["tiny"])
+	require.Equal(t, byte(1), stored["cach"][0])
+	require.Less(t, len(stored["cach"]), len(large))
+	require.Equal(t, append([]byte{0}, noisy...), stored["q8Zt"])
+
+	require.Len(t, metrics.records, 3)
+	require.Equal(t, compressionRecord{outcome: decorator.CompressionTooSmall, raw: 4}, metrics.records[0])
+	require.Equal(t, decorator.CompressionApplied, metrics.records[1].outcome)
+	require.Equal(t, len(stored["cach"])-1, metrics.records[1].compressed)
+	require.Equal(t, decorator.CompressionRejected, metrics.records[2].outcome)
+	require.Positive(t, metrics.records[2].compressed)
+
+	stored["bad"] = []byte{9, 1, 2}
+	_, err = d.Get(ctx, "bad")
+	require.ErrorContains(t, err, "unknown flag")
+	stored["empty"] = nil
+	_, err = d.Get(ctx, "empty")
+	require.Error(t, err)
+}
+
+func TestAdaptiveCompressionConfigValidate(t *testing.T) {
+	require.Error(t, decorator.AdaptiveCompressionConfig{}.Validate())
+	require.Error(t, decorator.AdaptiveCompressionConfig{Codec: compress.GzipCompression{}, MinSize: -1}.Validate())
+	require.Error(t, decorator.AdaptiveCompressionConfig{Codec: compress.GzipCompression{}, MinSavings: 1}.Validate())
+	require.NoError(t, decorator.AdaptiveCompressionConfig{Codec: compress.GzipCompression{}, MinSavings: 0.5}.Validate())
+}
@@ -0,0 +1,182 @@
+package decorator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/yikakia/cachalot/core/bloom"
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/telemetry"
+)
+
+var _ cache.Cache[any] = (*BloomGuardDecorator[any])(nil)
+
+// BloomGuardMetrics 如果 telemetry.Metrics 实现了该接口，则会上报布隆过滤器的拦截与误判
+type BloomGuardMetrics interface {
+	// RecordBloomRejected 过滤器判定 key 一定不存在，直接返回 cache.ErrNotFound
+	RecordBloomRejected(ctx context.Context)
+	// RecordBloomFalsePositive 过滤器判定 key 可能存在，但下游依旧未命中
+	RecordBloomFalsePositive(ctx context.Context)
+	// RecordBloomFalsePositiveRate 放行的请求完成后上报当前代过滤器观测到的误判率，过滤器轮换后重新统计
+	RecordBloomFalsePositiveRate(ctx context.Context, rate float64)
+}
+
+type BloomGuardConfig[T any] struct {
+	Cache  cache.Cache[T]
+	Filter bloom.KeyFilter
+	// 过滤器查询失败时，是否放行到下游 默认 false 即查询失败直接返回错误
+	FailOpen bool
+	Observer *telemetry.Observable
+}
+
+func NewBloomGuardDecorator[T any](config BloomGuardConfig[T]) *BloomGuardDecorator[T] {
+	d := &BloomGuardDecorator[T]{
+		cache:    config.Cache,
+		filter:   config.Filter,
+		failOpen: config.FailOpen,
+		ob:       config.Observer,
+	}
+	if config.Observer != nil {
+		d.metrics = telemetry.MetricsAs[BloomGuardMetrics](config.Observer.Metrics)
+	}
+	return d
+}
+
+// BloomGuardDecorator 基于布隆过滤器的防穿透装饰器
+//
+// Get GetWithTTL 时先查询过滤器，一定不存在的 key 直接返回 cache.ErrNotFound，不访问下游存储与回源函数。
+// Set 时会先把 key 写入过滤器。过滤器无法删除元素，Delete Clear 不影响过滤器，需要通过 bloom.Rotating 定期重建。
+// 如果启用了观测，则会在 Get GetWithTTL 中注入
+// bloom  rejected,false_positive 标明该请求是被拦截还是误判
+type BloomGuardDecorator[T any] struct {
+	cache    cache.Cache[T]
+	filter   bloom.KeyFilter
+	failOpen bool
+	ob       *telemetry.Observable
+	metrics  []BloomGuardMetrics
+
+	window atomic.Pointer[bloomWindow]
+}
+
+// bloomWindow 单代过滤器的放行与误判计数
+type bloomWindow struct {
+	generation     uint64
+	passed         atomic.Uint64
+	falsePositives atomic.Uint64
+}
+
+func (w *bloomWindow) rate() float64 {
+	passed := w.passed.Load()
+	if passed == 0 {
+		return 0
+	}
+	return float64(w.falsePositives.Load()) / float64(passed)
+}
+
+func (d *BloomGuardDecorator[T]) Get(ctx context.Context, key string, opts ...cache.CallOption) (T, error) {
+	var zero T
+	window, err := d.guard(ctx, key)
+	if err != nil {
+		return zero, err
+	}
+	val, err := d.cache.Get(ctx, key, opts...)
+	if window != nil {
+		d.observeResult(ctx, window, err)
+	}
+	return val, err
+}
+
+func (d *BloomGuardDecorator[T]) GetWithTTL(ctx context.Context, key string, opts ...cache.CallOption) (T, time.Duration, error) {
+	var zero T
+	window, err := d.guard(ctx, key)
+	if err != nil {
+		return zero, 0, err
+	}
+	val, ttl, err := d.cache.GetWithTTL(ctx, key, opts...)
+	if window != nil {
+		d.observeResult(ctx, window, err)
+	}
+	return val, ttl, err
+}
+
+// guard 在过滤器判定 key 可能存在时返回放行所在代的计数，只有这种情况下的未命中才算误判；
+// 查询失败后放行（FailOpen）不属于过滤器的判断，返回 nil，不计入误判率
+func (d *BloomGuardDecorator[T]) guard(ctx context.Context, key string) (*bloomWindow, error) {
+	window := d.currentWindow()
+	ok, err := d.filter.MayContain(ctx, key)
+	if err != nil {
+		if !d.failOpen {
+			return nil, fmt.Errorf("[BloomGuardDecorator] query filter failed: %w", err)
+		}
+		if d.ob != nil && d.ob.Logger != nil {
+			d.ob.Logger.WarnContext(ctx, "[BloomGuardDecorator] query filter failed, fail open.", "key", key, "err", err)
+		}
+		return nil, nil
+	}
+	if !ok {
+		telemetry.AddCustomFields(ctx, map[string]string{"bloom": "rejected"})
+		for _, m := range d.metrics {
+			m.RecordBloomRejected(ctx)
+		}
+		return nil, fmt.Errorf("key:%s rejected by bloom guard. %w", key, cache.ErrNotFound)
+	}
+	window.passed.Add(1)
+	return window, nil
+}
+
+func (d *BloomGuardDecorator[T]) observeResult(ctx context.Context, window *bloomWindow, err error) {
+	falsePositive := errors.Is(err, cache.ErrNotFound)
+	if falsePositive {
+		window.falsePositives.Add(1)
+		telemetry.AddCustomFields(ctx, map[string]string{"bloom": "false_positive"})
+	}
+	rate := window.rate()
+	for _, m := range d.metrics {
+		if falsePositive {
+			m.RecordBloomFalsePositive(ctx)
+		}
+		m.RecordBloomFalsePositiveRate(ctx, rate)
+	}
+}
+
+// currentWindow 返回过滤器当前代的计数，过滤器实现了 bloom.Generational 时轮换到新一代后重新统计
+func (d *BloomGuardDecorator[T]) currentWindow() *bloomWindow {
+	var generation uint64
+	if g, ok := d.filter.(bloom.Generational); ok {
+		generation = g.Generation()
+	}
+	w := d.window.Load()
+	// 并发读到旧代数时沿用已切换的新一代计数，不回退
+	if w != nil && w.generation >= generation {
+		return w
+	}
+	next := &bloomWindow{generation: generation}
+	if d.window.CompareAndSwap(w, next) {
+		return next
+	}
+	return d.window.Load()
+}
+
+// FalsePositiveRate 返回当前代过滤器观测到的误判率：过滤器放行但下游未命中的请求占放行请求的比例
+func (d *BloomGuardDecorator[T]) FalsePositiveRate() float64 {
+	return d.currentWindow().rate()
+}
+
+func (d *BloomGuardDecorator[T]) Set(ctx context.Context, key string, val T, ttl time.Duration, opts ...cache.CallOption) error {
+	// 先写过滤器，避免写入缓存成功但过滤器中不存在导致后续请求被误拦截
+	if err := d.filter.Add(ctx, key); err != nil {
+		return fmt.Errorf("[BloomGuardDecorator] add key to filter failed: %w", err)
+	}
+	return d.cache.Set(ctx, key, val, ttl, opts...)
+}
+
+func (d *BloomGuardDecorator[T]) Delete(ctx context.Context, key string, opts ...cache.CallOption) error {
+	return d.cache.Delete(ctx, key, opts...)
+}
+
+func (d *BloomGuardDecorator[T]) Clear(ctx context.Context) error {
+	return d.cache.Clear(ctx)
+}
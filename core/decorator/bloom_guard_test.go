@@ -0,0 +1,153 @@
+package decorator_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot/core/bloom"
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/telemetry"
+	"github.com/yikakia/cachalot/internal/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+type bloomMetrics struct {
+	telemetry.Metrics
+	rejected       int
+	falsePositives int
+	rate           float64
+}
+
+func (b *bloomMetrics) RecordBloomRejected(context.Context)      { b.rejected++ }
+func (b *bloomMetrics) RecordBloomFalsePositive(context.Context) { b.falsePositives++ }
+func (b *bloomMetrics) RecordBloomFalsePositiveRate(_ context.Context, rate float64) {
+	b.rate = rate
+}
+
+func TestBloomGuardDecorator(t *testing.T) {
+	ctx := context.Background()
+	ttl := time.Minute
+
+	ctrl := gomock.NewController(t)
+	mockCache := mocks.NewMockCache[string](ctrl)
+
+	filter, err := bloom.NewLocalFilter(100, 0.001)
+	require.NoError(t, err)
+	require.NoError(t, filter.Add(ctx, "exist"))
+
+	metrics := &bloomMetrics{Metrics: telemetry.NoopMetrics()}
+	d := decorator.NewBloomGuardDecorator(decorator.BloomGuardConfig[string]{
+		Cache:    mockCache,
+		Filter:   filter,
+		Observer: &telemetry.Observable{Metrics: metrics, Logger: telemetry.SlogLogger()},
+	})
+
+	t.Run("absent key rejected without touching cache", func(t *testing.T) {
+		_, err := d.Get(ctx, "absent")
+		require.ErrorIs(t, err, cache.ErrNotFound)
+		_, _, err = d.GetWithTTL(ctx, "absent")
+		require.ErrorIs(t, err, cache.ErrNotFound)
+		require.Equal(t, 2, metrics.rejected)
+	})
+
+	t.Run("existing key passes through", func(t *testing.T) {
+		mockCache.EXPECT().Get(gomock.Any(), "exist").Return("v", nil)
+		v, err := d.Get(ctx, "exist")
+		require.NoError(t, err)
+		require.Equal(t, "v", v)
+	})
+
+	t.Run("miss after pass counts false positive", func(t *testing.T) {
+		mockCache.EXPECT().Get(gomock.Any(), "exist").Return("", cache.ErrNotFound)
+		_, err := d.Get(ctx, "exist")
+		require.ErrorIs(t, err, cache.ErrNotFound)
+		require.Equal(t, 1, metrics.falsePositives)
+		require.InDelta(t, 0.5, d.FalsePositiveRate(), 1e-9)
+		require.InDelta(t, 0.5, metrics.rate, 1e-9)
+	})
+
+	t.Run("set adds key to filter", func(t *testing.T) {
+		mockCache.EXPECT().Set(gomock.Any(), "new", "v", ttl).Return(nil)
+		require.NoError(t, d.Set(ctx, "new", "v", ttl))
+
+		mockCache.EXPECT().Get(gomock.Any(), "new").Return("v", nil)
+		v, err := d.Get(ctx, "new")
+		require.NoError(t, err)
+		require.Equal(t, "v", v)
+	})
+}
+
+type failingFilter struct{ bloom.KeyFilter }
+
+func (failingFilter) MayContain(context.Context, string) (bool, error) {
+	return false, errors.New("filter unavailable")
+}
+
+func TestBloomGuardFailOpenNotCounted(t *testing.T) {
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	mockCache := mocks.NewMockCache[string](ctrl)
+
+	metrics := &bloomMetrics{Metrics: telemetry.NoopMetrics()}
+	d := decorator.NewBloomGuardDecorator(decorator.BloomGuardConfig[string]{
+		Cache:    mockCache,
+		Filter:   failingFilter{},
+		FailOpen: true,
+		Observer: &telemetry.Observable{Metrics: metrics, Logger: telemetry.SlogLogger()},
+	})
+
+	// 过滤器没有给出判断，下游未命中不算误判
+	mockCache.EXPECT().Get(gomock.Any(), "k").Return("", cache.ErrNotFound)
+	_, err := d.Get(ctx, "k")
+	require.ErrorIs(t, err, cache.ErrNotFound)
+	mockCache.EXPECT().GetWithTTL(gomock.Any(), "k").Return("", time.Duration(0), cache.ErrNotFound)
+	_, _, err = d.GetWithTTL(ctx, "k")
+	require.ErrorIs(t, err, cache.ErrNotFound)
+
+	require.Zero(t, metrics.falsePositives)
+	require.Zero(t, d.FalsePositiveRate())
+}
+
+func TestBloomGuardRateResetsOnRotation(t *testing.T) {
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	mockCache := mocks.NewMockCache[string](ctrl)
+
+	filter, err := bloom.NewRotating(ctx, bloom.RotatingConfig{
+		NewFilter: func(ctx context.Context, generation uint64) (bloom.KeyFilter, error) {
+			return bloom.NewLocalFilter(100, 0.001)
+		},
+		Enumerator: func(ctx context.Context, add func(key string) error) error {
+			return add("exist")
+		},
+	})
+	require.NoError(t, err)
+
+	metrics := &bloomMetrics{Metrics: telemetry.NoopMetrics()}
+	d := decorator.NewBloomGuardDecorator(decorator.BloomGuardConfig[string]{
+		Cache:    mockCache,
+		Filter:   filter,
+		Observer: &telemetry.Observable{Metrics: metrics},
+	})
+
+	mockCache.EXPECT().Get(gomock.Any(), "exist").Return("", cache.ErrNotFound)
+	_, err = d.Get(ctx, "exist")
+	require.ErrorIs(t, err, cache.ErrNotFound)
+	require.Equal(t, 1.0, d.FalsePositiveRate())
+	require.Equal(t, 1.0, metrics.rate)
+
+	// 新一代重新统计，上一代的误判不再计入
+	require.NoError(t, filter.Rebuild(ctx))
+	require.Zero(t, d.FalsePositiveRate())
+	mockCache.EXPECT().Get(gomock.Any(), "exist").Return("v", nil)
+	_, err = d.Get(ctx, "exist")
+	require.NoError(t, err)
+	require.Zero(t, metrics.rate)
+	require.Equal(t, 1, metrics.falsePositives)
+}
@@ -0,0 +1,39 @@
+package decorator
+
+import (
+	"context"
+	"time"
+
+	"github.com/yikakia/cachalot/core/cache"
+)
+
+// 字节层之间复用缓冲区的可选接口。
+//
+// 以 CodecDecorator -> CompressionDecorator -> Store 为例：
+// Set 时编码结果只是压缩的输入，压缩不会保留它，因此可以使用池化的缓冲区；
+// Get 时解压结果只是解码的输入，解码完成后即可归还。
+// 未实现这些接口的层保持原有的分配方式
+
+// ValueRetainer 可选接口，由 []byte 层实现
+//
+// RetainsValue 返回 false 表示 Set 返回后不再引用 val，上层可以传入池化的缓冲区并在返回后复用。
+// 未实现该接口的层（如直接写入 Store 的 BaseCache）视为会保留 val
+type ValueRetainer interface {
+	RetainsValue() bool
+}
+
+// BufferGetter 可选接口，由 []byte 层实现
+//
+// 返回的字节来自缓冲池，调用 release 后不能再使用，也不能被调用方保留
+type BufferGetter interface {
+	GetBuffer(ctx context.Context, key string, opts ...cache.CallOption) (val []byte, release func(), err error)
+	GetBufferWithTTL(ctx context.Context, key string, opts ...cache.CallOption) (val []byte, ttl time.Duration, release func(), err error)
+}
+
+// RetainsValue c 未实现 ValueRetainer 时返回 true
+func RetainsValue(c cache.Cache[[]byte]) bool {
+	r, ok := c.(ValueRetainer)
+	return !ok || r.RetainsValue()
+}
+
+func noRelease() {}
@@ -0,0 +1,109 @@
+package decorator_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/codec"
+	"github.com/yikakia/cachalot/core/compress"
+	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/internal/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+type bufferUser struct {
+	ID   int    `json:"id"`
+	Bio  string `json:"bio"`
+	Tags []string
+}
+
+func TestPooledBuffers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	// mock 直接保留 Set 的入参，若上层把池化的缓冲区传给它，后续写入会改写已存储的值
+	stored := map[string][]byte{}
+	store := mocks.NewMockCache[[]byte](ctrl)
+	store.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), time.Minute).DoAndReturn(
+		func(_ context.Context, key string, val []byte, _ time.Duration, _ ...cache.CallOption) error {
+			stored[key] = val
+			return nil
+		}).AnyTimes()
+	store.EXPECT().Get(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, key string, _ ...cache.CallOption) ([]byte, error) {
+			return stored[key], nil
+		}).AnyTimes()
+	store.EXPECT().GetWithTTL(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, key string, _ ...cache.CallOption) ([]byte, time.Duration, error) {
+			return stored[key], time.Minute, nil
+		}).AnyTimes()
+	require.True(t, decorator.RetainsValue(store))
+
+	for name, next := range map[string]cache.Cache[[]byte]{
+		"store":    store,
+		"checksum": decorator.NewChecksumDecorator(store, decorator.ChecksumConfig{}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			compression := decorator.NewCompressionDecorator(next, compress.GzipCompression{})
+			require.False(t, decorator.RetainsValue(compression))
+			c := &decorator.CodecDecorator[bufferUser]{Cache: compression, Codec: codec.JSONCodec{}}
+
+			want := func(i int) bufferUser {
+				return bufferUser{ID: i, Bio: strings.Repeat(fmt.Sprint(i), 100), Tags: []string{"whale"}}
+			}
+			for i := range 20 {
+				require.NoError(t, c.Set(ctx, fmt.Sprint(i), want(i), time.Minute))
+			}
+			for i := range 20 {
+				got, err := c.Get(ctx, fmt.Sprint(i))
+				require.NoError(t, err)
+				require.Equal(t, want(i), got)
+
+				got, ttl, err := c.GetWithTTL(ctx, fmt.Sprint(i))
+				require.NoError(t, err)
+				require.Equal(t, want(i), got)
+				require.Equal(t, time.Minute, ttl)
+			}
+		})
+	}
+}
+
+// aliasCodec 解码结果直接引用输入，没有实现 codec.NoRetain
+type aliasCodec struct{}
+
+func (aliasCodec) Marshal(v any) ([]byte, error) { return v.([]byte), nil }
+
+func (aliasCodec) Unmarshal(data []byte, v any) error {
+	*v.(*[]byte) = data
+	return nil
+}
+
+func TestPooledBuffers_RetainingCodec(t *testing.T) {
+	ctx := context.Background()
+	next, _ := newMapCache(t)
+	require.True(t, codec.RetainsData(aliasCodec{}))
+	require.False(t, codec.RetainsData(codec.JSONCodec{}))
+	require.False(t, codec.RetainsData(codec.VersionedCodec{Codec: codec.GobCodec{}, Version: 1}))
+	require.True(t, codec.RetainsData(codec.VersionedCodec{Codec: aliasCodec{}, Version: 1}))
+
+	c := &decorator.CodecDecorator[[]byte]{
+		Cache: decorator.NewCompressionDecorator(next, compress.GzipCompression{}),
+		Codec: aliasCodec{},
+	}
+	var got [][]byte
+	for i := range 10 {
+		require.NoError(t, c.Set(ctx, fmt.Sprint(i), []byte(strings.Repeat(fmt.Sprint(i), 100)), time.Minute))
+		v, err := c.Get(ctx, fmt.Sprint(i))
+		require.NoError(t, err)
+		got = append(got, v)
+	}
+	// 解码结果引用输入时，输入不能来自缓冲池，否则之后的读取会改写之前返回的值
+	for i, v := range got {
+		require.Equal(t, strings.Repeat(fmt.Sprint(i), 100), string(v))
+	}
+}
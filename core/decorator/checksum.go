@@ -0,0 +1,101 @@
+package decorator
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"sync"
+	"time"
+
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/telemetry"
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// ChecksumConfig 校验和配置
+type ChecksumConfig struct {
+	// 为空时使用 CRC32C；使用 xxhash 时传入 func() hash.Hash { return xxhash.New() }
+	NewHash func() hash.Hash
+}
+
+var _ cache.Cache[[]byte] = (*ChecksumDecorator)(nil)
+
+// ChecksumDecorator 写入时在值末尾追加摘要，读取时校验
+//
+// 校验失败时返回包裹 cache.ErrCorrupted 的错误，并在事件中写入自定义字段 checksum=mismatch
+type ChecksumDecorator struct {
+	cache.Cache[[]byte]
+	size int
+	sum  func(dst, data []byte) []byte
+}
+
+func NewChecksumDecorator(next cache.Cache[[]byte], cfg ChecksumConfig) *ChecksumDecorator {
+	d := &ChecksumDecorator{Cache: next}
+	if cfg.NewHash == nil {
+		d.size = crc32.Size
+		d.sum = func(dst, data []byte) []byte {
+			return binary.BigEndian.AppendUint32(dst, crc32.Checksum(data, castagnoli))
+		}
+		return d
+	}
+
+	pool := sync.Pool{New: func() any { return cfg.NewHash() }}
+	d.size = cfg.NewHash().Size()
+	d.sum = func(dst, data []byte) []byte {
+		h := pool.Get().(hash.Hash)
+		defer pool.Put(h)
+		h.Reset()
+		h.Write(data)
+		return h.Sum(dst)
+	}
+	return d
+}
+
+func (d *ChecksumDecorator) Get(ctx context.Context, key string, opts ...cache.CallOption) ([]byte, error) {
+	raw, err := d.Cache.Get(ctx, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return d.verify(ctx, key, raw)
+}
+
+func (d *ChecksumDecorator) GetWithTTL(ctx context.Context, key string, opts ...cache.CallOption) ([]byte, time.Duration, error) {
+	raw, ttl, err := d.Cache.GetWithTTL(ctx, key, opts...)
+	if err != nil {
+		return nil, 0, err
+	}
+	val, err := d.verify(ctx, key, raw)
+	if err != nil {
+		return nil, 0, err
+	}
+	return val, ttl, nil
+}
+
+func (d *ChecksumDecorator) Set(ctx context.Context, key string, val []byte, ttl time.Duration, opts ...cache.CallOption) error {
+	out := make([]byte, len(val), len(val)+d.size)
+	copy(out, val)
+	return d.Cache.Set(ctx, key, d.sum(out, val), ttl, opts...)
+}
+
+// RetainsValue Set 时复制 val 后追加摘要，返回后不再引用 val
+func (d *ChecksumDecorator) RetainsValue() bool {
+	return false
+}
+
+func (d *ChecksumDecorator) verify(ctx context.Context, key string, raw []byte) ([]byte, error) {
+	if len(raw) < d.size {
+		telemetry.AddCustomFields(ctx, map[string]string{"checksum": "mismatch"})
+		return nil, fmt.Errorf("key:%s truncated to %d bytes: %w", key, len(raw), cache.ErrCorrupted)
+	}
+	payload, digest := raw[:len(raw)-d.size], raw[len(raw)-d.size:]
+	var buf [64]byte
+	if !bytes.Equal(d.sum(buf[:0], payload), digest) {
+		telemetry.AddCustomFields(ctx, map[string]string{"checksum": "mismatch"})
+		return nil, fmt.Errorf("key:%s checksum mismatch: %w", key, cache.ErrCorrupted)
+	}
+	return payload, nil
+}
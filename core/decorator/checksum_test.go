@@ -0,0 +1,66 @@
+package decorator_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"hash"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/telemetry"
+	"github.com/yikakia/cachalot/internal/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestChecksumDecorator(t *testing.T) {
+	for name, cfg := range map[string]decorator.ChecksumConfig{
+		"crc32c": {},
+		"sha256": {NewHash: func() hash.Hash { return sha256.New() }},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			ctx := context.Background()
+
+			stored := map[string][]byte{}
+			next := mocks.NewMockCache[[]byte](ctrl)
+			next.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), time.Minute).DoAndReturn(
+				func(_ context.Context, key string, val []byte, _ time.Duration, _ ...cache.CallOption) error {
+					stored[key] = val
+					return nil
+				}).AnyTimes()
+			next.EXPECT().Get(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, key string, _ ...cache.CallOption) ([]byte, error) {
+					return stored[key], nil
+				}).AnyTimes()
+
+			d := decorator.NewChecksumDecorator(next, cfg)
+			val := []byte(`{"name":"cachalot"}`)
+			require.NoError(t, d.Set(ctx, "k", val, time.Minute))
+			require.Greater(t, len(stored["k"]), len(val))
+
+			got, err := d.Get(ctx, "k")
+			require.NoError(t, err)
+			require.Equal(t, val, got)
+
+			// 内容被修改
+			stored["k"][0] ^= 1
+			evt := &telemetry.Event{}
+			_, err = d.Get(telemetry.ContextWithEvent(ctx, evt), "k")
+			require.ErrorIs(t, err, cache.ErrCorrupted)
+			require.Equal(t, "mismatch", evt.FrozenCustomFields()["checksum"])
+
+			// 被截断
+			stored["k"] = stored["k"][:2]
+			_, err = d.Get(ctx, "k")
+			require.ErrorIs(t, err, cache.ErrCorrupted)
+
+			require.NoError(t, d.Set(ctx, "empty", nil, time.Minute))
+			got, err = d.Get(ctx, "empty")
+			require.NoError(t, err)
+			require.Empty(t, got)
+		})
+	}
+}
@@ -6,50 +6,111 @@ import (
 
 	"github.com/yikakia/cachalot/core/cache"
 	"github.com/yikakia/cachalot/core/codec"
+	"github.com/yikakia/cachalot/core/telemetry"
+	"github.com/yikakia/cachalot/internal/bufpool"
 )
 
 var _ cache.Cache[any] = (*CodecDecorator[any])(nil)
 
+// CodecDecorator 完成 T <-> []byte 的转换
+//
+// Codec 实现 codec.SchemaVersioned 时，无法升级的旧版本值按未命中处理，见 UnmarshalValue。
+// Codec 实现 codec.AppendMarshaler 且下层不保留 Set 的入参（ValueRetainer）时编码到池化的缓冲区；
+// Codec 实现 codec.NoRetain 且下层实现 BufferGetter 时从池化的缓冲区解码
 type CodecDecorator[T any] struct {
 	cache.Cache[[]byte]
 	Codec codec.Codec
+	// 可选，用于上报 SchemaMetrics
+	Observer *telemetry.Observable
 }
 
 func (t *CodecDecorator[T]) Get(ctx context.Context, key string, opts ...cache.CallOption) (T, error) {
 	var zero T
-	get, err := t.Cache.Get(ctx, key, opts...)
-	if err != nil {
-		return zero, err
+	if bg, ok := t.bufferGetter(); ok {
+		get, release, err := bg.GetBuffer(ctx, key, opts...)
+		if err != nil {
+			return zero, err
+		}
+		defer release()
+		return t.unmarshal(ctx, get)
 	}
-	var target T
-	err = t.Codec.Unmarshal(get, &target)
+
+	get, err := t.Cache.Get(ctx, key, opts...)
 	if err != nil {
 		return zero, err
 	}
-
-	return target, nil
+	return t.unmarshal(ctx, get)
 }
 
 func (t *CodecDecorator[T]) Set(ctx context.Context, key string, val T, ttl time.Duration, opts ...cache.CallOption) error {
+	if _, ok := t.Codec.(codec.AppendMarshaler); ok && !RetainsValue(t.Cache) {
+		buf := bufpool.Get()
+		defer bufpool.Put(buf)
+		marshal, err := codec.AppendMarshal(t.Codec, *buf, val)
+		if err != nil {
+			return err
+		}
+		*buf = marshal
+		recordEncodedBytes(ctx, len(marshal))
+		return t.Cache.Set(ctx, key, marshal, ttl, opts...)
+	}
+
 	marshal, err := t.Codec.Marshal(val)
 	if err != nil {
 		return err
 	}
+	recordEncodedBytes(ctx, len(marshal))
 
 	return t.Cache.Set(ctx, key, marshal, ttl, opts...)
 }
 
 func (t *CodecDecorator[T]) GetWithTTL(ctx context.Context, key string, opts ...cache.CallOption) (T, time.Duration, error) {
 	var zero T
+	if bg, ok := t.bufferGetter(); ok {
+		get, ttl, release, err := bg.GetBufferWithTTL(ctx, key, opts...)
+		if err != nil {
+			return zero, 0, err
+		}
+		defer release()
+		target, err := t.unmarshal(ctx, get)
+		if err != nil {
+			return zero, 0, err
+		}
+		return target, ttl, nil
+	}
+
 	get, ttl, err := t.Cache.GetWithTTL(ctx, key, opts...)
 	if err != nil {
 		return zero, 0, err
 	}
-
-	var target T
-	err = t.Codec.Unmarshal(get, &target)
+	target, err := t.unmarshal(ctx, get)
 	if err != nil {
 		return zero, 0, err
 	}
 	return target, ttl, nil
 }
+
+func (t *CodecDecorator[T]) unmarshal(ctx context.Context, data []byte) (T, error) {
+	recordEncodedBytes(ctx, len(data))
+	var target T
+	if err := UnmarshalValue(ctx, t.Observer, t.Codec, data, &target); err != nil {
+		var zero T
+		return zero, err
+	}
+	return target, nil
+}
+
+func recordEncodedBytes(ctx context.Context, n int) {
+	telemetry.UpdateDetails(ctx, func(d *telemetry.Details) {
+		d.EncodedBytes = n
+	})
+}
+
+// bufferGetter 解码结果可能引用输入时不能使用池化的缓冲区
+func (t *CodecDecorator[T]) bufferGetter() (BufferGetter, bool) {
+	if codec.RetainsData(t.Codec) {
+		return nil, false
+	}
+	bg, ok := t.Cache.(BufferGetter)
+	return bg, ok
+}
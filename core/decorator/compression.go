@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/telemetry"
+	"github.com/yikakia/cachalot/internal/bufpool"
 )
 
 type CompressionCodec interface {
@@ -12,18 +14,31 @@ type CompressionCodec interface {
 	Decompress(src []byte) ([]byte, error)
 }
 
+// CompressionAppender 可选接口，将结果追加到 dst 之后，调用方可以传入池化的缓冲区
+type CompressionAppender interface {
+	AppendCompress(dst, src []byte) ([]byte, error)
+	DecompressTo(dst, src []byte) ([]byte, error)
+}
+
 var _ cache.Cache[[]byte] = (*CompressionDecorator)(nil)
+var _ BufferGetter = (*CompressionDecorator)(nil)
+var _ ValueRetainer = (*CompressionDecorator)(nil)
 
+// CompressionDecorator codec 实现 CompressionAppender 时：
+// 下层不保留 Set 的入参则压缩到池化的缓冲区；上层通过 BufferGetter 读取时解压到池化的缓冲区
 type CompressionDecorator struct {
 	cache.Cache[[]byte]
-	codec CompressionCodec
+	codec    CompressionCodec
+	appender CompressionAppender
 }
 
 func NewCompressionDecorator(next cache.Cache[[]byte], codec CompressionCodec) *CompressionDecorator {
-	return &CompressionDecorator{
+	d := &CompressionDecorator{
 		Cache: next,
 		codec: codec,
 	}
+	d.appender, _ = codec.(CompressionAppender)
+	return d
 }
 
 func (d *CompressionDecorator) Get(ctx context.Context, key string, opts ...cache.CallOption) ([]byte, error) {
@@ -31,14 +46,32 @@ func (d *CompressionDecorator) Get(ctx context.Context, key string, opts ...cach
 	if err != nil {
 		return nil, err
 	}
-	return d.codec.Decompress(raw)
+	decoded, err := d.codec.Decompress(raw)
+	if err != nil {
+		return nil, err
+	}
+	RecordPayloadBytes(ctx, len(decoded), len(raw))
+	return decoded, nil
 }
 
 func (d *CompressionDecorator) Set(ctx context.Context, key string, val []byte, ttl time.Duration, opts ...cache.CallOption) error {
+	if d.appender != nil && !RetainsValue(d.Cache) {
+		buf := bufpool.Get()
+		defer bufpool.Put(buf)
+		compressed, err := d.appender.AppendCompress(*buf, val)
+		if err != nil {
+			return err
+		}
+		*buf = compressed
+		RecordPayloadBytes(ctx, len(val), len(compressed))
+		return d.Cache.Set(ctx, key, compressed, ttl, opts...)
+	}
+
 	compressed, err := d.codec.Compress(val)
 	if err != nil {
 		return err
 	}
+	RecordPayloadBytes(ctx, len(val), len(compressed))
 	return d.Cache.Set(ctx, key, compressed, ttl, opts...)
 }
 
@@ -51,5 +84,59 @@ func (d *CompressionDecorator) GetWithTTL(ctx context.Context, key string, opts
 	if err != nil {
 		return nil, 0, err
 	}
+	RecordPayloadBytes(ctx, len(decoded), len(raw))
 	return decoded, ttl, nil
 }
+
+// RetainsValue 压缩结果是新的字节，Set 返回后不再引用 val
+func (d *CompressionDecorator) RetainsValue() bool {
+	return false
+}
+
+func (d *CompressionDecorator) GetBuffer(ctx context.Context, key string, opts ...cache.CallOption) ([]byte, func(), error) {
+	if d.appender == nil {
+		val, err := d.Get(ctx, key, opts...)
+		return val, noRelease, err
+	}
+	raw, err := d.Cache.Get(ctx, key, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d.decompressBuffer(ctx, raw)
+}
+
+func (d *CompressionDecorator) GetBufferWithTTL(ctx context.Context, key string, opts ...cache.CallOption) ([]byte, time.Duration, func(), error) {
+	if d.appender == nil {
+		val, ttl, err := d.GetWithTTL(ctx, key, opts...)
+		return val, ttl, noRelease, err
+	}
+	raw, ttl, err := d.Cache.GetWithTTL(ctx, key, opts...)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	val, release, err := d.decompressBuffer(ctx, raw)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return val, ttl, release, nil
+}
+
+func (d *CompressionDecorator) decompressBuffer(ctx context.Context, raw []byte) ([]byte, func(), error) {
+	buf := bufpool.Get()
+	decoded, err := d.appender.DecompressTo(*buf, raw)
+	if err != nil {
+		bufpool.Put(buf)
+		return nil, nil, err
+	}
+	*buf = decoded
+	RecordPayloadBytes(ctx, len(decoded), len(raw))
+	return decoded, func() { bufpool.Put(buf) }, nil
+}
+
+// RecordPayloadBytes 在当前事件中记录编码后与压缩后的长度，不包含信封等头部，读写两个方向口径一致
+func RecordPayloadBytes(ctx context.Context, encoded, compressed int) {
+	telemetry.UpdateDetails(ctx, func(d *telemetry.Details) {
+		d.EncodedBytes = encoded
+		d.CompressedBytes = compressed
+	})
+}
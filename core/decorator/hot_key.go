@@ -0,0 +1,102 @@
+package decorator
+
+import (
+	"context"
+	"time"
+
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/hotkey"
+	"github.com/yikakia/cachalot/core/telemetry"
+)
+
+var _ cache.Cache[any] = (*HotKeyDecorator[any])(nil)
+
+type HotKeyConfig[T any] struct {
+	Cache    cache.Cache[T]
+	Detector *hotkey.Detector
+	// 热点 key 提升到本地缓存后的存活时间，<= 0 表示只探测不提升
+	PromoteTTL time.Duration
+	// 用于上报 TopK
+	CacheName string
+	Observer  *telemetry.Observable
+}
+
+func NewHotKeyDecorator[T any](config HotKeyConfig[T]) *HotKeyDecorator[T] {
+	return &HotKeyDecorator[T]{
+		cache:     config.Cache,
+		promoter:  hotkey.NewPromoter[T](config.Detector, config.PromoteTTL),
+		cacheName: config.CacheName,
+		ob:        config.Observer,
+	}
+}
+
+// HotKeyDecorator 热点 key 探测与本地提升
+//
+// Get GetWithTTL 会记录访问频率，热点 key 的值会写入进程内的本地缓存，之后的读取直接命中本地缓存。
+// Set Delete 会使本地缓存失效，Clear 会清空本地缓存。
+// 如果启用了观测，则会在 Get GetWithTTL 中注入
+// hotkey  true,local 标明该 key 是热点，或直接命中了本地提升的缓存
+type HotKeyDecorator[T any] struct {
+	cache     cache.Cache[T]
+	promoter  *hotkey.Promoter[T]
+	cacheName string
+	ob        *telemetry.Observable
+}
+
+func (d *HotKeyDecorator[T]) Get(ctx context.Context, key string, opts ...cache.CallOption) (T, error) {
+	obs := d.promoter.Observe(ctx, key, d.ob, d.cacheName)
+	version := d.promoter.Version(key)
+	if val, _, ok := d.promoter.Lookup(key); ok {
+		telemetry.AddCustomFields(ctx, map[string]string{"hotkey": "local"})
+		return val, nil
+	}
+	val, err := d.cache.Get(ctx, key, opts...)
+	if err == nil && obs.Hot {
+		telemetry.AddCustomFields(ctx, map[string]string{"hotkey": "true"})
+		d.promoter.Promote(key, val, version)
+	}
+	return val, err
+}
+
+func (d *HotKeyDecorator[T]) GetWithTTL(ctx context.Context, key string, opts ...cache.CallOption) (T, time.Duration, error) {
+	obs := d.promoter.Observe(ctx, key, d.ob, d.cacheName)
+	version := d.promoter.Version(key)
+	if val, ttl, ok := d.promoter.Lookup(key); ok {
+		telemetry.AddCustomFields(ctx, map[string]string{"hotkey": "local"})
+		return val, ttl, nil
+	}
+	val, ttl, err := d.cache.GetWithTTL(ctx, key, opts...)
+	if err == nil && obs.Hot {
+		telemetry.AddCustomFields(ctx, map[string]string{"hotkey": "true"})
+		d.promoter.Promote(key, val, version)
+	}
+	return val, ttl, err
+}
+
+// HotKeys 返回当前热点 key
+func (d *HotKeyDecorator[T]) HotKeys() []hotkey.KeyCount {
+	return d.promoter.Detector().TopK()
+}
+
+func (d *HotKeyDecorator[T]) Set(ctx context.Context, key string, val T, ttl time.Duration, opts ...cache.CallOption) error {
+	d.promoter.Invalidate(key)
+	err := d.cache.Set(ctx, key, val, ttl, opts...)
+	// 写入期间读到旧值的请求可能已经拿到了版本，再次失效让其放弃提升
+	d.promoter.Invalidate(key)
+	return err
+}
+
+func (d *HotKeyDecorator[T]) Delete(ctx context.Context, key string, opts ...cache.CallOption) error {
+	d.promoter.Invalidate(key)
+	err := d.cache.Delete(ctx, key, opts...)
+	// 删除期间读到旧值的请求可能已经拿到了版本，再次失效让其放弃提升
+	d.promoter.Invalidate(key)
+	return err
+}
+
+func (d *HotKeyDecorator[T]) Clear(ctx context.Context) error {
+	d.promoter.Clear()
+	err := d.cache.Clear(ctx)
+	d.promoter.Clear()
+	return err
+}
@@ -0,0 +1,45 @@
+package decorator_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/hotkey"
+	"github.com/yikakia/cachalot/internal/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHotKeyDecorator_Promote(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	mockCache := mocks.NewMockCache[string](ctrl)
+
+	detector := hotkey.NewDetector(hotkey.Config{Window: time.Minute, TopK: 4, MinCount: 3})
+	d := decorator.NewHotKeyDecorator(decorator.HotKeyConfig[string]{
+		Cache:      mockCache,
+		Detector:   detector,
+		PromoteTTL: time.Minute,
+	})
+
+	// 前 3 次访问下游，第 3 次成为热点并提升
+	mockCache.EXPECT().Get(gomock.Any(), "k").Return("v", nil).Times(3)
+	for i := 0; i < 10; i++ {
+		v, err := d.Get(ctx, "k")
+		require.NoError(t, err)
+		require.Equal(t, "v", v)
+	}
+	require.Equal(t, []hotkey.KeyCount{{Key: "k", Count: 10}}, d.HotKeys())
+
+	// Set 使本地缓存失效
+	mockCache.EXPECT().Set(gomock.Any(), "k", "v2", time.Minute).Return(nil)
+	require.NoError(t, d.Set(ctx, "k", "v2", time.Minute))
+	mockCache.EXPECT().Get(gomock.Any(), "k").Return("v2", nil).Times(1)
+	for i := 0; i < 3; i++ {
+		v, err := d.Get(ctx, "k")
+		require.NoError(t, err)
+		require.Equal(t, "v2", v)
+	}
+}
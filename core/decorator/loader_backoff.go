@@ -0,0 +1,203 @@
+package decorator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/internal/lru"
+)
+
+// ErrLoaderBackoff 回源函数处于失败退避窗口内，本次没有真正调用回源函数
+var ErrLoaderBackoff = errors.New("loader in backoff")
+
+// LoaderFailureMetrics 如果 telemetry.Metrics 实现了该接口，则会上报回源失败退避与兜底的次数
+type LoaderFailureMetrics interface {
+	// RecordLoaderBackoff 处于退避窗口内，直接返回上一次的回源错误
+	RecordLoaderBackoff(ctx context.Context)
+	// RecordStaleServed 回源失败，返回了影子槽中的旧值
+	RecordStaleServed(ctx context.Context)
+}
+
+type LoaderFailureMemoConfig struct {
+	// 第一次失败后的退避窗口，之后每次失败翻倍 默认 100ms
+	InitialBackoff time.Duration
+	// 退避窗口上限 默认 10s
+	MaxBackoff time.Duration
+	// 影子槽保留最后一次成功回源的值，回源失败时可以用于兜底
+	// MaxStale 从缓存中的值过期或被淘汰后的第一次回源开始计算，与回写 TTL 无关；
+	// 超过 MaxStale 的旧值不再使用，0 表示不开启 stale-if-error
+	MaxStale time.Duration
+	// 最多记录的 key 数量，超过后按 LRU 淘汰 默认 10000
+	MaxEntries int
+	// 时钟，用于计算退避窗口与旧值的存活时间 默认 time.Now
+	Now func() time.Time
+}
+
+// LoaderFailureMemo 记录每个 key 的回源失败与最后一次成功回源的值
+//
+// 回源失败后，在退避窗口内再次回源会直接返回上一次的错误（包裹 ErrLoaderBackoff），窗口随连续失败次数指数增长。
+// 回源成功会重置退避状态；回源返回 cache.ErrNotFound 说明源数据已不存在，会同时清理影子槽。
+// 调用方自身的 ctx 被取消或超时导致的失败不计入退避，避免一个请求超时让所有请求进入退避窗口。
+type LoaderFailureMemo[T any] struct {
+	cfg LoaderFailureMemoConfig
+
+	// mu 保护 entries 中条目的字段，entries 自身的 LRU 顺序由其内部加锁维护
+	mu      sync.Mutex
+	entries *lru.Cache[*loaderFailureEntry[T]]
+}
+
+type loaderFailureEntry[T any] struct {
+	failures int
+	retryAt  time.Time
+	lastErr  error
+
+	hasGood bool
+	good    T
+	// 影子槽中的值成功回源后，缓存第一次未命中而回源的时间，即缓存中的值已过期或被淘汰
+	staleSince time.Time
+}
+
+func NewLoaderFailureMemo[T any](cfg LoaderFailureMemoConfig) *LoaderFailureMemo[T] {
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = 100 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 10 * time.Second
+	}
+	if cfg.MaxBackoff < cfg.InitialBackoff {
+		cfg.MaxBackoff = cfg.InitialBackoff
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 10000
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &LoaderFailureMemo[T]{
+		cfg:     cfg,
+		entries: lru.New[*loaderFailureEntry[T]](cfg.MaxEntries),
+	}
+}
+
+// Wrap 包裹回源函数，记录失败并在退避窗口内短路
+func (m *LoaderFailureMemo[T]) Wrap(fn LoaderFn[T]) LoaderFn[T] {
+	return func(ctx context.Context, key string, opts ...cache.CallOption) (T, error) {
+		var zero T
+		if err := m.checkBackoff(key); err != nil {
+			return zero, err
+		}
+		val, err := fn(ctx, key, opts...)
+		switch {
+		case err == nil:
+			m.onSuccess(key, val)
+		case errors.Is(err, cache.ErrNotFound):
+			m.forget(key)
+		case ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+			// 调用方放弃了本次请求，不代表源站故障
+		default:
+			m.onFailure(key, err)
+		}
+		return val, err
+	}
+}
+
+// Stale 返回影子槽中未超过 MaxStale 的旧值，从缓存中的值失效后第一次回源开始计算
+func (m *LoaderFailureMemo[T]) Stale(key string) (T, bool) {
+	var zero T
+	if m.cfg.MaxStale <= 0 {
+		return zero, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.lookup(key)
+	if !ok || !e.hasGood {
+		return zero, false
+	}
+	if e.staleSince.IsZero() || m.cfg.Now().Sub(e.staleSince) > m.cfg.MaxStale {
+		return zero, false
+	}
+	return e.good, true
+}
+
+// checkBackoff 每次回源前调用，回源说明缓存中的值已不可用，同时记录影子槽开始过期的时间
+func (m *LoaderFailureMemo[T]) checkBackoff(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.lookup(key)
+	if !ok {
+		return nil
+	}
+	now := m.cfg.Now()
+	if e.hasGood && e.staleSince.IsZero() {
+		e.staleSince = now
+	}
+	if e.failures == 0 || !now.Before(e.retryAt) {
+		return nil
+	}
+	return fmt.Errorf("key:%s %w until %s: %w", key, ErrLoaderBackoff, e.retryAt.Format(time.RFC3339Nano), e.lastErr)
+}
+
+func (m *LoaderFailureMemo[T]) onSuccess(key string, val T) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.cfg.MaxStale <= 0 {
+		m.remove(key)
+		return
+	}
+	e := m.getOrCreate(key)
+	e.failures = 0
+	e.lastErr = nil
+	e.retryAt = time.Time{}
+	e.hasGood = true
+	e.good = val
+	e.staleSince = time.Time{}
+}
+
+func (m *LoaderFailureMemo[T]) onFailure(key string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.getOrCreate(key)
+	e.failures++
+	e.lastErr = err
+	e.retryAt = m.cfg.Now().Add(m.backoff(e.failures))
+}
+
+func (m *LoaderFailureMemo[T]) forget(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(key)
+}
+
+func (m *LoaderFailureMemo[T]) backoff(failures int) time.Duration {
+	d := m.cfg.InitialBackoff
+	for i := 1; i < failures && d < m.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, m.cfg.MaxBackoff)
+}
+
+func (m *LoaderFailureMemo[T]) lookup(key string) (*loaderFailureEntry[T], bool) {
+	e, _, ok := m.entries.Get(key)
+	return e, ok
+}
+
+func (m *LoaderFailureMemo[T]) getOrCreate(key string) *loaderFailureEntry[T] {
+	if e, ok := m.lookup(key); ok {
+		return e
+	}
+	e := &loaderFailureEntry[T]{}
+	m.entries.Set(key, e, 0)
+	return e
+}
+
+func (m *LoaderFailureMemo[T]) remove(key string) {
+	m.entries.Delete(key)
+}
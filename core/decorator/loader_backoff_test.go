@@ -0,0 +1,198 @@
+package decorator_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/telemetry"
+	"github.com/yikakia/cachalot/internal/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+type loaderFailureMetrics struct {
+	telemetry.Metrics
+	backoff int
+	stale   int
+}
+
+func (l *loaderFailureMetrics) RecordLoaderBackoff(context.Context) { l.backoff++ }
+func (l *loaderFailureMetrics) RecordStaleServed(context.Context)   { l.stale++ }
+
+func TestLoaderFailureMemo_Backoff(t *testing.T) {
+	ctx := context.Background()
+	loadErr := errors.New("db down")
+	calls := 0
+	now := time.Now()
+
+	memo := decorator.NewLoaderFailureMemo[string](decorator.LoaderFailureMemoConfig{
+		InitialBackoff: 20 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Now:            func() time.Time { return now },
+	})
+	fn := memo.Wrap(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+		calls++
+		return "", loadErr
+	})
+
+	_, err := fn(ctx, "k")
+	require.ErrorIs(t, err, loadErr)
+	require.NotErrorIs(t, err, decorator.ErrLoaderBackoff)
+
+	// 退避窗口内不会调用回源函数
+	_, err = fn(ctx, "k")
+	require.ErrorIs(t, err, decorator.ErrLoaderBackoff)
+	require.ErrorIs(t, err, loadErr)
+	require.Equal(t, 1, calls)
+
+	// 其他 key 不受影响
+	_, err = fn(ctx, "other")
+	require.NotErrorIs(t, err, decorator.ErrLoaderBackoff)
+	require.Equal(t, 2, calls)
+
+	now = now.Add(30 * time.Millisecond)
+	_, err = fn(ctx, "k")
+	require.NotErrorIs(t, err, decorator.ErrLoaderBackoff)
+	require.Equal(t, 3, calls)
+
+	// 第二次失败后窗口翻倍
+	now = now.Add(30 * time.Millisecond)
+	_, err = fn(ctx, "k")
+	require.ErrorIs(t, err, decorator.ErrLoaderBackoff)
+	require.Equal(t, 3, calls)
+	now = now.Add(10 * time.Millisecond)
+	_, err = fn(ctx, "k")
+	require.NotErrorIs(t, err, decorator.ErrLoaderBackoff)
+	require.Equal(t, 4, calls)
+}
+
+func TestLoaderFailureMemo_CallerContextNotBackedOff(t *testing.T) {
+	calls := 0
+	memo := decorator.NewLoaderFailureMemo[string](decorator.LoaderFailureMemoConfig{
+		InitialBackoff: time.Hour,
+	})
+	fn := memo.Wrap(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+		calls++
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		return "v", nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := fn(ctx, "k")
+	require.ErrorIs(t, err, context.Canceled)
+
+	// 回源函数自身返回的超时同样不计入退避
+	_, err = memo.Wrap(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+		return "", fmt.Errorf("query: %w", context.DeadlineExceeded)
+	})(context.Background(), "k")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// 其他调用方不受影响
+	v, err := fn(context.Background(), "k")
+	require.NoError(t, err)
+	require.Equal(t, "v", v)
+	require.Equal(t, 2, calls)
+}
+
+func TestLoaderFailureMemo_MaxStaleSinceExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	fail := false
+	loadErr := errors.New("db down")
+
+	memo := decorator.NewLoaderFailureMemo[string](decorator.LoaderFailureMemoConfig{
+		InitialBackoff: time.Millisecond,
+		MaxStale:       5 * time.Minute,
+		Now:            func() time.Time { return now },
+	})
+	fn := memo.Wrap(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+		if fail {
+			return "", loadErr
+		}
+		return "good", nil
+	})
+
+	_, err := fn(ctx, "k")
+	require.NoError(t, err)
+
+	// 回写 TTL 为 1h，缓存中的值过期后第一次回源失败时，旧值依旧可用
+	now = now.Add(2 * time.Hour)
+	fail = true
+	_, err = fn(ctx, "k")
+	require.ErrorIs(t, err, loadErr)
+	v, ok := memo.Stale("k")
+	require.True(t, ok)
+	require.Equal(t, "good", v)
+
+	now = now.Add(4 * time.Minute)
+	_, err = fn(ctx, "k")
+	require.ErrorIs(t, err, loadErr)
+	_, ok = memo.Stale("k")
+	require.True(t, ok)
+
+	// 从第一次回源算起超过 MaxStale 后不再使用
+	now = now.Add(2 * time.Minute)
+	_, ok = memo.Stale("k")
+	require.False(t, ok)
+}
+
+func TestMissedLoaderDecorator_StaleIfError(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	mockCache := mocks.NewMockCache[string](ctrl)
+
+	memo := decorator.NewLoaderFailureMemo[string](decorator.LoaderFailureMemoConfig{
+		InitialBackoff: time.Hour,
+		MaxStale:       time.Hour,
+	})
+	fail := false
+	loadErr := errors.New("db down")
+	metrics := &loaderFailureMetrics{Metrics: telemetry.NoopMetrics()}
+
+	d := decorator.NewMissedLoaderDecorator(decorator.MissedLoaderDecoratorConfig[string]{
+		Cache: mockCache,
+		LoadFn: memo.Wrap(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			if fail {
+				return "", loadErr
+			}
+			return "good", nil
+		}),
+		WriteBackTTL: time.Minute,
+		FailureMemo:  memo,
+		Observer:     &telemetry.Observable{Metrics: metrics, Logger: telemetry.SlogLogger()},
+	})
+
+	mockCache.EXPECT().Get(gomock.Any(), "k").Return("", cache.ErrNotFound).Times(3)
+	mockCache.EXPECT().Set(gomock.Any(), "k", "good", time.Minute).Return(nil).Times(1)
+
+	v, err := d.Get(ctx, "k")
+	require.NoError(t, err)
+	require.Equal(t, "good", v)
+
+	// 回源失败，返回旧值，且不会写回
+	fail = true
+	v, err = d.Get(ctx, "k")
+	require.NoError(t, err)
+	require.Equal(t, "good", v)
+
+	// 退避窗口内同样返回旧值
+	v, err = d.Get(ctx, "k")
+	require.NoError(t, err)
+	require.Equal(t, "good", v)
+
+	require.Equal(t, 2, metrics.stale)
+	require.Equal(t, 1, metrics.backoff)
+
+	// 没有旧值时返回原始错误
+	mockCache.EXPECT().Get(gomock.Any(), "none").Return("", cache.ErrNotFound)
+	_, err = d.Get(ctx, "none")
+	require.ErrorIs(t, err, loadErr)
+}
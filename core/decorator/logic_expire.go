@@ -33,9 +33,7 @@ func NewLogicTTLDecorator[T any](config LogicTTLDecoratorConfig[T]) (*LogicTTLDe
 		loadFn:          config.LoadFn,
 		writeBackTTL:    config.WriteBackTTL,
 	}
-	if ttlMetrics, ok := config.Observer.Metrics.(LogicTTLMetrics); ok {
-		l.logicExpireMetrics = ttlMetrics.RecordLogicExpire
-	}
+	l.logicExpireMetrics = telemetry.MetricsAs[LogicTTLMetrics](config.Observer.Metrics)
 
 	return l, nil
 }
@@ -43,7 +41,7 @@ func NewLogicTTLDecorator[T any](config LogicTTLDecoratorConfig[T]) (*LogicTTLDe
 type LogicTTLDecorator[T any] struct {
 	cache              cache.Cache[LogicTTLValue[T]]
 	ob                 *telemetry.Observable
-	logicExpireMetrics func(ctx context.Context)
+	logicExpireMetrics []LogicTTLMetrics
 
 	defaultLogicTTL time.Duration
 	loadFn          LoaderFn[T]
@@ -65,8 +63,8 @@ func (d *LogicTTLDecorator[T]) Get(ctx context.Context, key string, opts ...cach
 }
 
 func (d *LogicTTLDecorator[T]) onExpire(ctx context.Context, key string, opts ...cache.CallOption) {
-	if d.logicExpireMetrics != nil {
-		d.logicExpireMetrics(ctx)
+	for _, m := range d.logicExpireMetrics {
+		m.RecordLogicExpire(ctx)
 	}
 
 	if d.loadFn == nil {
@@ -3,6 +3,7 @@ package decorator
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/yikakia/cachalot/core/cache"
@@ -15,23 +16,43 @@ type MissedLoaderDecoratorConfig[T any] struct {
 	Cache        cache.Cache[T]
 	LoadFn       LoaderFn[T]
 	WriteBackTTL time.Duration
-	Observer     *telemetry.Observable
+	// 大于 0 时开启负缓存：回源返回 cache.ErrNotFound 时写入墓碑，墓碑的物理过期时间为 NegativeTTL
+	// 需要底层 Store 使用 NewNegativeCacheStore 包裹
+	NegativeTTL time.Duration
+	// 非空时，回源失败会返回影子槽中的旧值（stale-if-error），且不会写回缓存
+	// LoadFn 需要使用 FailureMemo.Wrap 包裹，才能记录成功的回源值与失败退避
+	FailureMemo *LoaderFailureMemo[T]
+	// 为 true 时，读到损坏的值（cache.ErrCorrupted）按未命中回源，并以回源结果覆盖损坏的值
+	CorruptionAsMiss bool
+	Observer         *telemetry.Observable
 }
 
 func NewMissedLoaderDecorator[T any](config MissedLoaderDecoratorConfig[T]) *MissedLoaderDecorator[T] {
-	return &MissedLoaderDecorator[T]{
+	d := &MissedLoaderDecorator[T]{
 		cache:        config.Cache,
 		loadFn:       config.LoadFn,
 		writeBackTTL: config.WriteBackTTL,
+		negativeTTL:  config.NegativeTTL,
+		failureMemo:  config.FailureMemo,
+		corruption:   config.CorruptionAsMiss,
 		ob:           config.Observer,
 	}
+	if config.Observer != nil {
+		d.failureMetrics = telemetry.MetricsAs[LoaderFailureMetrics](config.Observer.Metrics)
+	}
+	return d
 }
 
 type MissedLoaderDecorator[T any] struct {
 	cache        cache.Cache[T]
 	loadFn       LoaderFn[T]
 	writeBackTTL time.Duration
+	negativeTTL  time.Duration
+	failureMemo  *LoaderFailureMemo[T]
+	corruption   bool
 	ob           *telemetry.Observable
+
+	failureMetrics []LoaderFailureMetrics
 }
 
 func (d *MissedLoaderDecorator[T]) Get(ctx context.Context, key string, opts ...cache.CallOption) (T, error) {
@@ -40,24 +61,43 @@ func (d *MissedLoaderDecorator[T]) Get(ctx context.Context, key string, opts ...
 		return val, nil
 	}
 
-	if errors.Is(err, cache.ErrNotFound) && d.loadFn != nil {
-		return d.loadFromSource(ctx, key, opts...)
+	if d.shouldLoad(ctx, key, err) {
+		return d.loadFromSource(ctx, key, canWriteBack(err), opts...)
 	}
 
 	return val, err
 }
 
-func (d *MissedLoaderDecorator[T]) loadFromSource(ctx context.Context, key string, opts ...cache.CallOption) (T, error) {
+// writeBack 为 false 时只返回回源结果，不写回值或墓碑
+func (d *MissedLoaderDecorator[T]) loadFromSource(ctx context.Context, key string, writeBack bool, opts ...cache.CallOption) (T, error) {
 	var zero T
+	telemetry.AddCustomFields(ctx, map[string]string{"source": "loader"})
+	telemetry.UpdateDetails(ctx, func(d *telemetry.Details) {
+		d.LoaderRan = true
+	})
 	val, err := d.loadFn(ctx, key, opts...)
 	if err != nil {
+		if writeBack && d.negativeTTL > 0 && errors.Is(err, cache.ErrNotFound) {
+			return zero, d.storeTombstone(ctx, key, err, opts...)
+		}
 		// load failed
+		if stale, ok := d.serveStale(ctx, key, err); ok {
+			return stale, nil
+		}
 		return zero, err
 	}
 
+	if !writeBack {
+		telemetry.AddCustomFields(ctx, map[string]string{"write_back": "skipped"})
+		return val, nil
+	}
+
 	// write back
-	err = d.Set(ctx, key, val, d.writeBackTTL, opts...)
+	wctx, end := telemetry.StartWriteBack(ctx)
+	err = d.Set(wctx, key, val, d.writeBackTTL, opts...)
+	end(err)
 	if err != nil {
+		telemetry.AddCustomFields(ctx, map[string]string{"write_back": "fail"})
 		if d.ob != nil && d.ob.Logger != nil {
 			d.ob.Logger.ErrorContext(ctx, "[MissedLoaderDecorator] write back failed.", "key", key, "err", err)
 		}
@@ -66,14 +106,78 @@ func (d *MissedLoaderDecorator[T]) loadFromSource(ctx context.Context, key strin
 	return val, nil
 }
 
+// 回源失败时尝试从影子槽兜底，旧值不会写回缓存
+func (d *MissedLoaderDecorator[T]) serveStale(ctx context.Context, key string, loadErr error) (T, bool) {
+	if errors.Is(loadErr, ErrLoaderBackoff) {
+		telemetry.AddCustomFields(ctx, map[string]string{"loader_backoff": "true"})
+		for _, m := range d.failureMetrics {
+			m.RecordLoaderBackoff(ctx)
+		}
+	}
+	var zero T
+	if d.failureMemo == nil {
+		return zero, false
+	}
+	stale, ok := d.failureMemo.Stale(key)
+	if !ok {
+		return zero, false
+	}
+	telemetry.AddCustomFields(ctx, map[string]string{"stale": "true"})
+	for _, m := range d.failureMetrics {
+		m.RecordStaleServed(ctx)
+	}
+	if d.ob != nil && d.ob.Logger != nil {
+		d.ob.Logger.WarnContext(ctx, "[MissedLoaderDecorator] load failed, serve stale value.", "key", key, "err", loadErr)
+	}
+	return stale, true
+}
+
+// 墓碑命中时说明源数据已确认不存在，不再回源
+func (d *MissedLoaderDecorator[T]) shouldLoad(ctx context.Context, key string, err error) bool {
+	if d.loadFn == nil {
+		return false
+	}
+	if d.corruption && errors.Is(err, cache.ErrCorrupted) {
+		telemetry.AddCustomFields(ctx, map[string]string{"corrupted": "reloaded"})
+		if d.ob != nil && d.ob.Logger != nil {
+			d.ob.Logger.WarnContext(ctx, "[MissedLoaderDecorator] corrupted value, reload from source.", "key", key, "err", err)
+		}
+		return true
+	}
+	return errors.Is(err, cache.ErrNotFound) &&
+		!errors.Is(err, cache.ErrNegativeCached)
+}
+
+// 存储中的值由更高 schema 版本写入时不写回，避免新旧实例互相覆盖
+func canWriteBack(getErr error) bool {
+	return !errors.Is(getErr, cache.ErrNewerSchema)
+}
+
+// 回源确认不存在，写入墓碑
+func (d *MissedLoaderDecorator[T]) storeTombstone(ctx context.Context, key string, loadErr error, opts ...cache.CallOption) error {
+	var zero T
+	wctx, end := telemetry.StartWriteBack(contextWithTombstone(ctx))
+	err := d.Set(wctx, key, zero, d.negativeTTL, opts...)
+	end(err)
+	if err != nil {
+		if d.ob != nil && d.ob.Logger != nil {
+			d.ob.Logger.ErrorContext(ctx, "[MissedLoaderDecorator] write tombstone failed.", "key", key, "err", err)
+		}
+		// 墓碑没有写入，下一次请求仍会回源，不能报告为已负缓存
+		return fmt.Errorf("key:%s not found in source: %w", key, loadErr)
+	}
+	telemetry.AddCustomFields(ctx, map[string]string{"negative_cache": "stored"})
+	return fmt.Errorf("key:%s not found in source: %w. %w", key, loadErr, cache.ErrNegativeCached)
+}
+
 func (d *MissedLoaderDecorator[T]) GetWithTTL(ctx context.Context, key string, opts ...cache.CallOption) (T, time.Duration, error) {
 	val, ttl, err := d.cache.GetWithTTL(ctx, key, opts...)
 	if err == nil {
 		return val, ttl, nil
 	}
 
-	if errors.Is(err, cache.ErrNotFound) && d.loadFn != nil {
-		val, err := d.loadFromSource(ctx, key, opts...)
+	if d.shouldLoad(ctx, key, err) {
+		val, err := d.loadFromSource(ctx, key, canWriteBack(err), opts...)
 		if err != nil {
 			var zero T
 			return zero, 0, err
@@ -3,12 +3,14 @@ package decorator_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/yikakia/cachalot/core/cache"
 	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/telemetry"
 	"github.com/yikakia/cachalot/internal/mocks"
 	"go.uber.org/mock/gomock"
 )
@@ -90,4 +92,37 @@ func TestMissedLoaderDecorator_Get(t *testing.T) {
 		assert.ErrorIs(t, err, cache.ErrNotFound)
 		assert.Empty(t, res)
 	})
+
+	t.Run("corrupted value", func(t *testing.T) {
+		corrupted := fmt.Errorf("key:%s checksum mismatch: %w", key, cache.ErrCorrupted)
+		loader := func(ctx context.Context, k string, _ ...cache.CallOption) (string, error) {
+			return val, nil
+		}
+
+		// 默认不回源，直接返回错误
+		ctrl := gomock.NewController(t)
+		mockCache := mocks.NewMockCache[string](ctrl)
+		mockCache.EXPECT().Get(gomock.Any(), gomock.Eq(key)).Return("", corrupted)
+		d := decorator.NewMissedLoaderDecorator(decorator.MissedLoaderDecoratorConfig[string]{
+			Cache:  mockCache,
+			LoadFn: loader,
+		})
+		_, err := d.Get(ctx, key)
+		assert.ErrorIs(t, err, cache.ErrCorrupted)
+
+		// 开启后按未命中回源并覆盖
+		mockCache.EXPECT().Get(gomock.Any(), gomock.Eq(key)).Return("", corrupted)
+		mockCache.EXPECT().Set(gomock.Any(), gomock.Eq(key), gomock.Eq(val), gomock.Eq(ttl)).Return(nil)
+		d = decorator.NewMissedLoaderDecorator(decorator.MissedLoaderDecoratorConfig[string]{
+			Cache:            mockCache,
+			LoadFn:           loader,
+			WriteBackTTL:     ttl,
+			CorruptionAsMiss: true,
+		})
+		evt := &telemetry.Event{}
+		res, err := d.Get(telemetry.ContextWithEvent(ctx, evt), key)
+		assert.NoError(t, err)
+		assert.Equal(t, val, res)
+		assert.Equal(t, "reloaded", evt.FrozenCustomFields()["corrupted"])
+	})
 }
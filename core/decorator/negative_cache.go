@@ -0,0 +1,103 @@
+package decorator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/telemetry"
+)
+
+// negativeTombstoneBytes []byte 值的墓碑
+//
+// 普通值原样存储，不加任何前缀，开启负缓存前写入的值仍然可读。
+// 首字节 0xC1 在 MessagePack 中未使用，也不是合法的 UTF-8 起始字节，不会与常见编码的结果冲突；
+// 第二个字节 0xD0 与信封的 magic（0xC1 0xCA）不同，开启 WithEnvelope 时也不会冲突。
+//
+// 墓碑不经过信封：它位于校验和、加密等 byte-stage 之下，由 NegativeCacheStore 直接写入存储，
+// 因此不受校验和与加密保护。墓碑不携带数据，能伪造墓碑的一方本就可以删除或覆盖该 key，不会泄露或篡改值。
+// 唯一的冲突来源是不经过 codec 的 []byte 缓存：业务值恰好等于该字节序列时会被当作墓碑读出
+var negativeTombstoneBytes = []byte("\xc1\xd0cachalot:negative-tombstone")
+
+var _ cache.Store = (*NegativeCacheStore)(nil)
+
+// negativeTombstone 对象存储中的墓碑值
+type negativeTombstone struct{}
+
+type tombstoneCtxKey struct{}
+
+// contextWithTombstone 标记本次 Set 写入墓碑而不是 val
+//
+// 通过 context 传递，避免 NegativeCacheStore 在每次 Set 时解析 CallOption
+func contextWithTombstone(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tombstoneCtxKey{}, true)
+}
+
+func isTombstoneSet(ctx context.Context) bool {
+	b, _ := ctx.Value(tombstoneCtxKey{}).(bool)
+	return b
+}
+
+// NewNegativeCacheStore 包裹 Store，使其可以存储“已确认不存在”的墓碑
+//
+// 普通值原样存储；[]byte 的墓碑为固定的字节序列，因此可以透明地位于 codec、压缩等 byte-stage 之下，
+// 对于其他对象值，墓碑以内部哨兵对象存储
+// 读到墓碑时返回 cache.ErrNegativeCached
+func NewNegativeCacheStore(store cache.Store) *NegativeCacheStore {
+	return &NegativeCacheStore{Store: store}
+}
+
+type NegativeCacheStore struct {
+	cache.Store
+}
+
+func (s *NegativeCacheStore) Get(ctx context.Context, key string, opts ...cache.CallOption) (any, error) {
+	val, err := s.Store.Get(ctx, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return s.decode(ctx, key, val)
+}
+
+func (s *NegativeCacheStore) GetWithTTL(ctx context.Context, key string, opts ...cache.CallOption) (any, time.Duration, error) {
+	val, ttl, err := s.Store.GetWithTTL(ctx, key, opts...)
+	if err != nil {
+		return nil, 0, err
+	}
+	decoded, err := s.decode(ctx, key, val)
+	if err != nil {
+		return nil, 0, err
+	}
+	return decoded, ttl, nil
+}
+
+func (s *NegativeCacheStore) Set(ctx context.Context, key string, val any, ttl time.Duration, opts ...cache.CallOption) error {
+	if !isTombstoneSet(ctx) {
+		return s.Store.Set(ctx, key, val, ttl, opts...)
+	}
+	if _, ok := val.([]byte); ok {
+		return s.Store.Set(ctx, key, bytes.Clone(negativeTombstoneBytes), ttl, opts...)
+	}
+	return s.Store.Set(ctx, key, negativeTombstone{}, ttl, opts...)
+}
+
+func (s *NegativeCacheStore) decode(ctx context.Context, key string, val any) (any, error) {
+	switch v := val.(type) {
+	case negativeTombstone:
+		return nil, s.negativeHit(ctx, key)
+	case []byte:
+		if bytes.Equal(v, negativeTombstoneBytes) {
+			return nil, s.negativeHit(ctx, key)
+		}
+		return v, nil
+	default:
+		return val, nil
+	}
+}
+
+func (s *NegativeCacheStore) negativeHit(ctx context.Context, key string) error {
+	telemetry.AddCustomFields(ctx, map[string]string{"negative_cache": "hit"})
+	return fmt.Errorf("key:%s in store:%s. %w", key, s.StoreName(), cache.ErrNegativeCached)
+}
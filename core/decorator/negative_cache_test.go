@@ -0,0 +1,158 @@
+package decorator_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/codec"
+	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/internal/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMissedLoaderDecorator_NegativeCache(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	store := mocks.NewMockStore(ctrl)
+
+	var written any
+	store.EXPECT().Set(gomock.Any(), "k", gomock.Any(), 10*time.Second, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, val any, _ time.Duration, _ ...cache.CallOption) error {
+			written = val
+			return nil
+		})
+	store.EXPECT().Get(gomock.Any(), "k").Return(nil, cache.ErrNotFound)
+
+	loads := 0
+	d := decorator.NewMissedLoaderDecorator(decorator.MissedLoaderDecoratorConfig[[]byte]{
+		Cache: cache.NewBaseCache[[]byte](decorator.NewNegativeCacheStore(store)),
+		LoadFn: func(ctx context.Context, key string, opts ...cache.CallOption) ([]byte, error) {
+			loads++
+			return nil, cache.ErrNotFound
+		},
+		WriteBackTTL: time.Minute,
+		NegativeTTL:  10 * time.Second,
+	})
+
+	_, err := d.Get(ctx, "k")
+	require.ErrorIs(t, err, cache.ErrNegativeCached)
+	require.IsType(t, []byte{}, written)
+
+	// 墓碑命中不再回源
+	store.EXPECT().StoreName().Return("mock-store")
+	store.EXPECT().Get(gomock.Any(), "k").Return(written, nil)
+	_, err = d.Get(ctx, "k")
+	require.ErrorIs(t, err, cache.ErrNegativeCached)
+	require.Equal(t, 1, loads)
+
+	// 墓碑过期后再次回源
+	store.EXPECT().Get(gomock.Any(), "k").Return(nil, cache.ErrNotFound)
+	store.EXPECT().Set(gomock.Any(), "k", gomock.Any(), 10*time.Second, gomock.Any()).Return(nil)
+	_, err = d.Get(ctx, "k")
+	require.ErrorIs(t, err, cache.ErrNegativeCached)
+	require.Equal(t, 2, loads)
+}
+
+func TestMissedLoaderDecorator_NegativeCacheWriteFailed(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	store := mocks.NewMockStore(ctrl)
+
+	store.EXPECT().Get(gomock.Any(), "k").Return(nil, cache.ErrNotFound)
+	store.EXPECT().Set(gomock.Any(), "k", gomock.Any(), 10*time.Second, gomock.Any()).Return(errors.New("store down"))
+
+	d := decorator.NewMissedLoaderDecorator(decorator.MissedLoaderDecoratorConfig[[]byte]{
+		Cache: cache.NewBaseCache[[]byte](decorator.NewNegativeCacheStore(store)),
+		LoadFn: func(ctx context.Context, key string, opts ...cache.CallOption) ([]byte, error) {
+			return nil, cache.ErrNotFound
+		},
+		WriteBackTTL: time.Minute,
+		NegativeTTL:  10 * time.Second,
+	})
+
+	// 墓碑写入失败时返回回源的未命中，而不是已负缓存
+	_, err := d.Get(ctx, "k")
+	require.ErrorIs(t, err, cache.ErrNotFound)
+	require.NotErrorIs(t, err, cache.ErrNegativeCached)
+}
+
+func TestNegativeCacheStore_Values(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	store := mocks.NewMockStore(ctrl)
+	s := decorator.NewNegativeCacheStore(store)
+
+	// 普通值原样存储
+	store.EXPECT().Set(gomock.Any(), "bytes", []byte("v"), time.Minute).Return(nil)
+	require.NoError(t, s.Set(ctx, "bytes", []byte("v"), time.Minute))
+	store.EXPECT().Get(gomock.Any(), "bytes").Return([]byte("v"), nil)
+	got, err := s.Get(ctx, "bytes")
+	require.NoError(t, err)
+	require.Equal(t, []byte("v"), got)
+
+	store.EXPECT().Set(gomock.Any(), "obj", "v", time.Minute).Return(nil)
+	require.NoError(t, s.Set(ctx, "obj", "v", time.Minute))
+	store.EXPECT().Get(gomock.Any(), "obj").Return("v", nil)
+	got, err = s.Get(ctx, "obj")
+	require.NoError(t, err)
+	require.Equal(t, "v", got)
+
+	store.EXPECT().Get(gomock.Any(), "empty").Return([]byte{}, nil)
+	got, err = s.Get(ctx, "empty")
+	require.NoError(t, err)
+	require.Equal(t, []byte{}, got)
+}
+
+func TestNegativeCacheStore_Migration(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	stored := map[string]any{}
+	store := mocks.NewMockStore(ctrl)
+	store.EXPECT().StoreName().Return("mock-store").AnyTimes()
+	store.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, key string, val any, _ time.Duration, _ ...cache.CallOption) error {
+			stored[key] = val
+			return nil
+		}).AnyTimes()
+	store.EXPECT().Get(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, key string, _ ...cache.CallOption) (any, error) {
+			if v, ok := stored[key]; ok {
+				return v, nil
+			}
+			return nil, cache.ErrNotFound
+		}).AnyTimes()
+
+	// 未开启负缓存时写入的值，首字节与旧格式的标记相同也能原样读出
+	before := &decorator.CodecDecorator[string]{Cache: cache.NewBaseCache[[]byte](store), Codec: codec.JSONCodec{}}
+	require.NoError(t, before.Set(ctx, "json", "orca", time.Minute))
+	stored["flag"] = []byte{0x01}
+
+	after := &decorator.CodecDecorator[string]{
+		Cache: decorator.NewMissedLoaderDecorator(decorator.MissedLoaderDecoratorConfig[[]byte]{
+			Cache: cache.NewBaseCache[[]byte](decorator.NewNegativeCacheStore(store)),
+			LoadFn: func(ctx context.Context, key string, opts ...cache.CallOption) ([]byte, error) {
+				return nil, cache.ErrNotFound
+			},
+			WriteBackTTL: time.Minute,
+			NegativeTTL:  time.Minute,
+		}),
+		Codec: codec.JSONCodec{},
+	}
+	got, err := after.Get(ctx, "json")
+	require.NoError(t, err)
+	require.Equal(t, "orca", got)
+	raw, err := decorator.NewNegativeCacheStore(store).Get(ctx, "flag")
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x01}, raw)
+
+	// 墓碑对未开启负缓存的实例表现为无法解码的值，而不是伪造的零值
+	_, err = after.Get(ctx, "missing")
+	require.ErrorIs(t, err, cache.ErrNegativeCached)
+	_, err = before.Get(ctx, "missing")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, cache.ErrNotFound)
+}
@@ -44,13 +44,20 @@ func (d *NilCacheDecorator[T]) Get(ctx context.Context, key string, opts ...cach
 		return val, nil
 	}
 
-	if errors.Is(err, cache.ErrNotFound) && d.protectionFn != nil {
+	if d.shouldProtect(err) {
 		return d.protectFromPenetration(ctx, key, opts...)
 	}
 
 	return val, err
 }
 
+// 墓碑命中说明已由负缓存兜底，不再写入防护值
+func (d *NilCacheDecorator[T]) shouldProtect(err error) bool {
+	return d.protectionFn != nil &&
+		errors.Is(err, cache.ErrNotFound) &&
+		!errors.Is(err, cache.ErrNegativeCached)
+}
+
 func (d *NilCacheDecorator[T]) protectFromPenetration(ctx context.Context, key string, opts ...cache.CallOption) (T, error) {
 	// 调用防护函数获取防护值
 	val := d.protectionFn(key)
@@ -72,7 +79,7 @@ func (d *NilCacheDecorator[T]) GetWithTTL(ctx context.Context, key string, opts
 		return val, ttl, nil
 	}
 
-	if errors.Is(err, cache.ErrNotFound) && d.protectionFn != nil {
+	if d.shouldProtect(err) {
 		val, _ := d.protectFromPenetration(ctx, key, opts...)
 
 		return val, d.writeBackTTL, nil
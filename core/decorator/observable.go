@@ -30,18 +30,28 @@ type ObservableDecorator[T any] struct {
 	cache     cache.Cache[T]
 }
 
-func (o *ObservableDecorator[T]) initCtx(ctx context.Context, op telemetry.Op) (context.Context, *telemetry.Event) {
+func (o *ObservableDecorator[T]) initCtx(ctx context.Context, op telemetry.Op, key string) (context.Context, *telemetry.Event, telemetry.EventListener) {
 	evt := &telemetry.Event{
 		Op:        op,
 		CacheName: o.cacheName,
 		StoreName: o.storeName,
 	}
-	return telemetry.ContextWithEvent(ctx, evt), evt
+	if key != "" {
+		telemetry.RecordKey(evt, o.ob.KeyRedactor, key)
+	}
+	ctx, listener := telemetry.TakeEventListener(ctx)
+	return telemetry.ContextWithEvent(ctx, evt), evt, listener
+}
+
+func (o *ObservableDecorator[T]) notify(ctx context.Context, evt *telemetry.Event, listener telemetry.EventListener) {
+	if listener != nil {
+		listener(ctx, evt)
+	}
 }
 
 func (o *ObservableDecorator[T]) Get(ctx context.Context, key string, opts ...cache.CallOption) (_ T, finalErr error) {
 	start := time.Now()
-	ctx, evt := o.initCtx(ctx, telemetry.OpGet)
+	ctx, evt, listener := o.initCtx(ctx, telemetry.OpGet, key)
 	defer func() {
 		evt.Error = finalErr
 		evt.Latency = time.Since(start)
@@ -51,6 +61,7 @@ func (o *ObservableDecorator[T]) Get(ctx context.Context, key string, opts ...ca
 		if err != nil {
 			o.ob.Logger.ErrorContext(ctx, "[ObservableDecorator.Get] Record Metrics Failed.", "err", err.Error())
 		}
+		o.notify(ctx, evt, listener)
 	}()
 
 	var zero T
@@ -63,7 +74,7 @@ func (o *ObservableDecorator[T]) Get(ctx context.Context, key string, opts ...ca
 
 func (o *ObservableDecorator[T]) Set(ctx context.Context, key string, val T, ttl time.Duration, opts ...cache.CallOption) (finalErr error) {
 	start := time.Now()
-	ctx, evt := o.initCtx(ctx, telemetry.OpSet)
+	ctx, evt, listener := o.initCtx(ctx, telemetry.OpSet, key)
 	defer func() {
 		evt.Error = finalErr
 		evt.Latency = time.Since(start)
@@ -72,6 +83,7 @@ func (o *ObservableDecorator[T]) Set(ctx context.Context, key string, val T, ttl
 		if err != nil {
 			o.ob.Logger.ErrorContext(ctx, "[ObservableDecorator.Set] Record Metrics Failed.", "err", err.Error())
 		}
+		o.notify(ctx, evt, listener)
 	}()
 
 	err := o.cache.Set(ctx, key, val, ttl, opts...)
@@ -83,7 +95,7 @@ func (o *ObservableDecorator[T]) Set(ctx context.Context, key string, val T, ttl
 
 func (o *ObservableDecorator[T]) GetWithTTL(ctx context.Context, key string, opts ...cache.CallOption) (_ T, _ time.Duration, finalErr error) {
 	start := time.Now()
-	ctx, evt := o.initCtx(ctx, telemetry.OpGetWithTTL)
+	ctx, evt, listener := o.initCtx(ctx, telemetry.OpGetWithTTL, key)
 	defer func() {
 		evt.Error = finalErr
 		evt.Latency = time.Since(start)
@@ -93,6 +105,7 @@ func (o *ObservableDecorator[T]) GetWithTTL(ctx context.Context, key string, opt
 		if err != nil {
 			o.ob.Logger.ErrorContext(ctx, "[ObservableDecorator.GetWithTTL] Record Metrics Failed.", "err", err.Error())
 		}
+		o.notify(ctx, evt, listener)
 	}()
 
 	var zero T
@@ -105,7 +118,7 @@ func (o *ObservableDecorator[T]) GetWithTTL(ctx context.Context, key string, opt
 
 func (o *ObservableDecorator[T]) Delete(ctx context.Context, key string, opts ...cache.CallOption) (finalErr error) {
 	start := time.Now()
-	ctx, evt := o.initCtx(ctx, telemetry.OpDelete)
+	ctx, evt, listener := o.initCtx(ctx, telemetry.OpDelete, key)
 	defer func() {
 		evt.Error = finalErr
 		evt.Latency = time.Since(start)
@@ -114,6 +127,7 @@ func (o *ObservableDecorator[T]) Delete(ctx context.Context, key string, opts ..
 		if err != nil {
 			o.ob.Logger.ErrorContext(ctx, "[ObservableDecorator.Delete] Record Metrics Failed.", "err", err.Error())
 		}
+		o.notify(ctx, evt, listener)
 	}()
 
 	return o.cache.Delete(ctx, key, opts...)
@@ -121,7 +135,7 @@ func (o *ObservableDecorator[T]) Delete(ctx context.Context, key string, opts ..
 
 func (o *ObservableDecorator[T]) Clear(ctx context.Context) (finalErr error) {
 	start := time.Now()
-	ctx, evt := o.initCtx(ctx, telemetry.OpClear)
+	ctx, evt, listener := o.initCtx(ctx, telemetry.OpClear, "")
 	defer func() {
 		evt.Error = finalErr
 		evt.Latency = time.Since(start)
@@ -130,6 +144,7 @@ func (o *ObservableDecorator[T]) Clear(ctx context.Context) (finalErr error) {
 		if err != nil {
 			o.ob.Logger.ErrorContext(ctx, "[ObservableDecorator.Clear] Record Metrics Failed.", "err", err.Error())
 		}
+		o.notify(ctx, evt, listener)
 	}()
 
 	return o.cache.Clear(ctx)
@@ -0,0 +1,60 @@
+package decorator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/codec"
+	"github.com/yikakia/cachalot/core/telemetry"
+)
+
+// SchemaMetrics 带版本的 codec 读到旧版本值时的处理结果
+type SchemaMetrics interface {
+	// RecordSchemaUpgraded 旧版本的值通过升级函数升级到当前版本
+	RecordSchemaUpgraded(ctx context.Context, from, to uint32)
+	// RecordSchemaDiscarded 值的版本无法升级或高于当前版本，按未命中处理
+	RecordSchemaDiscarded(ctx context.Context, version, current uint32)
+}
+
+// UnmarshalValue 使用 c 解码 data
+//
+// c 实现 codec.SchemaVersioned 时：旧版本升级成功记录 schema=upgraded，
+// 无法升级时记录 schema=discarded 并返回包裹 cache.ErrNotFound 的错误，使外层回源重新加载；
+// 版本高于当前版本时记录 schema=newer 并返回包裹 cache.ErrNewerSchema 的错误，外层回源但不写回
+func UnmarshalValue(ctx context.Context, ob *telemetry.Observable, c codec.Codec, data []byte, v any) error {
+	vc, ok := c.(codec.SchemaVersioned)
+	if !ok {
+		return c.Unmarshal(data, v)
+	}
+
+	current := vc.SchemaVersion()
+	version, err := vc.UnmarshalVersion(data, v)
+	var metrics []SchemaMetrics
+	if ob != nil {
+		metrics = telemetry.MetricsAs[SchemaMetrics](ob.Metrics)
+	}
+	switch {
+	case errors.Is(err, codec.ErrSchemaNewer):
+		telemetry.AddCustomFields(ctx, map[string]string{"schema": "newer"})
+		for _, m := range metrics {
+			m.RecordSchemaDiscarded(ctx, version, current)
+		}
+		return fmt.Errorf("%w: %w", cache.ErrNewerSchema, err)
+	case errors.Is(err, codec.ErrSchemaMismatch):
+		telemetry.AddCustomFields(ctx, map[string]string{"schema": "discarded"})
+		for _, m := range metrics {
+			m.RecordSchemaDiscarded(ctx, version, current)
+		}
+		return fmt.Errorf("%w: %w", cache.ErrNotFound, err)
+	case err != nil:
+		return err
+	case version != current:
+		telemetry.AddCustomFields(ctx, map[string]string{"schema": "upgraded"})
+		for _, m := range metrics {
+			m.RecordSchemaUpgraded(ctx, version, current)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package decorator
+
+import (
+	"cmp"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/telemetry"
+)
+
+// OversizePolicy 值超过 SizeGuardConfig.MaxSize 时的处理方式
+type OversizePolicy string
+
+const (
+	// OversizeReject 返回包裹 cache.ErrValueTooLarge 的错误
+	OversizeReject OversizePolicy = "rejected"
+	// OversizeSkip 不写入缓存并删除旧值，Set 返回 nil
+	OversizeSkip OversizePolicy = "skipped"
+	// OversizeChunk 拆分为 key:0..N 多个分块，原 key 写入清单，读取时重新拼接
+	OversizeChunk OversizePolicy = "chunked"
+)
+
+// SizeGuardMetrics 写入超限值时上报
+type SizeGuardMetrics interface {
+	// RecordOversize size 为超限值的长度
+	RecordOversize(ctx context.Context, policy OversizePolicy, size int)
+}
+
+// SizeGuardConfig 写入长度限制配置
+type SizeGuardConfig struct {
+	// 写入 Store 的最大字节数，必须 > 0
+	MaxSize int
+	// 为空时使用 OversizeReject
+	Policy OversizePolicy
+	// OversizeChunk 时每个分块的最大字节数，为 0 时使用 MaxSize
+	ChunkSize int
+	// OversizeChunk 时最多拆分的块数，超过时按 OversizeReject 处理，为 0 时不限制
+	MaxChunks int
+}
+
+// Validate 校验配置
+func (c SizeGuardConfig) Validate() error {
+	var errs []error
+	if c.MaxSize <= 0 {
+		errs = append(errs, fmt.Errorf("size guard max size require > 0, but got: %d", c.MaxSize))
+	}
+	switch c.Policy {
+	case "", OversizeReject, OversizeSkip, OversizeChunk:
+	default:
+		errs = append(errs, fmt.Errorf("size guard unknown policy: %q", c.Policy))
+	}
+	if c.ChunkSize < 0 || (c.MaxSize > 0 && c.ChunkSize > c.MaxSize) {
+		errs = append(errs, fmt.Errorf("size guard chunk size require in [0, max size], but got: %d", c.ChunkSize))
+	}
+	if chunk := cmp.Or(c.ChunkSize, c.MaxSize); c.Policy == OversizeChunk && chunk <= generationSize {
+		errs = append(errs, fmt.Errorf("size guard chunk size require > %d, but got: %d", generationSize, chunk))
+	}
+	if c.MaxChunks < 0 {
+		errs = append(errs, fmt.Errorf("size guard max chunks require >= 0, but got: %d", c.MaxChunks))
+	}
+	return errors.Join(errs...)
+}
+
+// OversizeChunk 的存储格式：
+//
+//	原 key：| flag 0 | value |  或  | flag 1 | generation uint64 | uvarint 块数 | uvarint 总长度 |
+//	key:i ：| generation uint64 | data |
+//
+// 每次分块写入使用随机的 generation，读到与清单不一致的分块（并发写入或旧分块残留）时按未命中处理
+const (
+	sizeGuardFlagInline   byte = 0
+	sizeGuardFlagManifest byte = 1
+	generationSize             = 8
+	// 读取分块时最多按该数量的分块预分配
+	sizeGuardPreallocChunks = 64
+)
+
+var _ cache.Cache[[]byte] = (*SizeGuardDecorator)(nil)
+
+// SizeGuardDecorator 限制写入 Store 的值的长度，超限时按 OversizePolicy 处理
+//
+// 只有 OversizeChunk 会改变存储格式：每个值前附加 1 字节标记，切换策略时需要清空缓存或使用新的 key 前缀。
+// 超限时在事件中写入自定义字段 size_guard=rejected|skipped|chunked
+type SizeGuardDecorator struct {
+	cache.Cache[[]byte]
+	cfg     SizeGuardConfig
+	metrics []SizeGuardMetrics
+}
+
+// NewSizeGuardDecorator ob 可以为空，用于上报 SizeGuardMetrics
+func NewSizeGuardDecorator(next cache.Cache[[]byte], cfg SizeGuardConfig, ob *telemetry.Observable) (*SizeGuardDecorator, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Policy == "" {
+		cfg.Policy = OversizeReject
+	}
+	if cfg.ChunkSize == 0 {
+		cfg.ChunkSize = cfg.MaxSize
+	}
+	d := &SizeGuardDecorator{
+		Cache: next,
+		cfg:   cfg,
+	}
+	if ob != nil {
+		d.metrics = telemetry.MetricsAs[SizeGuardMetrics](ob.Metrics)
+	}
+	return d, nil
+}
+
+func (d *SizeGuardDecorator) Get(ctx context.Context, key string, opts ...cache.CallOption) ([]byte, error) {
+	raw, err := d.Cache.Get(ctx, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if d.cfg.Policy != OversizeChunk {
+		return raw, nil
+	}
+	return d.decode(ctx, key, raw, opts)
+}
+
+func (d *SizeGuardDecorator) GetWithTTL(ctx context.Context, key string, opts ...cache.CallOption) ([]byte, time.Duration, error) {
+	raw, ttl, err := d.Cache.GetWithTTL(ctx, key, opts...)
+	if err != nil {
+		return nil, 0, err
+	}
+	if d.cfg.Policy != OversizeChunk {
+		return raw, ttl, nil
+	}
+	val, err := d.decode(ctx, key, raw, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	return val, ttl, nil
+}
+
+func (d *SizeGuardDecorator) Set(ctx context.Context, key string, val []byte, ttl time.Duration, opts ...cache.CallOption) error {
+	if d.cfg.Policy != OversizeChunk {
+		if len(val) > d.cfg.MaxSize {
+			return d.oversize(ctx, key, d.cfg.Policy, len(val), opts)
+		}
+		return d.Cache.Set(ctx, key, val, ttl, opts...)
+	}
+
+	if 1+len(val) <= d.cfg.MaxSize {
+		inline := make([]byte, 0, 1+len(val))
+		inline = append(inline, sizeGuardFlagInline)
+		return d.Cache.Set(ctx, key, append(inline, val...), ttl, opts...)
+	}
+
+	dataSize := d.cfg.ChunkSize - generationSize
+	n := (len(val) + dataSize - 1) / dataSize
+	if d.cfg.MaxChunks > 0 && n > d.cfg.MaxChunks {
+		return d.oversize(ctx, key, OversizeReject, len(val), opts)
+	}
+	d.record(ctx, OversizeChunk, len(val))
+
+	gen := rand.Uint64()
+	for i := range n {
+		part := val[i*dataSize : min((i+1)*dataSize, len(val))]
+		chunk := make([]byte, 0, generationSize+len(part))
+		chunk = binary.BigEndian.AppendUint64(chunk, gen)
+		if err := d.Cache.Set(ctx, chunkKey(key, i), append(chunk, part...), ttl, opts...); err != nil {
+			return err
+		}
+	}
+	// 分块全部写入后再写清单，读取方不会看到不完整的值
+	manifest := make([]byte, 0, 1+generationSize+2*binary.MaxVarintLen64)
+	manifest = append(manifest, sizeGuardFlagManifest)
+	manifest = binary.BigEndian.AppendUint64(manifest, gen)
+	manifest = binary.AppendUvarint(manifest, uint64(n))
+	manifest = binary.AppendUvarint(manifest, uint64(len(val)))
+	return d.Cache.Set(ctx, key, manifest, ttl, opts...)
+}
+
+// Delete OversizeChunk 时同时删除清单指向的分块
+func (d *SizeGuardDecorator) Delete(ctx context.Context, key string, opts ...cache.CallOption) error {
+	if d.cfg.Policy == OversizeChunk {
+		if raw, err := d.Cache.Get(ctx, key, opts...); err == nil {
+			if _, n, _, err := d.parseManifest(raw); err == nil {
+				for i := range n {
+					if err := d.Cache.Delete(ctx, chunkKey(key, i), opts...); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return d.Cache.Delete(ctx, key, opts...)
+}
+
+// RetainsValue OversizeChunk 时写入的总是带标记的新字节
+func (d *SizeGuardDecorator) RetainsValue() bool {
+	return d.cfg.Policy != OversizeChunk && RetainsValue(d.Cache)
+}
+
+func (d *SizeGuardDecorator) oversize(ctx context.Context, key string, policy OversizePolicy, size int, opts []cache.CallOption) error {
+	d.record(ctx, policy, size)
+	if policy == OversizeSkip {
+		// 旧值已经与源数据不一致，删除后由下次读取回源
+		return d.Cache.Delete(ctx, key, opts...)
+	}
+	return fmt.Errorf("%w: key=%s size=%d max=%d", cache.ErrValueTooLarge, key, size, d.cfg.MaxSize)
+}
+
+func (d *SizeGuardDecorator) record(ctx context.Context, policy OversizePolicy, size int) {
+	telemetry.AddCustomFields(ctx, map[string]string{"size_guard": string(policy)})
+	for _, m := range d.metrics {
+		m.RecordOversize(ctx, policy, size)
+	}
+}
+
+func (d *SizeGuardDecorator) decode(ctx context.Context, key string, raw []byte, opts []cache.CallOption) ([]byte, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("%w: size guard missing flag byte", cache.ErrCorrupted)
+	}
+	if raw[0] == sizeGuardFlagInline {
+		return raw[1:], nil
+	}
+
+	gen, n, size, err := d.parseManifest(raw)
+	if err != nil {
+		return nil, err
+	}
+	// 清单可能已损坏，预分配不超过分块能容纳的长度，且不超过 sizeGuardPreallocChunks 个分块，其余按需扩容
+	val := make([]byte, 0, min(size, min(n, sizeGuardPreallocChunks)*d.cfg.ChunkSize))
+	for i := range n {
+		chunk, err := d.Cache.Get(ctx, chunkKey(key, i), opts...)
+		if err != nil {
+			return nil, err
+		}
+		if len(chunk) < generationSize || binary.BigEndian.Uint64(chunk) != gen {
+			// 分块已被其他写入覆盖，按未命中回源
+			return nil, fmt.Errorf("%w: chunk %d of key %s is stale", cache.ErrNotFound, i, key)
+		}
+		val = append(val, chunk[generationSize:]...)
+	}
+	if len(val) != size {
+		return nil, fmt.Errorf("%w: size guard reassembled %d bytes, manifest says %d", cache.ErrCorrupted, len(val), size)
+	}
+	return val, nil
+}
+
+// parseManifest 解析并校验清单，清单来自 Store，需要防御被篡改或损坏的分块数与总长度
+func (d *SizeGuardDecorator) parseManifest(raw []byte) (gen uint64, n, size int, err error) {
+	if len(raw) < 1+generationSize || raw[0] != sizeGuardFlagManifest {
+		return 0, 0, 0, fmt.Errorf("%w: size guard invalid manifest", cache.ErrCorrupted)
+	}
+	gen = binary.BigEndian.Uint64(raw[1:])
+	rest := raw[1+generationSize:]
+	count, k := binary.Uvarint(rest)
+	if k <= 0 {
+		return 0, 0, 0, fmt.Errorf("%w: size guard invalid manifest", cache.ErrCorrupted)
+	}
+	total, m := binary.Uvarint(rest[k:])
+	if m <= 0 || total > math.MaxInt || count > total+1 {
+		return 0, 0, 0, fmt.Errorf("%w: size guard invalid manifest", cache.ErrCorrupted)
+	}
+	if count > uint64(math.MaxInt/d.cfg.ChunkSize) {
+		return 0, 0, 0, fmt.Errorf("%w: size guard manifest has %d chunks, overflows chunk size %d", cache.ErrCorrupted, count, d.cfg.ChunkSize)
+	}
+	if d.cfg.MaxChunks > 0 && count > uint64(d.cfg.MaxChunks) {
+		return 0, 0, 0, fmt.Errorf("%w: size guard manifest has %d chunks, max %d", cache.ErrCorrupted, count, d.cfg.MaxChunks)
+	}
+	return gen, int(count), int(total), nil
+}
+
+func chunkKey(key string, i int) string {
+	return key + ":" + strconv.Itoa(i)
+}
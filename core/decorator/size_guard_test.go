@@ -0,0 +1,171 @@
+package decorator_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/telemetry"
+	"github.com/yikakia/cachalot/internal/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+type sizeGuardMetrics struct {
+	telemetry.Metrics
+	policies []decorator.OversizePolicy
+}
+
+func (m *sizeGuardMetrics) RecordOversize(_ context.Context, policy decorator.OversizePolicy, _ int) {
+	m.policies = append(m.policies, policy)
+}
+
+func newMapCache(t *testing.T) (*mocks.MockCache[[]byte], map[string][]byte) {
+	ctrl := gomock.NewController(t)
+	stored := map[string][]byte{}
+	next := mocks.NewMockCache[[]byte](ctrl)
+	next.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, key string, val []byte, _ time.Duration, _ ...cache.CallOption) error {
+			stored[key] = val
+			return nil
+		}).AnyTimes()
+	next.EXPECT().Get(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, key string, _ ...cache.CallOption) ([]byte, error) {
+			if v, ok := stored[key]; ok {
+				return v, nil
+			}
+			return nil, cache.ErrNotFound
+		}).AnyTimes()
+	next.EXPECT().Delete(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, key string, _ ...cache.CallOption) error {
+			delete(stored, key)
+			return nil
+		}).AnyTimes()
+	return next, stored
+}
+
+func TestSizeGuardDecorator_RejectAndSkip(t *testing.T) {
+	ctx := context.Background()
+	next, stored := newMapCache(t)
+	metrics := &sizeGuardMetrics{}
+	ob := &telemetry.Observable{Metrics: metrics}
+
+	reject, err := decorator.NewSizeGuardDecorator(next, decorator.SizeGuardConfig{MaxSize: 8}, ob)
+	require.NoError(t, err)
+	require.NoError(t, reject.Set(ctx, "k", []byte("12345678"), time.Minute))
+	require.Equal(t, []byte("12345678"), stored["k"])
+
+	evt := &telemetry.Event{}
+	err = reject.Set(telemetry.ContextWithEvent(ctx, evt), "k", []byte("123456789"), time.Minute)
+	require.ErrorIs(t, err, cache.ErrValueTooLarge)
+	require.Equal(t, "rejected", evt.FrozenCustomFields()["size_guard"])
+	require.Equal(t, []byte("12345678"), stored["k"])
+
+	// 跳过时删除旧值，避免读到与源数据不一致的值
+	skip, err := decorator.NewSizeGuardDecorator(next, decorator.SizeGuardConfig{MaxSize: 8, Policy: decorator.OversizeSkip}, ob)
+	require.NoError(t, err)
+	require.NoError(t, skip.Set(ctx, "k", []byte("123456789"), time.Minute))
+	require.NotContains(t, stored, "k")
+
+	require.Equal(t, []decorator.OversizePolicy{decorator.OversizeReject, decorator.OversizeSkip}, metrics.policies)
+}
+
+func TestSizeGuardDecorator_Chunk(t *testing.T) {
+	ctx := context.Background()
+	next, stored := newMapCache(t)
+	metrics := &sizeGuardMetrics{}
+	d, err := decorator.NewSizeGuardDecorator(next, decorator.SizeGuardConfig{
+		MaxSize:   64,
+		Policy:    decorator.OversizeChunk,
+		ChunkSize: 32,
+		MaxChunks: 10,
+	}, &telemetry.Observable{Metrics: metrics})
+	require.NoError(t, err)
+	require.False(t, decorator.RetainsValue(d))
+
+	small := []byte("tiny")
+	large := bytes.Repeat([]byte("cachalot"), 20)
+	for _, v := range [][]byte{small, large} {
+		require.NoError(t, d.Set(ctx, string(v[:4]), v, time.Minute))
+		got, err := d.Get(ctx, string(v[:4]))
+		require.NoError(t, err)
+		require.Equal(t, v, got)
+	}
+	require.Equal(t, append([]byte{0}, small...), stored["tiny"])
+	// 160 字节，每块 24 字节数据，共 7 块
+	require.Contains(t, stored, "cach:6")
+	require.NotContains(t, stored, "cach:7")
+	for key, val := range stored {
+		require.LessOrEqual(t, len(val), 64, key)
+	}
+	require.Equal(t, []decorator.OversizePolicy{decorator.OversizeChunk}, metrics.policies)
+
+	// 超过 MaxChunks 时拒绝
+	err = d.Set(ctx, "huge", bytes.Repeat([]byte("x"), 1000), time.Minute)
+	require.ErrorIs(t, err, cache.ErrValueTooLarge)
+
+	// 分块缺失或属于另一次写入时按未命中处理
+	chunk := stored["cach:3"]
+	delete(stored, "cach:3")
+	_, err = d.Get(ctx, "cach")
+	require.ErrorIs(t, err, cache.ErrNotFound)
+	stored["cach:3"] = append([]byte("otherGen"), chunk[8:]...)
+	_, err = d.Get(ctx, "cach")
+	require.ErrorIs(t, err, cache.ErrNotFound)
+
+	// 删除清单时一并删除分块
+	require.NoError(t, d.Delete(ctx, "cach"))
+	require.Len(t, stored, 1)
+
+	stored["bad"] = []byte{1, 2}
+	_, err = d.Get(ctx, "bad")
+	require.ErrorIs(t, err, cache.ErrCorrupted)
+}
+
+func TestSizeGuardDecorator_HostileManifest(t *testing.T) {
+	ctx := context.Background()
+	next, stored := newMapCache(t)
+	// MaxChunks 为 0 时不限制分块数，仍需防御损坏的清单
+	d, err := decorator.NewSizeGuardDecorator(next, decorator.SizeGuardConfig{
+		MaxSize: 64,
+		Policy:  decorator.OversizeChunk,
+	}, &telemetry.Observable{})
+	require.NoError(t, err)
+
+	manifest := func(count, total uint64) []byte {
+		raw := append([]byte{1}, "someGen!"...)
+		raw = binary.AppendUvarint(raw, count)
+		return binary.AppendUvarint(raw, total)
+	}
+	for name, raw := range map[string][]byte{
+		"total overflows int":      manifest(1, 1<<63),
+		"total max uint64":         manifest(0, math.MaxUint64),
+		"count overflows chunks":   manifest(1<<60, 1<<62),
+		"count exceeds total":      manifest(10, 2),
+		"huge but consistent size": manifest(1<<40, 1<<40),
+	} {
+		stored["k"] = raw
+		require.NotPanics(t, func() {
+			_, err = d.Get(ctx, "k")
+		}, name)
+		require.Error(t, err, name)
+	}
+
+	stored["k"] = manifest(1<<60, 1<<62)
+	_, err = d.Get(ctx, "k")
+	require.ErrorIs(t, err, cache.ErrCorrupted)
+}
+
+func TestSizeGuardConfigValidate(t *testing.T) {
+	require.Error(t, decorator.SizeGuardConfig{}.Validate())
+	require.Error(t, decorator.SizeGuardConfig{MaxSize: 10, Policy: "drop"}.Validate())
+	require.Error(t, decorator.SizeGuardConfig{MaxSize: 10, ChunkSize: 20}.Validate())
+	require.Error(t, decorator.SizeGuardConfig{MaxSize: 10, MaxChunks: -1}.Validate())
+	require.Error(t, decorator.SizeGuardConfig{MaxSize: 8, Policy: decorator.OversizeChunk}.Validate())
+	require.NoError(t, decorator.SizeGuardConfig{MaxSize: 10, Policy: decorator.OversizeChunk}.Validate())
+}
@@ -0,0 +1,198 @@
+package decorator
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/telemetry"
+)
+
+// WatchdogMetrics 超过软截止时间的调用
+type WatchdogMetrics interface {
+	// RecordSlowInFlight 调用超过软截止时间且仍在进行中
+	RecordSlowInFlight(ctx context.Context, alert WatchdogAlert)
+	// RecordOverrun 超时的调用最终结束，alert.Elapsed 为最终耗时
+	RecordOverrun(ctx context.Context, alert WatchdogAlert)
+}
+
+type WatchdogTarget string
+
+const (
+	WatchdogTargetStore  WatchdogTarget = "store"
+	WatchdogTargetLoader WatchdogTarget = "loader"
+)
+
+// WatchdogAlert 一次超过软截止时间的调用
+type WatchdogAlert struct {
+	Target WatchdogTarget
+	// 存储调用的操作类型，回源时为空
+	Op telemetry.Op
+	// 存储调用为 StoreName，回源为缓存名
+	Name         string
+	Key          string
+	SoftDeadline time.Duration
+	// 在途告警时为触发时的耗时，结束告警时为最终耗时
+	Elapsed time.Duration
+	// false 表示调用仍在进行中
+	Done bool
+	// 仅结束告警时有值
+	Err error
+}
+
+// Overrun 超出软截止时间的部分
+func (a WatchdogAlert) Overrun() time.Duration {
+	return max(a.Elapsed-a.SoftDeadline, 0)
+}
+
+type WatchdogConfig struct {
+	// 软截止时间，超过后只告警不中断调用
+	SoftDeadline time.Duration
+	// 调用超时且仍在进行中时回调一次，该调用结束时再回调一次（Done=true）
+	// 在途回调运行在计时器的 goroutine 中，调用结束时在途回调尚未返回的，结束回调随后在同一个 goroutine 中执行，
+	// 调用本身不等待回调；同一次调用的两次回调不会并发，不同调用的回调可能并发
+	OnSlow func(ctx context.Context, alert WatchdogAlert)
+}
+
+// Watchdog 为每次调用启动计时器，超过软截止时间时通过回调、WatchdogMetrics 与事件自定义字段告警
+//
+// 自定义字段：在途时写入 watchdog=<target>，结束时写入 watchdog_overrun=<超出时长>
+type Watchdog struct {
+	cfg     WatchdogConfig
+	metrics []WatchdogMetrics
+}
+
+func NewWatchdog(cfg WatchdogConfig, ob *telemetry.Observable) *Watchdog {
+	w := &Watchdog{cfg: cfg}
+	if ob != nil {
+		w.metrics = telemetry.MetricsAs[WatchdogMetrics](ob.Metrics)
+	}
+	return w
+}
+
+// Watch 开始计时，调用结束时需要调用返回的函数
+func (w *Watchdog) Watch(ctx context.Context, alert WatchdogAlert) func(err error) {
+	if w == nil || w.cfg.SoftDeadline <= 0 {
+		return func(error) {}
+	}
+	alert.SoftDeadline = w.cfg.SoftDeadline
+	start := time.Now()
+	var (
+		mu       sync.Mutex
+		notified bool
+		pending  *WatchdogAlert
+	)
+	timer := time.AfterFunc(w.cfg.SoftDeadline, func() {
+		inFlight := alert
+		inFlight.Elapsed = time.Since(start)
+		telemetry.AddCustomFields(ctx, map[string]string{"watchdog": string(alert.Target)})
+		w.notify(ctx, inFlight)
+
+		mu.Lock()
+		notified = true
+		done := pending
+		mu.Unlock()
+		if done != nil {
+			w.notify(ctx, *done)
+		}
+	})
+
+	return func(err error) {
+		if timer.Stop() {
+			return
+		}
+		done := alert
+		done.Elapsed = time.Since(start)
+		done.Done = true
+		done.Err = err
+		telemetry.AddCustomFields(ctx, map[string]string{"watchdog_overrun": done.Overrun().String()})
+
+		// 在途告警尚未完成时交给计时器的 goroutine 随后发送，保证回调的先后顺序且不阻塞调用
+		mu.Lock()
+		if !notified {
+			pending = &done
+			mu.Unlock()
+			return
+		}
+		mu.Unlock()
+		w.notify(ctx, done)
+	}
+}
+
+func (w *Watchdog) notify(ctx context.Context, alert WatchdogAlert) {
+	if w.cfg.OnSlow != nil {
+		w.cfg.OnSlow(ctx, alert)
+	}
+	for _, m := range w.metrics {
+		if alert.Done {
+			m.RecordOverrun(ctx, alert)
+		} else {
+			m.RecordSlowInFlight(ctx, alert)
+		}
+	}
+}
+
+// WatchLoader 监控回源函数，应当位于 singleflight 内侧以观测实际的回源调用
+func WatchLoader[T any](w *Watchdog, name string, fn LoaderFn[T]) LoaderFn[T] {
+	return func(ctx context.Context, key string, opts ...cache.CallOption) (_ T, err error) {
+		stop := w.Watch(ctx, WatchdogAlert{Target: WatchdogTargetLoader, Name: name, Key: key})
+		defer func() { stop(err) }()
+		return fn(ctx, key, opts...)
+	}
+}
+
+var _ cache.Store = (*WatchdogStore)(nil)
+
+// WatchdogStore 监控 Store 的每次调用
+type WatchdogStore struct {
+	store    cache.Store
+	watchdog *Watchdog
+}
+
+func NewWatchdogStore(store cache.Store, w *Watchdog) *WatchdogStore {
+	return &WatchdogStore{store: store, watchdog: w}
+}
+
+func (s *WatchdogStore) watch(ctx context.Context, op telemetry.Op, key string) func(error) {
+	return s.watchdog.Watch(ctx, WatchdogAlert{
+		Target: WatchdogTargetStore,
+		Op:     op,
+		Name:   s.store.StoreName(),
+		Key:    key,
+	})
+}
+
+func (s *WatchdogStore) Get(ctx context.Context, key string, opts ...cache.CallOption) (_ any, err error) {
+	stop := s.watch(ctx, telemetry.OpGet, key)
+	defer func() { stop(err) }()
+	return s.store.Get(ctx, key, opts...)
+}
+
+func (s *WatchdogStore) Set(ctx context.Context, key string, val any, ttl time.Duration, opts ...cache.CallOption) (err error) {
+	stop := s.watch(ctx, telemetry.OpSet, key)
+	defer func() { stop(err) }()
+	return s.store.Set(ctx, key, val, ttl, opts...)
+}
+
+func (s *WatchdogStore) GetWithTTL(ctx context.Context, key string, opts ...cache.CallOption) (_ any, _ time.Duration, err error) {
+	stop := s.watch(ctx, telemetry.OpGetWithTTL, key)
+	defer func() { stop(err) }()
+	return s.store.GetWithTTL(ctx, key, opts...)
+}
+
+func (s *WatchdogStore) Delete(ctx context.Context, key string, opts ...cache.CallOption) (err error) {
+	stop := s.watch(ctx, telemetry.OpDelete, key)
+	defer func() { stop(err) }()
+	return s.store.Delete(ctx, key, opts...)
+}
+
+func (s *WatchdogStore) Clear(ctx context.Context) (err error) {
+	stop := s.watch(ctx, telemetry.OpClear, "")
+	defer func() { stop(err) }()
+	return s.store.Clear(ctx)
+}
+
+func (s *WatchdogStore) StoreName() string {
+	return s.store.StoreName()
+}
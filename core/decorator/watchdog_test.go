@@ -0,0 +1,184 @@
+package decorator_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/telemetry"
+	"github.com/yikakia/cachalot/internal/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+type watchdogMetrics struct {
+	telemetry.Metrics
+	mu       sync.Mutex
+	inFlight []decorator.WatchdogAlert
+	overrun  []decorator.WatchdogAlert
+}
+
+func (w *watchdogMetrics) RecordSlowInFlight(_ context.Context, alert decorator.WatchdogAlert) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.inFlight = append(w.inFlight, alert)
+}
+
+func (w *watchdogMetrics) RecordOverrun(_ context.Context, alert decorator.WatchdogAlert) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.overrun = append(w.overrun, alert)
+}
+
+func TestWatchdogStore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mocks.NewMockStore(ctrl)
+	store.EXPECT().StoreName().Return("slow").AnyTimes()
+
+	metrics := &watchdogMetrics{}
+	alerts := make(chan decorator.WatchdogAlert, 2)
+	w := decorator.NewWatchdog(decorator.WatchdogConfig{
+		SoftDeadline: 10 * time.Millisecond,
+		OnSlow: func(ctx context.Context, alert decorator.WatchdogAlert) {
+			alerts <- alert
+		},
+	}, &telemetry.Observable{Metrics: metrics})
+	ws := decorator.NewWatchdogStore(store, w)
+
+	// 快速调用不会告警
+	store.EXPECT().Get(gomock.Any(), "fast").Return("v", nil)
+	_, err := ws.Get(context.Background(), "fast")
+	require.NoError(t, err)
+	require.Empty(t, alerts)
+
+	// 慢调用先告警在途，结束后再告警一次
+	storeErr := errors.New("timeout")
+	release := make(chan struct{})
+	store.EXPECT().Get(gomock.Any(), "slow").DoAndReturn(func(ctx context.Context, key string, opts ...cache.CallOption) (any, error) {
+		<-release
+		return nil, storeErr
+	})
+
+	evt := &telemetry.Event{}
+	ctx := telemetry.ContextWithEvent(context.Background(), evt)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := ws.Get(ctx, "slow")
+		require.ErrorIs(t, err, storeErr)
+	}()
+
+	inFlight := <-alerts
+	require.False(t, inFlight.Done)
+	require.Equal(t, decorator.WatchdogTargetStore, inFlight.Target)
+	require.Equal(t, telemetry.OpGet, inFlight.Op)
+	require.Equal(t, "slow", inFlight.Name)
+	require.Equal(t, "slow", inFlight.Key)
+	require.GreaterOrEqual(t, inFlight.Elapsed, 10*time.Millisecond)
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	<-done
+
+	final := <-alerts
+	require.True(t, final.Done)
+	require.ErrorIs(t, final.Err, storeErr)
+	require.Greater(t, final.Overrun(), time.Duration(0))
+
+	require.Len(t, metrics.inFlight, 1)
+	require.Len(t, metrics.overrun, 1)
+	require.Equal(t, "store", evt.FrozenCustomFields()["watchdog"])
+	require.Equal(t, final.Overrun().String(), evt.FrozenCustomFields()["watchdog_overrun"])
+}
+
+func TestWatchLoader(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		alerts []decorator.WatchdogAlert
+	)
+	w := decorator.NewWatchdog(decorator.WatchdogConfig{
+		SoftDeadline: 10 * time.Millisecond,
+		OnSlow: func(ctx context.Context, alert decorator.WatchdogAlert) {
+			mu.Lock()
+			defer mu.Unlock()
+			alerts = append(alerts, alert)
+		},
+	}, nil)
+
+	fn := decorator.WatchLoader(w, "users", func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+		if key == "slow" {
+			time.Sleep(30 * time.Millisecond)
+		}
+		return key, nil
+	})
+
+	v, err := fn(context.Background(), "fast")
+	require.NoError(t, err)
+	require.Equal(t, "fast", v)
+
+	v, err = fn(context.Background(), "slow")
+	require.NoError(t, err)
+	require.Equal(t, "slow", v)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, alerts, 2)
+	require.Equal(t, decorator.WatchdogTargetLoader, alerts[0].Target)
+	require.Equal(t, "users", alerts[0].Name)
+	require.False(t, alerts[0].Done)
+	require.True(t, alerts[1].Done)
+	require.NoError(t, alerts[1].Err)
+	require.GreaterOrEqual(t, alerts[1].Elapsed, 30*time.Millisecond)
+}
+
+func TestWatchdogSlowCallbackDoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	inFlightStarted := make(chan struct{})
+	var (
+		mu     sync.Mutex
+		alerts []decorator.WatchdogAlert
+	)
+	doneDelivered := make(chan struct{})
+	w := decorator.NewWatchdog(decorator.WatchdogConfig{
+		SoftDeadline: time.Millisecond,
+		OnSlow: func(ctx context.Context, alert decorator.WatchdogAlert) {
+			if !alert.Done {
+				close(inFlightStarted)
+				<-release
+			}
+			mu.Lock()
+			alerts = append(alerts, alert)
+			mu.Unlock()
+			if alert.Done {
+				close(doneDelivered)
+			}
+		},
+	}, nil)
+
+	stop := w.Watch(context.Background(), decorator.WatchdogAlert{Target: decorator.WatchdogTargetStore})
+	<-inFlightStarted
+
+	// 在途回调阻塞时结束调用不会等待
+	returned := make(chan struct{})
+	go func() {
+		stop(nil)
+		close(returned)
+	}()
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("stop blocked on the in-flight callback")
+	}
+
+	close(release)
+	<-doneDelivered
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, alerts, 2)
+	require.False(t, alerts[0].Done)
+	require.True(t, alerts[1].Done)
+}
@@ -0,0 +1,105 @@
+package encrypt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/telemetry"
+)
+
+// Metrics 解密失败时上报，ob.Metrics 实现该接口时生效
+type Metrics interface {
+	// RecordTampered 值认证失败，按未命中处理
+	RecordTampered(ctx context.Context, keyID uint32)
+	// RecordUnknownKey 值使用的密钥已退役或不存在，按未命中处理
+	RecordUnknownKey(ctx context.Context, keyID uint32)
+}
+
+var _ cache.Cache[[]byte] = (*Decorator)(nil)
+
+// Decorator 写入时加密，读取时解密
+//
+// 解密失败（篡改或密钥已退役）时返回包裹 cache.ErrNotFound 的错误，外层按未命中回源并以当前密钥写回，
+// 同时在事件中写入自定义字段 encryption=tampered|unknown_key
+type Decorator struct {
+	cache.Cache[[]byte]
+	keyring *Keyring
+	metrics []Metrics
+}
+
+// NewDecorator ob 可以为空，用于上报 Metrics
+func NewDecorator(next cache.Cache[[]byte], keyring *Keyring, ob *telemetry.Observable) *Decorator {
+	d := &Decorator{
+		Cache:   next,
+		keyring: keyring,
+	}
+	if ob != nil {
+		d.metrics = telemetry.MetricsAs[Metrics](ob.Metrics)
+	}
+	return d
+}
+
+// Transform 返回可直接传给 Builder.WithByteTransforms 的字节级转换
+func Transform(keyring *Keyring) func(next cache.Cache[[]byte], ob *telemetry.Observable) (cache.Cache[[]byte], error) {
+	return func(next cache.Cache[[]byte], ob *telemetry.Observable) (cache.Cache[[]byte], error) {
+		if keyring == nil {
+			return nil, errors.New("encrypt: keyring is required")
+		}
+		return NewDecorator(next, keyring, ob), nil
+	}
+}
+
+func (d *Decorator) Get(ctx context.Context, key string, opts ...cache.CallOption) ([]byte, error) {
+	raw, err := d.Cache.Get(ctx, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return d.open(ctx, key, raw)
+}
+
+func (d *Decorator) GetWithTTL(ctx context.Context, key string, opts ...cache.CallOption) ([]byte, time.Duration, error) {
+	raw, ttl, err := d.Cache.GetWithTTL(ctx, key, opts...)
+	if err != nil {
+		return nil, 0, err
+	}
+	plaintext, err := d.open(ctx, key, raw)
+	if err != nil {
+		return nil, 0, err
+	}
+	return plaintext, ttl, nil
+}
+
+func (d *Decorator) Set(ctx context.Context, key string, val []byte, ttl time.Duration, opts ...cache.CallOption) error {
+	sealed, err := d.keyring.Seal(key, val)
+	if err != nil {
+		return err
+	}
+	return d.Cache.Set(ctx, key, sealed, ttl, opts...)
+}
+
+// RetainsValue 密文是新的字节，Set 返回后不再引用 val
+func (d *Decorator) RetainsValue() bool {
+	return false
+}
+
+func (d *Decorator) open(ctx context.Context, key string, raw []byte) ([]byte, error) {
+	plaintext, id, err := d.keyring.Open(key, raw)
+	switch {
+	case err == nil:
+		return plaintext, nil
+	case errors.Is(err, ErrUnknownKey):
+		telemetry.AddCustomFields(ctx, map[string]string{"encryption": "unknown_key"})
+		for _, m := range d.metrics {
+			m.RecordUnknownKey(ctx, id)
+		}
+	default:
+		telemetry.AddCustomFields(ctx, map[string]string{"encryption": "tampered"})
+		for _, m := range d.metrics {
+			m.RecordTampered(ctx, id)
+		}
+	}
+	return nil, fmt.Errorf("%w: %w: %w", cache.ErrNotFound, cache.ErrCorrupted, err)
+}
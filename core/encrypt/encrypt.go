@@ -0,0 +1,188 @@
+// Package encrypt 提供缓存值的静态加密，支持密钥轮换
+//
+// 存储格式：| version | key ID (4 字节大端) | nonce | 密文 + 认证标签 |
+//
+// 认证附加数据为头部与缓存 key，密文无法被挪到其他 key 下使用
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	formatVersion byte = 1
+	headerSize         = 1 + 4
+)
+
+var (
+	// ErrTampered 值认证失败，可能被篡改或损坏
+	ErrTampered = errors.New("encrypt: message authentication failed")
+	// ErrUnknownKey 值使用的密钥不在 Keyring 中，通常是已退役
+	ErrUnknownKey = errors.New("encrypt: unknown key id")
+)
+
+// Key 带 ID 的 AEAD 密钥，ID 会写入每个值的头部，用于解密时选择密钥
+//
+// AEAD 可以是任意实现，如 chacha20poly1305.NewX 创建的 XChaCha20-Poly1305
+type Key struct {
+	ID   uint32
+	AEAD cipher.AEAD
+}
+
+// NewAESGCM 创建 AES-GCM 密钥，secret 长度需为 16、24 或 32 字节
+//
+// Seal 每次写入随机生成 96 位 nonce，同一密钥写入超过约 2^32 次后 nonce 碰撞的概率不可忽略，
+// 写入量大的缓存需要在此之前 Rotate，或改用 nonce 为 192 位的 XChaCha20-Poly1305
+func NewAESGCM(id uint32, secret []byte) (Key, error) {
+	block, err := aes.NewCipher(secret)
+	if err != nil {
+		return Key{}, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return Key{}, err
+	}
+	return Key{ID: id, AEAD: aead}, nil
+}
+
+// Keyring 管理当前密钥与仍可解密的旧密钥，可并发使用
+//
+// 新写入使用当前密钥；Rotate 后旧密钥仍可解密，直到 Retire
+type Keyring struct {
+	mu    sync.Mutex
+	state atomic.Pointer[keyringState]
+}
+
+type keyringState struct {
+	current Key
+	keys    map[uint32]Key
+}
+
+// NewKeyring current 为写入使用的密钥，previous 为仅用于解密的旧密钥
+func NewKeyring(current Key, previous ...Key) (*Keyring, error) {
+	state := &keyringState{current: current, keys: map[uint32]Key{}}
+	for _, k := range append([]Key{current}, previous...) {
+		if k.AEAD == nil {
+			return nil, fmt.Errorf("encrypt: key %d has nil AEAD", k.ID)
+		}
+		if _, ok := state.keys[k.ID]; ok {
+			return nil, fmt.Errorf("encrypt: duplicate key id %d", k.ID)
+		}
+		state.keys[k.ID] = k
+	}
+	r := &Keyring{}
+	r.state.Store(state)
+	return r, nil
+}
+
+// Rotate 将 next 设为当前密钥，原当前密钥保留用于解密
+func (r *Keyring) Rotate(next Key) error {
+	if next.AEAD == nil {
+		return fmt.Errorf("encrypt: key %d has nil AEAD", next.ID)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := r.state.Load()
+	if _, ok := old.keys[next.ID]; ok {
+		return fmt.Errorf("encrypt: duplicate key id %d", next.ID)
+	}
+	keys := make(map[uint32]Key, len(old.keys)+1)
+	for id, k := range old.keys {
+		keys[id] = k
+	}
+	keys[next.ID] = next
+	r.state.Store(&keyringState{current: next, keys: keys})
+	return nil
+}
+
+// Retire 移除旧密钥，之后使用该密钥的值按未命中处理；不能移除当前密钥
+func (r *Keyring) Retire(id uint32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := r.state.Load()
+	if old.current.ID == id {
+		return fmt.Errorf("encrypt: cannot retire current key %d", id)
+	}
+	if _, ok := old.keys[id]; !ok {
+		return fmt.Errorf("%w: %d", ErrUnknownKey, id)
+	}
+	keys := make(map[uint32]Key, len(old.keys)-1)
+	for kid, k := range old.keys {
+		if kid != id {
+			keys[kid] = k
+		}
+	}
+	r.state.Store(&keyringState{current: old.current, keys: keys})
+	return nil
+}
+
+// Current 当前写入使用的密钥 ID
+func (r *Keyring) Current() uint32 {
+	return r.state.Load().current.ID
+}
+
+// IDs 仍可解密的密钥 ID，升序
+func (r *Keyring) IDs() []uint32 {
+	state := r.state.Load()
+	ids := make([]uint32, 0, len(state.keys))
+	for id := range state.keys {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// Seal 使用当前密钥加密 plaintext，key 为缓存 key，作为认证附加数据
+//
+// nonce 随机生成，每个密钥的写入次数受 AEAD 的 nonce 长度限制，见 NewAESGCM
+func (r *Keyring) Seal(key string, plaintext []byte) ([]byte, error) {
+	k := r.state.Load().current
+	nonceSize := k.AEAD.NonceSize()
+
+	out := make([]byte, headerSize+nonceSize, headerSize+nonceSize+len(plaintext)+k.AEAD.Overhead())
+	out[0] = formatVersion
+	binary.BigEndian.PutUint32(out[1:headerSize], k.ID)
+	nonce := out[headerSize:]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return k.AEAD.Seal(out, nonce, plaintext, additionalData(out[:headerSize], key)), nil
+}
+
+// Open 解密 Seal 的结果，返回值使用的密钥 ID
+//
+// 密钥不存在时返回 ErrUnknownKey，格式错误或认证失败时返回 ErrTampered
+func (r *Keyring) Open(key string, data []byte) ([]byte, uint32, error) {
+	if len(data) < headerSize || data[0] != formatVersion {
+		return nil, 0, ErrTampered
+	}
+	id := binary.BigEndian.Uint32(data[1:headerSize])
+	k, ok := r.state.Load().keys[id]
+	if !ok {
+		return nil, id, fmt.Errorf("%w: %d", ErrUnknownKey, id)
+	}
+	nonceSize := k.AEAD.NonceSize()
+	if len(data) < headerSize+nonceSize+k.AEAD.Overhead() {
+		return nil, id, ErrTampered
+	}
+	nonce := data[headerSize : headerSize+nonceSize]
+	plaintext, err := k.AEAD.Open(nil, nonce, data[headerSize+nonceSize:], additionalData(data[:headerSize], key))
+	if err != nil {
+		return nil, id, ErrTampered
+	}
+	return plaintext, id, nil
+}
+
+func additionalData(header []byte, key string) []byte {
+	ad := make([]byte, 0, len(header)+len(key))
+	ad = append(ad, header...)
+	return append(ad, key...)
+}
@@ -0,0 +1,140 @@
+package encrypt_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/encrypt"
+	"github.com/yikakia/cachalot/core/telemetry"
+	"github.com/yikakia/cachalot/internal/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func newKey(t *testing.T, id uint32, size int) encrypt.Key {
+	k, err := encrypt.NewAESGCM(id, bytes.Repeat([]byte{byte(id)}, size))
+	require.NoError(t, err)
+	return k
+}
+
+func TestKeyringRotation(t *testing.T) {
+	k1, k2 := newKey(t, 1, 16), newKey(t, 2, 32)
+
+	ring, err := encrypt.NewKeyring(k1)
+	require.NoError(t, err)
+	old, err := ring.Seal("k", []byte("v1"))
+	require.NoError(t, err)
+
+	require.NoError(t, ring.Rotate(k2))
+	require.Equal(t, uint32(2), ring.Current())
+	require.Equal(t, []uint32{1, 2}, ring.IDs())
+	require.Error(t, ring.Rotate(k1))
+
+	// 旧密钥加密的值在退役前仍可解密
+	got, id, err := ring.Open("k", old)
+	require.NoError(t, err)
+	require.Equal(t, []byte("v1"), got)
+	require.Equal(t, uint32(1), id)
+
+	sealed, err := ring.Seal("k", []byte("v2"))
+	require.NoError(t, err)
+	_, id, err = ring.Open("k", sealed)
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), id)
+
+	require.Error(t, ring.Retire(2))
+	require.NoError(t, ring.Retire(1))
+	_, _, err = ring.Open("k", old)
+	require.ErrorIs(t, err, encrypt.ErrUnknownKey)
+
+	_, err = encrypt.NewKeyring(k1, k1)
+	require.Error(t, err)
+	_, err = encrypt.NewAESGCM(3, []byte("short"))
+	require.Error(t, err)
+}
+
+func TestKeyringTampered(t *testing.T) {
+	ring, err := encrypt.NewKeyring(newKey(t, 1, 32))
+	require.NoError(t, err)
+	sealed, err := ring.Seal("k", []byte("secret"))
+	require.NoError(t, err)
+	require.NotContains(t, string(sealed), "secret")
+
+	flipped := bytes.Clone(sealed)
+	flipped[len(flipped)-1] ^= 1
+	_, _, err = ring.Open("k", flipped)
+	require.ErrorIs(t, err, encrypt.ErrTampered)
+
+	// 密文绑定了缓存 key
+	_, _, err = ring.Open("other", sealed)
+	require.ErrorIs(t, err, encrypt.ErrTampered)
+
+	_, _, err = ring.Open("k", sealed[:3])
+	require.ErrorIs(t, err, encrypt.ErrTampered)
+}
+
+type encryptMetrics struct {
+	telemetry.Metrics
+	tampered, unknown []uint32
+}
+
+func (m *encryptMetrics) RecordTampered(_ context.Context, keyID uint32) {
+	m.tampered = append(m.tampered, keyID)
+}
+
+func (m *encryptMetrics) RecordUnknownKey(_ context.Context, keyID uint32) {
+	m.unknown = append(m.unknown, keyID)
+}
+
+func TestDecorator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	stored := map[string][]byte{}
+	next := mocks.NewMockCache[[]byte](ctrl)
+	next.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), time.Minute).DoAndReturn(
+		func(_ context.Context, key string, val []byte, _ time.Duration, _ ...cache.CallOption) error {
+			stored[key] = val
+			return nil
+		}).AnyTimes()
+	next.EXPECT().GetWithTTL(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, key string, _ ...cache.CallOption) ([]byte, time.Duration, error) {
+			return stored[key], time.Minute, nil
+		}).AnyTimes()
+
+	ring, err := encrypt.NewKeyring(newKey(t, 1, 32))
+	require.NoError(t, err)
+	metrics := &encryptMetrics{}
+	c, err := encrypt.Transform(ring)(next, &telemetry.Observable{Metrics: metrics})
+	require.NoError(t, err)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("pii"), time.Minute))
+	require.NoError(t, c.Set(ctx, "b", []byte("pii"), time.Minute))
+	got, ttl, err := c.GetWithTTL(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, []byte("pii"), got)
+	require.Equal(t, time.Minute, ttl)
+
+	stored["a"][len(stored["a"])-1] ^= 1
+	evt := &telemetry.Event{}
+	_, _, err = c.GetWithTTL(telemetry.ContextWithEvent(ctx, evt), "a")
+	require.ErrorIs(t, err, cache.ErrNotFound)
+	require.ErrorIs(t, err, cache.ErrCorrupted)
+	require.ErrorIs(t, err, encrypt.ErrTampered)
+	require.Equal(t, "tampered", evt.FrozenCustomFields()["encryption"])
+	require.Equal(t, []uint32{1}, metrics.tampered)
+
+	require.NoError(t, ring.Rotate(newKey(t, 2, 32)))
+	require.NoError(t, ring.Retire(1))
+	_, _, err = c.GetWithTTL(ctx, "b")
+	require.ErrorIs(t, err, cache.ErrNotFound)
+	require.ErrorIs(t, err, cache.ErrCorrupted)
+	require.ErrorIs(t, err, encrypt.ErrUnknownKey)
+	require.Equal(t, []uint32{1}, metrics.unknown)
+
+	_, err = encrypt.Transform(nil)(next, nil)
+	require.Error(t, err)
+}
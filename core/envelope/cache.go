@@ -0,0 +1,191 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/codec"
+	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/telemetry"
+	"github.com/yikakia/cachalot/internal/bufpool"
+)
+
+type Config struct {
+	// 为空时使用 Default()
+	Registry *Registry
+	// 写入使用的格式，需要已注册
+	Write Format
+	// 非空时，没有信封头的数据按该格式解码，用于从未使用信封的旧数据滚动迁移
+	Legacy *Format
+}
+
+var _ cache.Cache[any] = (*Decorator[any])(nil)
+
+// Decorator 以信封格式完成 T <-> []byte 的转换
+//
+// 写入时使用 Config.Write 编码、压缩并追加信封头；读取时按信封头中的格式解码，
+// 因此切换编解码或压缩方式后旧数据仍然可读，无需清空缓存。
+// 读到与当前写入格式不同的值时，在事件中写入自定义字段 envelope=legacy|outdated
+type Decorator[T any] struct {
+	cache.Cache[[]byte]
+	registry *Registry
+	write    Format
+	legacy   *Format
+	ob       *telemetry.Observable
+}
+
+// NewDecorator ob 可以为空，用于上报带版本的 codec 的 decorator.SchemaMetrics
+func NewDecorator[T any](next cache.Cache[[]byte], cfg Config, ob *telemetry.Observable) (*Decorator[T], error) {
+	registry := cfg.Registry
+	if registry == nil {
+		registry = Default()
+	}
+	if cfg.Write.Codec == CodecRaw && reflect.TypeFor[T]() != reflect.TypeFor[[]byte]() {
+		return nil, fmt.Errorf("envelope: raw codec requires []byte value type, but got %s", reflect.TypeFor[T]())
+	}
+	formats := []Format{cfg.Write}
+	if cfg.Legacy != nil {
+		formats = append(formats, *cfg.Legacy)
+	}
+	for _, f := range formats {
+		if _, err := registry.Codec(f.Codec); err != nil {
+			return nil, err
+		}
+		if _, err := registry.Compression(f.Compression); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Decorator[T]{
+		Cache:    next,
+		registry: registry,
+		write:    cfg.Write,
+		legacy:   cfg.Legacy,
+		ob:       ob,
+	}, nil
+}
+
+func (d *Decorator[T]) Get(ctx context.Context, key string, opts ...cache.CallOption) (T, error) {
+	var zero T
+	raw, err := d.Cache.Get(ctx, key, opts...)
+	if err != nil {
+		return zero, err
+	}
+	v, err := d.unmarshal(ctx, raw)
+	if err != nil {
+		return zero, err
+	}
+	return v, nil
+}
+
+func (d *Decorator[T]) GetWithTTL(ctx context.Context, key string, opts ...cache.CallOption) (T, time.Duration, error) {
+	var zero T
+	raw, ttl, err := d.Cache.GetWithTTL(ctx, key, opts...)
+	if err != nil {
+		return zero, 0, err
+	}
+	v, err := d.unmarshal(ctx, raw)
+	if err != nil {
+		return zero, 0, err
+	}
+	return v, ttl, nil
+}
+
+func (d *Decorator[T]) Set(ctx context.Context, key string, val T, ttl time.Duration, opts ...cache.CallOption) error {
+	buf := bufpool.Get()
+	defer bufpool.Put(buf)
+	raw, err := d.marshal(ctx, val, buf)
+	if err != nil {
+		return err
+	}
+	// 编码在池化的缓冲区中完成，下层会保留 val 时复制一份
+	if decorator.RetainsValue(d.Cache) {
+		raw = bytes.Clone(raw)
+	}
+	return d.Cache.Set(ctx, key, raw, ttl, opts...)
+}
+
+// marshal 将信封追加到 out 中
+func (d *Decorator[T]) marshal(ctx context.Context, val T, out *[]byte) ([]byte, error) {
+	c, err := d.registry.Codec(d.write.Codec)
+	if err != nil {
+		return nil, err
+	}
+	z, err := d.registry.Compression(d.write.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	encBuf := bufpool.Get()
+	defer bufpool.Put(encBuf)
+	encoded, err := codec.AppendMarshal(c, *encBuf, val)
+	if err != nil {
+		return nil, err
+	}
+	*encBuf = encoded
+
+	raw := appendHeader(*out, d.write)
+	if ca, ok := z.(decorator.CompressionAppender); ok {
+		raw, err = ca.AppendCompress(raw, encoded)
+	} else {
+		var compressed []byte
+		if compressed, err = z.Compress(encoded); err == nil {
+			raw = append(raw, compressed...)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	*out = raw
+	decorator.RecordPayloadBytes(ctx, len(encoded), len(raw)-HeaderSize)
+	return raw, nil
+}
+
+func (d *Decorator[T]) unmarshal(ctx context.Context, raw []byte) (T, error) {
+	var target T
+	f, payload, err := Decode(raw)
+	switch {
+	case err == nil:
+		if f != d.write {
+			telemetry.AddCustomFields(ctx, map[string]string{"envelope": "outdated"})
+		}
+	case errors.Is(err, ErrNotEnveloped) && d.legacy != nil:
+		f, payload = *d.legacy, raw
+		telemetry.AddCustomFields(ctx, map[string]string{"envelope": "legacy"})
+	default:
+		return target, err
+	}
+
+	c, err := d.registry.Codec(f.Codec)
+	if err != nil {
+		return target, err
+	}
+	z, err := d.registry.Compression(f.Compression)
+	if err != nil {
+		return target, err
+	}
+
+	var encoded []byte
+	// 解码结果可能引用解压结果（如 raw codec）时不能使用池化的缓冲区
+	if ca, ok := z.(decorator.CompressionAppender); ok && !codec.RetainsData(c) && f.Compression != CompressionNone {
+		buf := bufpool.Get()
+		defer bufpool.Put(buf)
+		encoded, err = ca.DecompressTo(*buf, payload)
+		*buf = encoded
+	} else {
+		encoded, err = z.Decompress(payload)
+	}
+	if err != nil {
+		return target, err
+	}
+	decorator.RecordPayloadBytes(ctx, len(encoded), len(payload))
+	if err := decorator.UnmarshalValue(ctx, d.ob, c, encoded, &target); err != nil {
+		return target, err
+	}
+	return target, nil
+}
@@ -0,0 +1,140 @@
+package envelope
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 信封格式：
+//
+//	| magic 0xC1 0xCA | version | codec ID | compression ID | payload |
+//
+// 0xC1 既不是合法的 UTF-8 首字节，也是 MessagePack 保留不用的字节，
+// 未使用信封的旧数据（JSON、MessagePack 等）不会被误判为信封
+const (
+	magic0 byte = 0xC1
+	magic1 byte = 0xCA
+
+	// Version 当前的信封格式版本
+	Version byte = 1
+	// HeaderSize 信封头的长度
+	HeaderSize = 5
+)
+
+var (
+	// ErrNotEnveloped 数据没有信封头
+	ErrNotEnveloped = errors.New("envelope: data is not enveloped")
+	// ErrInvalidEnvelope 信封头不完整或版本不支持
+	ErrInvalidEnvelope = errors.New("envelope: invalid envelope")
+	// ErrUnknownFormat 信封中的编解码或压缩方式没有注册
+	ErrUnknownFormat = errors.New("envelope: unknown format")
+)
+
+// CodecID 编解码方式的标识，写入信封后即成为持久化格式的一部分，不能修改含义
+type CodecID uint8
+
+// 内置与预留的编解码标识，自定义实现请使用 >= CodecUserDefined 的值
+const (
+	// CodecRaw 值本身就是 []byte，不做编解码
+	CodecRaw  CodecID = 0
+	CodecJSON CodecID = 1
+	CodecGob  CodecID = 2
+	// 以下为独立模块预留，需要手动注册
+	CodecProtobuf    CodecID = 3
+	CodecMessagePack CodecID = 4
+	CodecCBOR        CodecID = 5
+
+	CodecUserDefined CodecID = 128
+)
+
+var codecNames = map[CodecID]string{
+	CodecRaw:         "raw",
+	CodecJSON:        "json",
+	CodecGob:         "gob",
+	CodecProtobuf:    "protobuf",
+	CodecMessagePack: "msgpack",
+	CodecCBOR:        "cbor",
+}
+
+func (id CodecID) String() string {
+	if name, ok := codecNames[id]; ok {
+		return name
+	}
+	return fmt.Sprintf("codec(%d)", uint8(id))
+}
+
+// CompressionID 压缩方式的标识，写入信封后即成为持久化格式的一部分，不能修改含义
+type CompressionID uint8
+
+// 内置与预留的压缩标识，自定义实现请使用 >= CompressionUserDefined 的值
+const (
+	CompressionNone  CompressionID = 0
+	CompressionGzip  CompressionID = 1
+	CompressionZlib  CompressionID = 2
+	CompressionFlate CompressionID = 3
+	CompressionLZW   CompressionID = 4
+	// 以下为独立模块预留，需要手动注册
+	CompressionZstd   CompressionID = 5
+	CompressionSnappy CompressionID = 6
+	CompressionLZ4    CompressionID = 7
+
+	CompressionUserDefined CompressionID = 128
+)
+
+var compressionNames = map[CompressionID]string{
+	CompressionNone:   "none",
+	CompressionGzip:   "gzip",
+	CompressionZlib:   "zlib",
+	CompressionFlate:  "flate",
+	CompressionLZW:    "lzw",
+	CompressionZstd:   "zstd",
+	CompressionSnappy: "snappy",
+	CompressionLZ4:    "lz4",
+}
+
+func (id CompressionID) String() string {
+	if name, ok := compressionNames[id]; ok {
+		return name
+	}
+	return fmt.Sprintf("compression(%d)", uint8(id))
+}
+
+// Format 一个值使用的编解码与压缩方式
+type Format struct {
+	Codec       CodecID
+	Compression CompressionID
+}
+
+func (f Format) String() string {
+	return f.Codec.String() + "+" + f.Compression.String()
+}
+
+// IsEnveloped 判断数据是否带有信封头
+func IsEnveloped(data []byte) bool {
+	return len(data) >= 2 && data[0] == magic0 && data[1] == magic1
+}
+
+// Encode 在 payload 前追加信封头
+func Encode(f Format, payload []byte) []byte {
+	out := make([]byte, 0, HeaderSize+len(payload))
+	return append(appendHeader(out, f), payload...)
+}
+
+func appendHeader(dst []byte, f Format) []byte {
+	return append(dst, magic0, magic1, Version, byte(f.Codec), byte(f.Compression))
+}
+
+// Decode 解析信封头，返回格式与 payload，payload 与 data 共享底层数组
+func Decode(data []byte) (Format, []byte, error) {
+	if !IsEnveloped(data) {
+		return Format{}, nil, ErrNotEnveloped
+	}
+	if len(data) < HeaderSize {
+		return Format{}, nil, fmt.Errorf("%w: header truncated, got %d bytes", ErrInvalidEnvelope, len(data))
+	}
+	if v := data[2]; v != Version {
+		return Format{}, nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidEnvelope, v)
+	}
+	f := Format{Codec: CodecID(data[3]), Compression: CompressionID(data[4])}
+	return f, data[HeaderSize:], nil
+}
@@ -0,0 +1,186 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/codec"
+	"github.com/yikakia/cachalot/core/compress"
+	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/telemetry"
+	"github.com/yikakia/cachalot/stores/storetests"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	f := Format{Codec: CodecJSON, Compression: CompressionGzip}
+	data := Encode(f, []byte("payload"))
+	require.True(t, IsEnveloped(data))
+	require.Len(t, data, HeaderSize+len("payload"))
+
+	got, payload, err := Decode(data)
+	require.NoError(t, err)
+	require.Equal(t, f, got)
+	require.Equal(t, []byte("payload"), payload)
+	require.Equal(t, "json+gzip", got.String())
+	require.Equal(t, "codec(200)", CodecID(200).String())
+
+	_, _, err = Decode([]byte(`{"a":1}`))
+	require.ErrorIs(t, err, ErrNotEnveloped)
+
+	_, _, err = Decode(data[:3])
+	require.ErrorIs(t, err, ErrInvalidEnvelope)
+
+	future := Encode(f, nil)
+	future[2] = Version + 1
+	_, _, err = Decode(future)
+	require.ErrorIs(t, err, ErrInvalidEnvelope)
+}
+
+// newBytesCache 使用固定时钟，GetWithTTL 返回写入时的 TTL
+func newBytesCache() (cache.Cache[[]byte], *storetests.MemoryStore) {
+	now := time.Now()
+	s := storetests.NewMemoryStore(storetests.WithClock(func() time.Time { return now }))
+	return cache.NewBaseCache[[]byte](s), s
+}
+
+type user struct {
+	Name string
+	Age  int
+}
+
+func TestDecoratorRollingMigration(t *testing.T) {
+	ctx := context.Background()
+	next, store := newBytesCache()
+
+	// 旧版本：未使用信封，JSON + gzip
+	legacyWriter := &decorator.CodecDecorator[user]{
+		Cache: decorator.NewCompressionDecorator(next, compress.GzipCompression{}),
+		Codec: codec.JSONCodec{},
+	}
+	require.NoError(t, legacyWriter.Set(ctx, "old", user{"legacy", 1}, time.Minute))
+
+	// 第一步：开启信封，写入格式不变，旧数据按 Legacy 解码
+	legacy := Format{Codec: CodecJSON, Compression: CompressionGzip}
+	v1, err := NewDecorator[user](next, Config{Write: legacy, Legacy: &legacy}, nil)
+	require.NoError(t, err)
+	require.NoError(t, v1.Set(ctx, "v1", user{"v1", 2}, time.Minute))
+	raw, _ := store.Raw("v1")
+	require.True(t, IsEnveloped(raw.([]byte)))
+
+	// 第二步：切换到 gob + zlib，新旧数据都可读
+	v2, err := NewDecorator[user](next, Config{
+		Write:  Format{Codec: CodecGob, Compression: CompressionZlib},
+		Legacy: &legacy,
+	}, nil)
+	require.NoError(t, err)
+	require.NoError(t, v2.Set(ctx, "v2", user{"v2", 3}, time.Minute))
+
+	for key, want := range map[string]struct {
+		user  user
+		field string
+	}{
+		"old": {user{"legacy", 1}, "legacy"},
+		"v1":  {user{"v1", 2}, "outdated"},
+		"v2":  {user{"v2", 3}, ""},
+	} {
+		evt := &telemetry.Event{}
+		got, ttl, err := v2.GetWithTTL(telemetry.ContextWithEvent(ctx, evt), key)
+		require.NoError(t, err, key)
+		require.Equal(t, want.user, got, key)
+		require.Equal(t, time.Minute, ttl, key)
+		require.Equal(t, want.field, evt.FrozenCustomFields()["envelope"], key)
+	}
+
+	// 旧版本的读取方仍然能读到第一步写入的数据
+	got, err := v1.Get(ctx, "v2")
+	require.NoError(t, err)
+	require.Equal(t, user{"v2", 3}, got)
+
+	// 不配置 Legacy 时，没有信封头的数据无法解码
+	strict, err := NewDecorator[user](next, Config{Write: legacy}, nil)
+	require.NoError(t, err)
+	_, err = strict.Get(ctx, "old")
+	require.ErrorIs(t, err, ErrNotEnveloped)
+}
+
+func TestDecoratorRegistry(t *testing.T) {
+	ctx := context.Background()
+	next, store := newBytesCache()
+
+	custom := CodecUserDefined + 1
+	_, err := NewDecorator[user](next, Config{Write: Format{Codec: custom}}, nil)
+	require.ErrorIs(t, err, ErrUnknownFormat)
+
+	registry := NewRegistry()
+	registry.RegisterCodec(custom, codec.JSONCodec{})
+	c, err := NewDecorator[user](next, Config{Registry: registry, Write: Format{Codec: custom}}, nil)
+	require.NoError(t, err)
+	require.NoError(t, c.Set(ctx, "k", user{"custom", 1}, time.Minute))
+
+	// 读取方没有注册该格式
+	other, err := NewDecorator[user](next, Config{Write: Format{Codec: CodecJSON}}, nil)
+	require.NoError(t, err)
+	_, err = other.Get(ctx, "k")
+	require.ErrorIs(t, err, ErrUnknownFormat)
+
+	// raw 仅支持 []byte
+	_, err = NewDecorator[user](next, Config{Write: Format{Codec: CodecRaw}}, nil)
+	require.Error(t, err)
+	raw, err := NewDecorator[[]byte](next, Config{Write: Format{Codec: CodecRaw, Compression: CompressionFlate}}, nil)
+	require.NoError(t, err)
+	require.NoError(t, raw.Set(ctx, "raw", []byte("bytes"), time.Minute))
+	stored, _ := store.Raw("raw")
+	require.Equal(t, byte(CompressionFlate), stored.([]byte)[4])
+	got, err := raw.Get(ctx, "raw")
+	require.NoError(t, err)
+	require.Equal(t, []byte("bytes"), got)
+}
+
+// aliasCodec 解码结果直接引用输入，没有实现 codec.NoRetain
+type aliasCodec struct{ rawCodec }
+
+func TestDecoratorRetainingCodec(t *testing.T) {
+	ctx := context.Background()
+	next, _ := newBytesCache()
+
+	registry := NewRegistry()
+	registry.RegisterCodec(CodecUserDefined, aliasCodec{})
+	c, err := NewDecorator[[]byte](next, Config{
+		Registry: registry,
+		Write:    Format{Codec: CodecUserDefined, Compression: CompressionGzip},
+	}, nil)
+	require.NoError(t, err)
+
+	var got [][]byte
+	for _, key := range []string{"a", "b", "c"} {
+		require.NoError(t, c.Set(ctx, key, bytes.Repeat([]byte(key), 100), time.Minute))
+		v, err := c.Get(ctx, key)
+		require.NoError(t, err)
+		got = append(got, v)
+	}
+	// 解压结果不能来自缓冲池，否则之后的读取会改写之前返回的值
+	for i, key := range []string{"a", "b", "c"} {
+		require.Equal(t, bytes.Repeat([]byte(key), 100), got[i])
+	}
+}
+
+func TestDecoratorPayloadBytes(t *testing.T) {
+	ctx := context.Background()
+	next, _ := newBytesCache()
+	d, err := NewDecorator[user](next, Config{Write: Format{Codec: CodecJSON, Compression: CompressionGzip}}, nil)
+	require.NoError(t, err)
+
+	setEvt := &telemetry.Event{}
+	require.NoError(t, d.Set(telemetry.ContextWithEvent(ctx, setEvt), "k", user{"whale", 1}, time.Minute))
+	getEvt := &telemetry.Event{}
+	_, err = d.Get(telemetry.ContextWithEvent(ctx, getEvt), "k")
+	require.NoError(t, err)
+
+	// 读写两个方向都不计入信封头部
+	require.NotZero(t, setEvt.Details().CompressedBytes)
+	require.Equal(t, setEvt.Details(), getEvt.Details())
+}
@@ -0,0 +1,104 @@
+package envelope
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/yikakia/cachalot/core/codec"
+	"github.com/yikakia/cachalot/core/compress"
+	"github.com/yikakia/cachalot/core/decorator"
+)
+
+// Registry 编解码与压缩方式的注册表，读取时按信封中的标识查找实现
+//
+// 同一个标识在所有实例上必须对应可互相解码的实现
+type Registry struct {
+	mu           sync.RWMutex
+	codecs       map[CodecID]codec.Codec
+	compressions map[CompressionID]decorator.CompressionCodec
+}
+
+// NewRegistry 创建注册表，已注册 core 内置的编解码（raw/json/gob）与压缩（none/gzip/zlib/flate/lzw）
+func NewRegistry() *Registry {
+	r := &Registry{
+		codecs: map[CodecID]codec.Codec{
+			CodecRaw:  rawCodec{},
+			CodecJSON: codec.JSONCodec{},
+			CodecGob:  codec.GobCodec{},
+		},
+		compressions: map[CompressionID]decorator.CompressionCodec{
+			CompressionNone:  noCompression{},
+			CompressionGzip:  compress.GzipCompression{},
+			CompressionZlib:  compress.ZlibCompression{},
+			CompressionFlate: compress.FlateCompression{},
+			CompressionLZW:   compress.LZWCompression{},
+		},
+	}
+	return r
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default 全局默认注册表，Config.Registry 为空时使用
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// RegisterCodec 注册编解码实现，已存在时覆盖，可用于替换同一格式的实现（如调整参数）
+func (r *Registry) RegisterCodec(id CodecID, c codec.Codec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.codecs[id] = c
+}
+
+// RegisterCompression 注册压缩实现，已存在时覆盖，可用于替换同一格式的实现（如调整压缩等级）
+func (r *Registry) RegisterCompression(id CompressionID, c decorator.CompressionCodec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.compressions[id] = c
+}
+
+func (r *Registry) Codec(id CodecID) (codec.Codec, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.codecs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: codec %s not registered", ErrUnknownFormat, id)
+	}
+	return c, nil
+}
+
+func (r *Registry) Compression(id CompressionID) (decorator.CompressionCodec, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.compressions[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: compression %s not registered", ErrUnknownFormat, id)
+	}
+	return c, nil
+}
+
+// rawCodec 值本身就是 []byte
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	b, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("raw codec requires []byte, but got %T", v)
+	}
+	return b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	p, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("raw codec requires *[]byte, but got %T", v)
+	}
+	*p = data
+	return nil
+}
+
+type noCompression struct{}
+
+func (noCompression) Compress(src []byte) ([]byte, error)   { return src, nil }
+func (noCompression) Decompress(src []byte) ([]byte, error) { return src, nil }
@@ -0,0 +1,263 @@
+package hotkey
+
+import (
+	"hash/fnv"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Config 热点 key 探测配置
+type Config struct {
+	// 滑动窗口大小，内部拆分为两个半窗口轮换 默认 10s
+	Window time.Duration
+	// 记录访问次数最高的 K 个 key 默认 16
+	TopK int
+	// 窗口内访问次数达到 MinCount 且位于 TopK 中才认为是热点 默认 100
+	MinCount uint64
+	// count-min sketch 的宽度与深度 默认 2048 * 4
+	Width int
+	Depth int
+}
+
+// KeyCount 热点 key 与其在窗口内的估算访问次数
+type KeyCount struct {
+	Key   string `json:"key"`
+	Count uint64 `json:"count"`
+}
+
+// Observation 一次访问的观测结果
+type Observation struct {
+	// 当前 key 是否为热点
+	Hot bool
+	// 本次观测触发了窗口轮换，调用方可以借此上报 TopK 并清理不再热的 key
+	Rotated bool
+}
+
+// Detector 基于 count-min sketch 的滑动窗口热点探测器
+//
+// 窗口被拆分为两个半窗口，估算值 = 当前半窗口 + 上一个半窗口，每半个窗口轮换一次，因此流量迁移后热点集合会随之变化。
+// 一个 Detector 只应该用于一个缓存实例。
+type Detector struct {
+	cfg  Config
+	now  func() time.Time
+	half time.Duration
+
+	mu         sync.Mutex
+	current    *countMinSketch
+	previous   *countMinSketch
+	rotateAt   time.Time
+	candidates map[string]uint64
+	// candidates 已满时计数的下界，同一个半窗口内候选的计数只增不减，
+	// 计数不超过下界的 key 可以直接跳过，冷 key 不需要遍历候选集合
+	floor uint64
+}
+
+func NewDetector(cfg Config) *Detector {
+	if cfg.Window <= 0 {
+		cfg.Window = 10 * time.Second
+	}
+	if cfg.TopK <= 0 {
+		cfg.TopK = 16
+	}
+	if cfg.MinCount == 0 {
+		cfg.MinCount = 100
+	}
+	if cfg.Width <= 0 {
+		cfg.Width = 2048
+	}
+	if cfg.Depth <= 0 {
+		cfg.Depth = 4
+	}
+	d := &Detector{
+		cfg:        cfg,
+		now:        time.Now,
+		half:       cfg.Window / 2,
+		current:    newCountMinSketch(cfg.Width, cfg.Depth),
+		previous:   newCountMinSketch(cfg.Width, cfg.Depth),
+		candidates: make(map[string]uint64, cfg.TopK),
+	}
+	d.rotateAt = d.now().Add(d.half)
+	return d
+}
+
+// Observe 记录一次访问
+func (d *Detector) Observe(key string) Observation {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	rotated := d.rotateIfNeeded()
+	count := d.current.add(key) + d.previous.estimate(key)
+	d.track(key, count)
+
+	_, inTop := d.candidates[key]
+	return Observation{
+		Hot:     inTop && count >= d.cfg.MinCount,
+		Rotated: rotated,
+	}
+}
+
+// IsHot 判断 key 当前是否为热点
+//
+// 读取方法不会触发窗口轮换，轮换只发生在 Observe 中，否则调用方会错过 Observation.Rotated。
+// 读取到的是最近一次轮换后维护的热点集合，超过一个完整窗口没有访问时视为全部过期。
+func (d *Detector) IsHot(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.expired() {
+		return false
+	}
+	count, ok := d.candidates[key]
+	return ok && count >= d.cfg.MinCount
+}
+
+// TopK 返回当前热点 key，按访问次数从高到低排序，与 IsHot 一样不会触发窗口轮换
+func (d *Detector) TopK() []KeyCount {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.expired() {
+		return nil
+	}
+
+	ret := make([]KeyCount, 0, len(d.candidates))
+	for k, c := range d.candidates {
+		if c >= d.cfg.MinCount {
+			ret = append(ret, KeyCount{Key: k, Count: c})
+		}
+	}
+	slices.SortFunc(ret, func(a, b KeyCount) int {
+		if a.Count != b.Count {
+			if a.Count > b.Count {
+				return -1
+			}
+			return 1
+		}
+		if a.Key < b.Key {
+			return -1
+		}
+		if a.Key > b.Key {
+			return 1
+		}
+		return 0
+	})
+	return ret
+}
+
+func (d *Detector) track(key string, count uint64) {
+	if _, ok := d.candidates[key]; ok {
+		d.candidates[key] = count
+		return
+	}
+	if len(d.candidates) < d.cfg.TopK {
+		if len(d.candidates) == 0 || count < d.floor {
+			d.floor = count
+		}
+		d.candidates[key] = count
+		return
+	}
+	if count <= d.floor {
+		return
+	}
+	minKey, minCount := d.minCandidate()
+	// 替换后新的最小值不会低于 minCount，依旧是合法的下界
+	d.floor = minCount
+	if count > minCount {
+		delete(d.candidates, minKey)
+		d.candidates[key] = count
+	}
+}
+
+func (d *Detector) minCandidate() (string, uint64) {
+	minKey, minCount := "", uint64(0)
+	first := true
+	for k, c := range d.candidates {
+		if first || c < minCount {
+			minKey, minCount, first = k, c, false
+		}
+	}
+	return minKey, minCount
+}
+
+// expired 超过一个完整窗口没有 Observe，热点集合已全部过期
+func (d *Detector) expired() bool {
+	return d.now().Sub(d.rotateAt) >= d.half
+}
+
+func (d *Detector) rotateIfNeeded() bool {
+	now := d.now()
+	if now.Before(d.rotateAt) {
+		return false
+	}
+	if now.Sub(d.rotateAt) >= d.half {
+		// 超过一个完整窗口没有访问，全部过期
+		d.previous.reset()
+	} else {
+		d.previous, d.current = d.current, d.previous
+	}
+	d.current.reset()
+	d.rotateAt = now.Add(d.half)
+
+	for k := range d.candidates {
+		c := d.previous.estimate(k)
+		if c == 0 {
+			delete(d.candidates, k)
+			continue
+		}
+		d.candidates[k] = c
+	}
+	_, d.floor = d.minCandidate()
+	return true
+}
+
+type countMinSketch struct {
+	width int
+	rows  [][]uint64
+}
+
+func newCountMinSketch(width, depth int) *countMinSketch {
+	rows := make([][]uint64, depth)
+	for i := range rows {
+		rows[i] = make([]uint64, width)
+	}
+	return &countMinSketch{width: width, rows: rows}
+}
+
+// add 计数加一并返回估算值
+func (s *countMinSketch) add(key string) uint64 {
+	a, b := hashKey(key)
+	var est uint64
+	for i, row := range s.rows {
+		idx := (a + uint64(i)*b) % uint64(s.width)
+		row[idx]++
+		if i == 0 || row[idx] < est {
+			est = row[idx]
+		}
+	}
+	return est
+}
+
+func (s *countMinSketch) estimate(key string) uint64 {
+	a, b := hashKey(key)
+	var est uint64
+	for i, row := range s.rows {
+		v := row[(a+uint64(i)*b)%uint64(s.width)]
+		if i == 0 || v < est {
+			est = v
+		}
+	}
+	return est
+}
+
+func (s *countMinSketch) reset() {
+	for _, row := range s.rows {
+		clear(row)
+	}
+}
+
+func hashKey(key string) (uint64, uint64) {
+	h1 := fnv.New64a()
+	_, _ = h1.Write([]byte(key))
+	h2 := fnv.New64()
+	_, _ = h2.Write([]byte(key))
+	return h1.Sum64(), h2.Sum64() | 1
+}
@@ -0,0 +1,154 @@
+package hotkey
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot/core/telemetry"
+)
+
+func newTestDetector(now *time.Time) *Detector {
+	d := NewDetector(Config{Window: 10 * time.Second, TopK: 2, MinCount: 5})
+	d.now = func() time.Time { return *now }
+	d.rotateAt = now.Add(d.half)
+	return d
+}
+
+func TestDetectorTopK(t *testing.T) {
+	now := time.Now()
+	d := newTestDetector(&now)
+
+	for i := 0; i < 10; i++ {
+		d.Observe("hot")
+	}
+	for i := 0; i < 6; i++ {
+		d.Observe("warm")
+	}
+	for i := 0; i < 20; i++ {
+		d.Observe(fmt.Sprintf("cold-%d", i))
+	}
+
+	require.Equal(t, []KeyCount{{Key: "hot", Count: 10}, {Key: "warm", Count: 6}}, d.TopK())
+	require.True(t, d.IsHot("hot"))
+	require.False(t, d.IsHot("cold-1"))
+}
+
+func TestDetectorColdKeysSkipCandidates(t *testing.T) {
+	now := time.Now()
+	d := newTestDetector(&now)
+
+	for i := 0; i < 3; i++ {
+		d.Observe("a")
+		d.Observe("b")
+	}
+	require.Equal(t, uint64(1), d.floor)
+
+	// 计数不超过下界的冷 key 直接跳过
+	d.Observe("cold")
+	require.Equal(t, uint64(1), d.floor)
+
+	// 超过下界时才遍历候选，并把下界提升到当前最小值
+	d.Observe("d")
+	d.Observe("d")
+	require.Equal(t, uint64(3), d.floor)
+	require.NotContains(t, d.candidates, "d")
+
+	d.Observe("d")
+	d.Observe("d")
+	require.Contains(t, d.candidates, "d")
+	require.Len(t, d.candidates, 2)
+}
+
+func TestDetectorSlidingWindow(t *testing.T) {
+	now := time.Now()
+	d := newTestDetector(&now)
+
+	for i := 0; i < 10; i++ {
+		d.Observe("a")
+	}
+	require.True(t, d.IsHot("a"))
+
+	// 半个窗口后依旧在窗口内
+	now = now.Add(6 * time.Second)
+	obs := d.Observe("b")
+	require.True(t, obs.Rotated)
+	require.True(t, d.IsHot("a"))
+
+	// 流量迁移到 b
+	for i := 0; i < 10; i++ {
+		d.Observe("b")
+	}
+	now = now.Add(6 * time.Second)
+	require.True(t, d.Observe("b").Rotated)
+	require.False(t, d.IsHot("a"))
+	require.True(t, d.IsHot("b"))
+
+	// 长时间没有访问，全部过期
+	now = now.Add(time.Minute)
+	require.Empty(t, d.TopK())
+}
+
+type topKMetrics struct {
+	telemetry.Metrics
+	reported [][]KeyCount
+}
+
+func (m *topKMetrics) RecordTopK(_ context.Context, _ string, keys []KeyCount) {
+	m.reported = append(m.reported, keys)
+}
+
+func TestPromoterDemotesColdKeys(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	d := newTestDetector(&now)
+	p := NewPromoter[string](d, time.Hour)
+	metrics := &topKMetrics{Metrics: telemetry.NoopMetrics()}
+	ob := &telemetry.Observable{Metrics: metrics, Logger: telemetry.SlogLogger()}
+
+	for i := 0; i < 10; i++ {
+		p.Observe(ctx, "a", ob, "cache")
+	}
+	p.Promote("a", "va", p.Version("a"))
+	v, _, ok := p.Lookup("a")
+	require.True(t, ok)
+	require.Equal(t, "va", v)
+
+	// 两个半窗口后 a 不再是热点，轮换时被清理
+	// 读取热点不会抢先轮换窗口，轮换仍由 Observe 上报并清理
+	now = now.Add(6 * time.Second)
+	require.NotEmpty(t, d.TopK())
+	p.Observe(ctx, "b", ob, "cache")
+	now = now.Add(6 * time.Second)
+	require.False(t, d.IsHot("b"))
+	p.Observe(ctx, "b", ob, "cache")
+
+	_, _, ok = p.Lookup("a")
+	require.False(t, ok)
+	require.Len(t, metrics.reported, 2)
+}
+
+func TestPromoterSkipsInvalidatedVersion(t *testing.T) {
+	now := time.Now()
+	p := NewPromoter[string](newTestDetector(&now), time.Hour)
+
+	// 读取下游期间 key 被写入，读到的旧值不会被提升
+	version := p.Version("a")
+	p.Invalidate("a")
+	p.Promote("a", "old", version)
+	_, _, ok := p.Lookup("a")
+	require.False(t, ok)
+
+	version = p.Version("a")
+	p.Clear()
+	p.Promote("a", "old", version)
+	_, _, ok = p.Lookup("a")
+	require.False(t, ok)
+
+	p.Promote("a", "new", p.Version("a"))
+	v, _, ok := p.Lookup("a")
+	require.True(t, ok)
+	require.Equal(t, "new", v)
+}
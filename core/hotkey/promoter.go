@@ -0,0 +1,133 @@
+package hotkey
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yikakia/cachalot/core/telemetry"
+	"github.com/yikakia/cachalot/internal/lru"
+)
+
+// TopKMetrics 如果 telemetry.Metrics 实现了该接口，则会在每次窗口轮换时上报当前热点 key
+type TopKMetrics interface {
+	RecordTopK(ctx context.Context, cacheName string, keys []KeyCount)
+}
+
+// Promoter 在 Detector 的基础上，将热点 key 提升到进程内的小容量本地缓存
+//
+// 本地缓存容量为 Config.TopK，条目存活 ttl；窗口轮换时会清理不再是热点的 key。
+// ttl <= 0 时只做探测，不做本地提升。
+//
+// 读取下游前通过 Version 获取 key 的版本，Promote 时版本发生变化说明期间有写入或删除，读到的值可能已经过期，不会提升。
+// 版本按 key 的哈希分片记录，不同 key 偶尔共享分片只会让提升推迟到下一次读取。
+type Promoter[T any] struct {
+	detector *Detector
+	ttl      time.Duration
+	local    *lru.Cache[T]
+
+	// mu 保证版本检查与写入本地缓存的原子性
+	mu       sync.Mutex
+	versions [promoterVersionShards]atomic.Uint64
+}
+
+const promoterVersionShards = 64
+
+func NewPromoter[T any](detector *Detector, ttl time.Duration) *Promoter[T] {
+	p := &Promoter[T]{
+		detector: detector,
+		ttl:      ttl,
+	}
+	if ttl > 0 {
+		p.local = lru.New[T](detector.cfg.TopK)
+	}
+	return p
+}
+
+func (p *Promoter[T]) Detector() *Detector {
+	return p.detector
+}
+
+// Lookup 查询本地缓存
+func (p *Promoter[T]) Lookup(key string) (T, time.Duration, bool) {
+	var zero T
+	if p.local == nil {
+		return zero, 0, false
+	}
+	return p.local.Get(key)
+}
+
+// Observe 记录一次访问，窗口轮换时清理不再是热点的本地条目并上报 TopK
+func (p *Promoter[T]) Observe(ctx context.Context, key string, ob *telemetry.Observable, cacheName string) Observation {
+	obs := p.detector.Observe(key)
+	if !obs.Rotated {
+		return obs
+	}
+	top := p.detector.TopK()
+	if p.local != nil {
+		hot := make(map[string]struct{}, len(top))
+		for _, kc := range top {
+			hot[kc.Key] = struct{}{}
+		}
+		for _, k := range p.local.Keys() {
+			if _, ok := hot[k]; !ok {
+				p.local.Delete(k)
+			}
+		}
+	}
+	if ob != nil {
+		for _, m := range telemetry.MetricsAs[TopKMetrics](ob.Metrics) {
+			m.RecordTopK(ctx, cacheName, top)
+		}
+	}
+	return obs
+}
+
+// Version 返回 key 当前的版本，需要在读取下游之前获取，并传给 Promote
+func (p *Promoter[T]) Version(key string) uint64 {
+	return p.versions[versionShard(key)].Load()
+}
+
+// Promote 将热点 key 的值写入本地缓存，version 之后有过 Invalidate 或 Clear 时放弃
+func (p *Promoter[T]) Promote(key string, val T, version uint64) {
+	if p.local == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.versions[versionShard(key)].Load() != version {
+		return
+	}
+	p.local.Set(key, val, p.ttl)
+}
+
+// Invalidate 使本地缓存失效，并让进行中的读取放弃提升
+//
+// 写入下游前后都需要调用：之前调用清理旧值，之后调用让写入期间读到旧值的请求放弃提升
+func (p *Promoter[T]) Invalidate(key string) {
+	if p.local == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.versions[versionShard(key)].Add(1)
+	p.local.Delete(key)
+}
+
+func (p *Promoter[T]) Clear() {
+	if p.local == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range p.versions {
+		p.versions[i].Add(1)
+	}
+	p.local.Clear()
+}
+
+func versionShard(key string) uint64 {
+	a, _ := hashKey(key)
+	return a % promoterVersionShards
+}
@@ -0,0 +1,103 @@
+package interceptor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/telemetry"
+)
+
+// NewDecorator 将拦截链作用于 Cache 的全部操作
+func NewDecorator[T any](c cache.Cache[T], cacheName string, interceptors ...Interceptor) cache.Cache[T] {
+	return &Decorator[T]{
+		cache:     c,
+		cacheName: cacheName,
+		chain:     Chain(interceptors...),
+	}
+}
+
+type Decorator[T any] struct {
+	cache     cache.Cache[T]
+	cacheName string
+	chain     Interceptor
+}
+
+var _ cache.Cache[any] = (*Decorator[any])(nil)
+
+func (d *Decorator[T]) Get(ctx context.Context, key string, opts ...cache.CallOption) (T, error) {
+	info := &OpInfo{Op: telemetry.OpGet, CacheName: d.cacheName, Key: key, Options: opts}
+	err := d.chain(ctx, info, func(ctx context.Context, info *OpInfo) error {
+		val, err := d.cache.Get(ctx, info.Key, info.Options...)
+		if err != nil {
+			return err
+		}
+		info.Value = val
+		return nil
+	})
+	return valueOf[T](info, err)
+}
+
+func (d *Decorator[T]) Set(ctx context.Context, key string, val T, ttl time.Duration, opts ...cache.CallOption) error {
+	info := &OpInfo{Op: telemetry.OpSet, CacheName: d.cacheName, Key: key, TTL: ttl, Options: opts, Value: val}
+	return d.chain(ctx, info, func(ctx context.Context, info *OpInfo) error {
+		v, err := ValueAs[T](info)
+		if err != nil {
+			return err
+		}
+		return d.cache.Set(ctx, info.Key, v, info.TTL, info.Options...)
+	})
+}
+
+func (d *Decorator[T]) GetWithTTL(ctx context.Context, key string, opts ...cache.CallOption) (T, time.Duration, error) {
+	info := &OpInfo{Op: telemetry.OpGetWithTTL, CacheName: d.cacheName, Key: key, Options: opts}
+	err := d.chain(ctx, info, func(ctx context.Context, info *OpInfo) error {
+		val, ttl, err := d.cache.GetWithTTL(ctx, info.Key, info.Options...)
+		if err != nil {
+			return err
+		}
+		info.Value, info.TTL = val, ttl
+		return nil
+	})
+	val, err := valueOf[T](info, err)
+	if err != nil {
+		return val, 0, err
+	}
+	return val, info.TTL, nil
+}
+
+func (d *Decorator[T]) Delete(ctx context.Context, key string, opts ...cache.CallOption) error {
+	info := &OpInfo{Op: telemetry.OpDelete, CacheName: d.cacheName, Key: key, Options: opts}
+	return d.chain(ctx, info, func(ctx context.Context, info *OpInfo) error {
+		return d.cache.Delete(ctx, info.Key, info.Options...)
+	})
+}
+
+func (d *Decorator[T]) Clear(ctx context.Context) error {
+	info := &OpInfo{Op: telemetry.OpClear, CacheName: d.cacheName}
+	return d.chain(ctx, info, func(ctx context.Context, info *OpInfo) error {
+		return d.cache.Clear(ctx)
+	})
+}
+
+// ValueAs 将 OpInfo.Value 转换为缓存的值类型，拦截器替换了不匹配的值时返回 cache.ErrTypeMismatch
+func ValueAs[T any](info *OpInfo) (T, error) {
+	var zero T
+	if info.Value == nil {
+		return zero, nil
+	}
+	v, ok := info.Value.(T)
+	if !ok {
+		return zero, fmt.Errorf("interceptor value %T: %w", info.Value, cache.ErrTypeMismatch)
+	}
+	return v, nil
+}
+
+func valueOf[T any](info *OpInfo, err error) (T, error) {
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return ValueAs[T](info)
+}
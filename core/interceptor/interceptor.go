@@ -0,0 +1,56 @@
+// Package interceptor 提供统一的操作拦截链，横切逻辑只需实现一个函数即可同时作用于 Cache 与 MultiCache
+package interceptor
+
+import (
+	"context"
+	"time"
+
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/telemetry"
+)
+
+// OpInfo 一次缓存操作的上下文
+//
+// 调用 next 之前可以修改 Key、TTL、Options、Value 来改变实际的调用参数，
+// next 返回后 Value 与 TTL 会被填充为操作结果
+type OpInfo struct {
+	Op        telemetry.Op
+	CacheName string
+	// Clear 时为空
+	Key string
+	// Set 时为写入的 ttl，GetWithTTL 返回后为剩余的 ttl
+	TTL     time.Duration
+	Options []cache.CallOption
+	// Set 时为写入的值，Get/GetWithTTL/FetchByLoader 成功返回后为读到的值
+	Value any
+}
+
+// Invoker 执行拦截链中的下一环
+type Invoker func(ctx context.Context, info *OpInfo) error
+
+// Interceptor 拦截一次缓存操作，必须调用 next 才会继续执行，返回的 error 即为操作的 error
+type Interceptor func(ctx context.Context, info *OpInfo, next Invoker) error
+
+// Chain 将多个拦截器按声明顺序串联，第一个位于最外层
+func Chain(interceptors ...Interceptor) Interceptor {
+	switch len(interceptors) {
+	case 0:
+		return func(ctx context.Context, info *OpInfo, next Invoker) error {
+			return next(ctx, info)
+		}
+	case 1:
+		return interceptors[0]
+	}
+	return func(ctx context.Context, info *OpInfo, next Invoker) error {
+		return interceptors[0](ctx, info, chainFrom(interceptors, 1, next))
+	}
+}
+
+func chainFrom(interceptors []Interceptor, i int, final Invoker) Invoker {
+	if i == len(interceptors) {
+		return final
+	}
+	return func(ctx context.Context, info *OpInfo) error {
+		return interceptors[i](ctx, info, chainFrom(interceptors, i+1, final))
+	}
+}
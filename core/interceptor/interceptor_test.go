@@ -0,0 +1,130 @@
+package interceptor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/telemetry"
+)
+
+type mapCache struct {
+	data map[string]string
+	ttls map[string]time.Duration
+}
+
+func newMapCache() *mapCache {
+	return &mapCache{data: map[string]string{}, ttls: map[string]time.Duration{}}
+}
+
+func (m *mapCache) Get(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+	v, _, err := m.GetWithTTL(ctx, key, opts...)
+	return v, err
+}
+
+func (m *mapCache) Set(_ context.Context, key string, val string, ttl time.Duration, _ ...cache.CallOption) error {
+	m.data[key] = val
+	m.ttls[key] = ttl
+	return nil
+}
+
+func (m *mapCache) GetWithTTL(_ context.Context, key string, _ ...cache.CallOption) (string, time.Duration, error) {
+	v, ok := m.data[key]
+	if !ok {
+		return "", 0, fmt.Errorf("key:%s %w", key, cache.ErrNotFound)
+	}
+	return v, m.ttls[key], nil
+}
+
+func (m *mapCache) Delete(_ context.Context, key string, _ ...cache.CallOption) error {
+	delete(m.data, key)
+	return nil
+}
+
+func (m *mapCache) Clear(_ context.Context) error {
+	m.data = map[string]string{}
+	return nil
+}
+
+func TestChainOrder(t *testing.T) {
+	var trace []string
+	record := func(name string) Interceptor {
+		return func(ctx context.Context, info *OpInfo, next Invoker) error {
+			trace = append(trace, name+">"+string(info.Op))
+			err := next(ctx, info)
+			trace = append(trace, name+"<")
+			return err
+		}
+	}
+
+	c := NewDecorator[string](newMapCache(), "c", record("a"), record("b"))
+	require.NoError(t, c.Set(context.Background(), "k", "v", time.Minute))
+	require.Equal(t, []string{"a>set", "b>set", "b<", "a<"}, trace)
+}
+
+func TestInterceptorSeesArgumentsAndResult(t *testing.T) {
+	ctx := context.Background()
+	inner := newMapCache()
+	var seen []OpInfo
+	c := NewDecorator[string](inner, "users", func(ctx context.Context, info *OpInfo, next Invoker) error {
+		err := next(ctx, info)
+		seen = append(seen, *info)
+		return err
+	})
+
+	require.NoError(t, c.Set(ctx, "k", "v", time.Minute))
+	val, ttl, err := c.GetWithTTL(ctx, "k")
+	require.NoError(t, err)
+	require.Equal(t, "v", val)
+	require.Equal(t, time.Minute, ttl)
+	_, err = c.Get(ctx, "absent")
+	require.ErrorIs(t, err, cache.ErrNotFound)
+	require.NoError(t, c.Clear(ctx))
+
+	require.Len(t, seen, 4)
+	require.Equal(t, OpInfo{Op: telemetry.OpSet, CacheName: "users", Key: "k", TTL: time.Minute, Value: "v"}, seen[0])
+	require.Equal(t, OpInfo{Op: telemetry.OpGetWithTTL, CacheName: "users", Key: "k", TTL: time.Minute, Value: "v"}, seen[1])
+	require.Nil(t, seen[2].Value)
+	require.Equal(t, telemetry.OpClear, seen[3].Op)
+}
+
+func TestInterceptorRewritesArguments(t *testing.T) {
+	ctx := context.Background()
+	inner := newMapCache()
+	prefix := func(ctx context.Context, info *OpInfo, next Invoker) error {
+		info.Key = "tenant:" + info.Key
+		return next(ctx, info)
+	}
+	c := NewDecorator[string](inner, "c", prefix)
+
+	require.NoError(t, c.Set(ctx, "k", "v", time.Minute))
+	require.Equal(t, "v", inner.data["tenant:k"])
+	got, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	require.Equal(t, "v", got)
+
+	wrongType := NewDecorator[string](inner, "c", func(ctx context.Context, info *OpInfo, next Invoker) error {
+		info.Value = 42
+		return next(ctx, info)
+	})
+	require.ErrorIs(t, wrongType.Set(ctx, "k", "v", time.Minute), cache.ErrTypeMismatch)
+}
+
+func TestInterceptorShortCircuit(t *testing.T) {
+	ctx := context.Background()
+	inner := newMapCache()
+	require.NoError(t, inner.Set(ctx, "k", "v", 0))
+
+	deny := fmt.Errorf("denied")
+	c := NewDecorator[string](inner, "c", func(ctx context.Context, info *OpInfo, next Invoker) error {
+		if info.Op == telemetry.OpDelete {
+			return deny
+		}
+		return next(ctx, info)
+	})
+	require.ErrorIs(t, c.Delete(ctx, "k"), deny)
+	require.Contains(t, inner.data, "k")
+}
@@ -0,0 +1,155 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/telemetry"
+)
+
+// LoggingConfig 访问日志配置
+//
+// 级别为 telemetry.LevelDefault（零值）时使用 DefaultLoggingConfig 中的默认级别
+type LoggingConfig struct {
+	// 通过 Builder 配置时忽略该字段，使用 WithLogger 传入的 Logger
+	Logger telemetry.Logger
+	// 日志中记录的 key，返回 false 时不输出 key 字段，为空时原样输出；
+	// 通过 Builder 配置了 WithKeyRedactor 时使用同一个 redactor，与观测事件中的 key 保持一致
+	KeyRedactor telemetry.KeyRedactor
+	// 访问日志的级别，未配置的操作使用 DefaultLevel
+	Levels       map[telemetry.Op]telemetry.Level
+	DefaultLevel telemetry.Level
+	// 访问日志的采样比例 [0,1]，未配置的操作使用 DefaultSampleRatio，错误与慢操作日志不采样
+	SampleRatios       map[telemetry.Op]float64
+	DefaultSampleRatio float64
+	// 操作失败时的日志级别，cache.ErrNotFound 不视为失败
+	ErrorLevel telemetry.Level
+	// 大于 0 时耗时超过阈值的操作会输出慢日志，并附带当前事件的自定义字段
+	SlowThreshold time.Duration
+	SlowLevel     telemetry.Level
+	// 按 (操作, 级别, 结果) 限流，Builder 还会用同样的配置按消息限流核心装饰器的错误日志
+	Throttle telemetry.ThrottleConfig
+}
+
+// DefaultLoggingConfig 访问日志 Debug 全量，失败 Error，超过 100ms 的慢操作 Warn，每种操作与结果每秒最多 10 条
+func DefaultLoggingConfig() LoggingConfig {
+	return LoggingConfig{
+		DefaultLevel:       defaultAccessLevel,
+		DefaultSampleRatio: 1,
+		ErrorLevel:         defaultErrorLevel,
+		SlowThreshold:      100 * time.Millisecond,
+		SlowLevel:          defaultSlowLevel,
+		Throttle: telemetry.ThrottleConfig{
+			Interval: time.Second,
+			Burst:    10,
+		},
+	}
+}
+
+const (
+	defaultAccessLevel = telemetry.LevelDebug
+	defaultErrorLevel  = telemetry.LevelError
+	defaultSlowLevel   = telemetry.LevelWarn
+)
+
+const (
+	msgAccess = "[cachalot] cache op."
+	msgFailed = "[cachalot] cache op failed."
+	msgSlow   = "[cachalot] slow cache op."
+)
+
+// loggingKey 限流维度，不同操作与结果互不影响
+type loggingKey struct {
+	op    telemetry.Op
+	level telemetry.Level
+	msg   string
+}
+
+// Logging 按配置输出访问日志、失败日志与慢日志
+//
+// 先按级别与采样过滤，再按 (操作, 级别, 结果) 限流，被抑制的条数在下一次放行时以 suppressed 字段输出
+func Logging(cfg LoggingConfig) Interceptor {
+	throttle := telemetry.NewThrottle(cfg.Throttle)
+	return func(ctx context.Context, info *OpInfo, next Invoker) error {
+		start := time.Now()
+		err := next(ctx, info)
+		cost := time.Since(start)
+
+		var level telemetry.Level
+		var msg string
+		switch {
+		case err != nil && !errors.Is(err, cache.ErrNotFound):
+			level, msg = orDefault(cfg.ErrorLevel, defaultErrorLevel), msgFailed
+		case cfg.SlowThreshold > 0 && cost >= cfg.SlowThreshold:
+			level, msg = orDefault(cfg.SlowLevel, defaultSlowLevel), msgSlow
+		default:
+			if !sampled(cfg.sampleRatio(info.Op)) {
+				return err
+			}
+			level, msg = cfg.level(info.Op), msgAccess
+		}
+		if level == telemetry.LevelOff {
+			return err
+		}
+		suppressed, ok := throttle.Allow(loggingKey{op: info.Op, level: level, msg: msg})
+		if !ok {
+			return err
+		}
+
+		args := []any{"cache", info.CacheName, "op", info.Op}
+		if key, ok := cfg.redactKey(info.Key); ok {
+			args = append(args, "key", key)
+		}
+		args = append(args, "cost", cost)
+		if err != nil {
+			args = append(args, "err", err)
+		}
+		if msg == msgSlow {
+			if evt, ok := telemetry.EventFromContext(ctx); ok {
+				fields := evt.FrozenCustomFields()
+				for _, k := range slices.Sorted(maps.Keys(fields)) {
+					args = append(args, k, fields[k])
+				}
+			}
+		}
+		telemetry.LogAt(ctx, cfg.Logger, level, msg, telemetry.WithSuppressed(args, suppressed)...)
+		return err
+	}
+}
+
+func (c LoggingConfig) level(op telemetry.Op) telemetry.Level {
+	if l, ok := c.Levels[op]; ok && l != telemetry.LevelDefault {
+		return l
+	}
+	return orDefault(c.DefaultLevel, defaultAccessLevel)
+}
+
+func (c LoggingConfig) redactKey(key string) (string, bool) {
+	if c.KeyRedactor == nil {
+		return key, true
+	}
+	return c.KeyRedactor(key)
+}
+
+func orDefault(l, def telemetry.Level) telemetry.Level {
+	if l == telemetry.LevelDefault {
+		return def
+	}
+	return l
+}
+
+func (c LoggingConfig) sampleRatio(op telemetry.Op) float64 {
+	if r, ok := c.SampleRatios[op]; ok {
+		return r
+	}
+	return c.DefaultSampleRatio
+}
+
+func sampled(ratio float64) bool {
+	return ratio >= 1 || (ratio > 0 && rand.Float64() < ratio)
+}
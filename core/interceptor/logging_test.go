@@ -0,0 +1,155 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot/core/telemetry"
+)
+
+type logEntry struct {
+	level telemetry.Level
+	msg   string
+	args  []any
+}
+
+type captureLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (c *captureLogger) add(level telemetry.Level, msg string, args []any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, logEntry{level: level, msg: msg, args: args})
+}
+
+func (c *captureLogger) DebugContext(_ context.Context, msg string, args ...any) {
+	c.add(telemetry.LevelDebug, msg, args)
+}
+func (c *captureLogger) InfoContext(_ context.Context, msg string, args ...any) {
+	c.add(telemetry.LevelInfo, msg, args)
+}
+func (c *captureLogger) WarnContext(_ context.Context, msg string, args ...any) {
+	c.add(telemetry.LevelWarn, msg, args)
+}
+func (c *captureLogger) ErrorContext(_ context.Context, msg string, args ...any) {
+	c.add(telemetry.LevelError, msg, args)
+}
+
+func TestLoggingLevelsAndSampling(t *testing.T) {
+	ctx := context.Background()
+	logger := &captureLogger{}
+	cfg := DefaultLoggingConfig()
+	cfg.Logger = logger
+	cfg.Levels = map[telemetry.Op]telemetry.Level{telemetry.OpSet: telemetry.LevelInfo}
+	cfg.SampleRatios = map[telemetry.Op]float64{telemetry.OpDelete: 0}
+
+	c := NewDecorator[string](newMapCache(), "c", Logging(cfg))
+	require.NoError(t, c.Set(ctx, "k", "v", time.Minute))
+	_, err := c.Get(ctx, "absent")
+	require.Error(t, err)
+	require.NoError(t, c.Delete(ctx, "k"))
+
+	require.Len(t, logger.entries, 2)
+	require.Equal(t, telemetry.LevelInfo, logger.entries[0].level)
+	require.Equal(t, msgAccess, logger.entries[0].msg)
+	// 未命中不是失败，按访问日志输出
+	require.Equal(t, telemetry.LevelDebug, logger.entries[1].level)
+	require.Equal(t, msgAccess, logger.entries[1].msg)
+}
+
+func TestLoggingErrorAndSlow(t *testing.T) {
+	logger := &captureLogger{}
+	cfg := DefaultLoggingConfig()
+	cfg.Logger = logger
+	cfg.SlowThreshold = 10 * time.Millisecond
+
+	boom := errors.New("boom")
+	c := NewDecorator[string](newMapCache(), "c",
+		Logging(cfg),
+		func(ctx context.Context, info *OpInfo, next Invoker) error {
+			if info.Key == "fail" {
+				return boom
+			}
+			telemetry.AddCustomFields(ctx, map[string]string{"shared": "true"})
+			time.Sleep(15 * time.Millisecond)
+			return next(ctx, info)
+		})
+
+	evt := &telemetry.Event{}
+	ctx := telemetry.ContextWithEvent(context.Background(), evt)
+	require.ErrorIs(t, c.Set(ctx, "fail", "v", 0), boom)
+	require.NoError(t, c.Set(ctx, "slow", "v", 0))
+
+	require.Len(t, logger.entries, 2)
+	require.Equal(t, telemetry.LevelError, logger.entries[0].level)
+	require.Equal(t, msgFailed, logger.entries[0].msg)
+	require.Contains(t, logger.entries[0].args, boom)
+
+	require.Equal(t, telemetry.LevelWarn, logger.entries[1].level)
+	require.Equal(t, msgSlow, logger.entries[1].msg)
+	require.Subset(t, logger.entries[1].args, []any{"shared", "true"})
+}
+
+func TestLoggingThrottleAndDefaults(t *testing.T) {
+	ctx := context.Background()
+	logger := &captureLogger{}
+	// 未配置的级别使用默认值，而不是 Debug
+	cfg := LoggingConfig{
+		Logger:             logger,
+		DefaultSampleRatio: 1,
+		Throttle:           telemetry.ThrottleConfig{Interval: time.Hour, Burst: 1},
+	}
+	boom := errors.New("boom")
+	c := NewDecorator[string](newMapCache(), "c",
+		Logging(cfg),
+		func(ctx context.Context, info *OpInfo, next Invoker) error {
+			if info.Key == "fail" {
+				return boom
+			}
+			return next(ctx, info)
+		})
+
+	for range 3 {
+		_, _ = c.Get(ctx, "absent")
+	}
+	// 不同操作与结果各自限流，不会被 Get 的访问日志挤掉
+	require.NoError(t, c.Set(ctx, "k", "v", time.Minute))
+	require.ErrorIs(t, c.Set(ctx, "fail", "v", time.Minute), boom)
+
+	require.Len(t, logger.entries, 3)
+	require.Equal(t, telemetry.LevelDebug, logger.entries[0].level)
+	require.Equal(t, msgAccess, logger.entries[1].msg)
+	require.Contains(t, logger.entries[1].args, telemetry.OpSet)
+	require.Equal(t, telemetry.LevelError, logger.entries[2].level)
+	require.Equal(t, msgFailed, logger.entries[2].msg)
+}
+
+func TestLoggingKeyRedactor(t *testing.T) {
+	ctx := context.Background()
+	logger := &captureLogger{}
+	cfg := DefaultLoggingConfig()
+	cfg.Logger = logger
+	cfg.KeyRedactor = func(key string) (string, bool) {
+		if key == "secret" {
+			return "", false
+		}
+		return "family", true
+	}
+	c := NewDecorator[string](newMapCache(), "c", Logging(cfg))
+
+	require.NoError(t, c.Set(ctx, "user:1", "v", time.Minute))
+	require.NoError(t, c.Set(ctx, "secret", "v", time.Minute))
+
+	require.Len(t, logger.entries, 2)
+	require.Contains(t, logger.entries[0].args, "family")
+	require.NotContains(t, logger.entries[0].args, "user:1")
+	// redactor 放弃记录时不输出 key 字段
+	require.NotContains(t, logger.entries[1].args, "key")
+	require.NotContains(t, logger.entries[1].args, "secret")
+}
@@ -0,0 +1,70 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClosed Group 关闭后提交的任务返回该错误
+var ErrClosed = errors.New("lifecycle: group closed")
+
+// Group 跟踪异步写回、刷新等后台任务，Close 后拒绝新任务并等待已有任务结束
+//
+// 零值可以直接使用
+type Group struct {
+	mu     sync.Mutex
+	wg     sync.WaitGroup
+	closed bool
+}
+
+// Go 在新的 goroutine 中执行 fn，Group 已关闭时不执行并返回 ErrClosed
+func (g *Group) Go(fn func()) error {
+	g.mu.Lock()
+	if g.closed {
+		g.mu.Unlock()
+		return ErrClosed
+	}
+	g.wg.Add(1)
+	g.mu.Unlock()
+
+	go func() {
+		defer g.wg.Done()
+		fn()
+	}()
+	return nil
+}
+
+// Close 拒绝新任务并等待已有任务结束，ctx 结束时返回 ctx.Err()，未结束的任务继续在后台执行
+//
+// 可以重复调用
+func (g *Group) Close(ctx context.Context) error {
+	g.mu.Lock()
+	g.closed = true
+	g.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type groupKey struct{}
+
+// ContextWithGroup 在 ctx 中携带任务组，未显式指定任务组的后台任务（如异步写回）会提交到 g
+func ContextWithGroup(ctx context.Context, g *Group) context.Context {
+	return context.WithValue(ctx, groupKey{}, g)
+}
+
+// GroupFromContext 返回 ContextWithGroup 携带的任务组，不存在时返回 nil
+func GroupFromContext(ctx context.Context) *Group {
+	g, _ := ctx.Value(groupKey{}).(*Group)
+	return g
+}
@@ -0,0 +1,37 @@
+package lifecycle
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroup(t *testing.T) {
+	var g Group
+	var done atomic.Int32
+	release := make(chan struct{})
+	for range 3 {
+		require.NoError(t, g.Go(func() {
+			<-release
+			done.Add(1)
+		}))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, g.Close(ctx), context.DeadlineExceeded)
+	require.ErrorIs(t, g.Go(func() {}), ErrClosed)
+
+	close(release)
+	require.NoError(t, g.Close(context.Background()))
+	require.EqualValues(t, 3, done.Load())
+}
+
+func TestGroupFromContext(t *testing.T) {
+	require.Nil(t, GroupFromContext(context.Background()))
+	var g Group
+	require.Same(t, &g, GroupFromContext(ContextWithGroup(context.Background(), &g)))
+}
@@ -2,6 +2,8 @@ package multicache
 
 import (
 	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/interceptor"
+	"github.com/yikakia/cachalot/core/lifecycle"
 	"github.com/yikakia/cachalot/core/telemetry"
 )
 
@@ -13,4 +15,12 @@ type Config[T any] struct {
 	WriteBackFn          WriteBackFn[T]
 	ErrorHandleMode      ErrorHandleMode
 	Observable           *telemetry.Observable
+	// 非空时开启 stale-if-error
+	StaleIfError *StaleIfErrorConfig
+	// 非空时开启热点 key 探测
+	HotKey *HotKeyConfig
+	// 按声明顺序作用于全部操作，位于观测层内侧
+	Interceptors []interceptor.Interceptor
+	// 非空时随 ctx 传给 WriteBackFn（见 lifecycle.ContextWithGroup），未指定任务组的异步写回在其中执行
+	Background *lifecycle.Group
 }
@@ -20,6 +20,7 @@ type FetchContext[T any] struct {
 // FetchPolicy 缓存获取策略（描述怎么从多个缓存中获取数据）
 //
 // 当返回 error 时，会直接返回 error 不会再触发回写的逻辑
+// 此时返回的 []FailedCache 仅用于判断是否存在存储故障（stale-if-error）
 type FetchPolicy[T any] func(ctx context.Context, getCtx *FetchContext[T]) (T, []FailedCache[T], error)
 type FailedCache[T any] struct {
 	Cache cache.Cache[T]
@@ -46,14 +47,20 @@ func FetchPolicySequential[T any](ctx context.Context, getCtx *FetchContext[T])
 			continue
 		}
 		tags["source"] = "cache_" + strconv.Itoa(i)
+		telemetry.UpdateDetails(ctx, func(d *telemetry.Details) {
+			d.Tier, d.HasTier = i, true
+		})
 		return val, failedCaches, nil
 	}
 
 	// 没有加载成功的，可能是不存在或者失败，这里认为是都需要回源
+	telemetry.UpdateDetails(ctx, func(d *telemetry.Details) {
+		d.LoaderRan = true
+	})
 	val, err := m.FetchByLoader(ctx, key, getCtx.Options...)
 	if err != nil {
-		// 回源失败了，这里直接返回 err
-		return zero, nil, fmt.Errorf("[FetchPolicySequential] get from source failed: %w", err)
+		// 回源失败了，这里直接返回 err，同时带上失败的 cache 用于 stale-if-error 判断
+		return zero, failedCaches, fmt.Errorf("[FetchPolicySequential] get from source failed: %w", err)
 	}
 
 	tags["source"] = "loader"
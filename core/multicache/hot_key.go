@@ -0,0 +1,72 @@
+package multicache
+
+import (
+	"context"
+	"time"
+
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/hotkey"
+	"github.com/yikakia/cachalot/core/telemetry"
+)
+
+// HotKeyConfig 热点 key 探测配置
+type HotKeyConfig struct {
+	Detector *hotkey.Detector
+	// 热点 key 提升到本地缓存后的存活时间，<= 0 表示只探测不提升
+	PromoteTTL time.Duration
+}
+
+// hotKeyDecorator 热点 key 探测与本地提升，语义与 decorator.HotKeyDecorator 一致
+type hotKeyDecorator[T any] struct {
+	MultiCache[T]
+	promoter *hotkey.Promoter[T]
+	name     string
+	ob       *telemetry.Observable
+}
+
+func newHotKeyDecorator[T any](name string, inner MultiCache[T], cfg *HotKeyConfig, ob *telemetry.Observable) MultiCache[T] {
+	return &hotKeyDecorator[T]{
+		MultiCache: inner,
+		promoter:   hotkey.NewPromoter[T](cfg.Detector, cfg.PromoteTTL),
+		name:       name,
+		ob:         ob,
+	}
+}
+
+func (d *hotKeyDecorator[T]) Get(ctx context.Context, key string, opts ...cache.CallOption) (T, error) {
+	obs := d.promoter.Observe(ctx, key, d.ob, d.name)
+	version := d.promoter.Version(key)
+	if val, _, ok := d.promoter.Lookup(key); ok {
+		telemetry.AddCustomFields(ctx, map[string]string{"hotkey": "local"})
+		return val, nil
+	}
+	val, err := d.MultiCache.Get(ctx, key, opts...)
+	if err == nil && obs.Hot {
+		telemetry.AddCustomFields(ctx, map[string]string{"hotkey": "true"})
+		d.promoter.Promote(key, val, version)
+	}
+	return val, err
+}
+
+func (d *hotKeyDecorator[T]) Set(ctx context.Context, key string, val T, ttl time.Duration, opts ...cache.CallOption) error {
+	d.promoter.Invalidate(key)
+	err := d.MultiCache.Set(ctx, key, val, ttl, opts...)
+	// 写入期间读到旧值的请求可能已经拿到了版本，再次失效让其放弃提升
+	d.promoter.Invalidate(key)
+	return err
+}
+
+func (d *hotKeyDecorator[T]) Delete(ctx context.Context, key string, opts ...cache.CallOption) error {
+	d.promoter.Invalidate(key)
+	err := d.MultiCache.Delete(ctx, key, opts...)
+	// 删除期间读到旧值的请求可能已经拿到了版本，再次失效让其放弃提升
+	d.promoter.Invalidate(key)
+	return err
+}
+
+func (d *hotKeyDecorator[T]) Clear(ctx context.Context) error {
+	d.promoter.Clear()
+	err := d.MultiCache.Clear(ctx)
+	d.promoter.Clear()
+	return err
+}
@@ -0,0 +1,87 @@
+package multicache
+
+import (
+	"context"
+	"time"
+
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/interceptor"
+	"github.com/yikakia/cachalot/core/telemetry"
+)
+
+// interceptorDecorator 将拦截链作用于 MultiCache 的 Get、Set、Delete、Clear 与 FetchByLoader
+//
+// 与单级缓存一致，每次对外调用只经过一次拦截链：Get 未命中时的回源属于 Get 的一部分，
+// 只有直接调用 FetchByLoader 时才以 telemetry.OpFetchByLoader 经过拦截链
+type interceptorDecorator[T any] struct {
+	MultiCache[T]
+	name  string
+	chain interceptor.Interceptor
+}
+
+func newInterceptorDecorator[T any](name string, inner MultiCache[T], interceptors []interceptor.Interceptor) MultiCache[T] {
+	return &interceptorDecorator[T]{
+		MultiCache: inner,
+		name:       name,
+		chain:      interceptor.Chain(interceptors...),
+	}
+}
+
+func (d *interceptorDecorator[T]) Get(ctx context.Context, key string, opts ...cache.CallOption) (T, error) {
+	info := &interceptor.OpInfo{Op: telemetry.OpGet, CacheName: d.name, Key: key, Options: opts}
+	err := d.chain(ctx, info, func(ctx context.Context, info *interceptor.OpInfo) error {
+		val, err := d.MultiCache.Get(ctx, info.Key, info.Options...)
+		if err != nil {
+			return err
+		}
+		info.Value = val
+		return nil
+	})
+	return resultOf[T](info, err)
+}
+
+func (d *interceptorDecorator[T]) Set(ctx context.Context, key string, val T, ttl time.Duration, opts ...cache.CallOption) error {
+	info := &interceptor.OpInfo{Op: telemetry.OpSet, CacheName: d.name, Key: key, TTL: ttl, Options: opts, Value: val}
+	return d.chain(ctx, info, func(ctx context.Context, info *interceptor.OpInfo) error {
+		v, err := interceptor.ValueAs[T](info)
+		if err != nil {
+			return err
+		}
+		return d.MultiCache.Set(ctx, info.Key, v, info.TTL, info.Options...)
+	})
+}
+
+func (d *interceptorDecorator[T]) Delete(ctx context.Context, key string, opts ...cache.CallOption) error {
+	info := &interceptor.OpInfo{Op: telemetry.OpDelete, CacheName: d.name, Key: key, Options: opts}
+	return d.chain(ctx, info, func(ctx context.Context, info *interceptor.OpInfo) error {
+		return d.MultiCache.Delete(ctx, info.Key, info.Options...)
+	})
+}
+
+func (d *interceptorDecorator[T]) Clear(ctx context.Context) error {
+	info := &interceptor.OpInfo{Op: telemetry.OpClear, CacheName: d.name}
+	return d.chain(ctx, info, func(ctx context.Context, info *interceptor.OpInfo) error {
+		return d.MultiCache.Clear(ctx)
+	})
+}
+
+func (d *interceptorDecorator[T]) FetchByLoader(ctx context.Context, key string, opts ...cache.CallOption) (T, error) {
+	info := &interceptor.OpInfo{Op: telemetry.OpFetchByLoader, CacheName: d.name, Key: key, Options: opts}
+	err := d.chain(ctx, info, func(ctx context.Context, info *interceptor.OpInfo) error {
+		val, err := d.MultiCache.FetchByLoader(ctx, info.Key, info.Options...)
+		if err != nil {
+			return err
+		}
+		info.Value = val
+		return nil
+	})
+	return resultOf[T](info, err)
+}
+
+func resultOf[T any](info *interceptor.OpInfo, err error) (T, error) {
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return interceptor.ValueAs[T](info)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/sourcegraph/conc/pool"
 	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/lifecycle"
 	"github.com/yikakia/cachalot/core/telemetry"
 )
 
@@ -16,9 +17,16 @@ func New[T any](name string, cfg Config[T], caches ...cache.Cache[T]) (MultiCach
 	m := &multiCache[T]{
 		caches: caches,
 		cfg:    &cfg,
+		stale:  newStaleStore[T](cfg.StaleIfError),
 	}
 
 	var res MultiCache[T] = m
+	if cfg.HotKey != nil && cfg.HotKey.Detector != nil {
+		res = newHotKeyDecorator(name, res, cfg.HotKey, cfg.Observable)
+	}
+	if len(cfg.Interceptors) > 0 {
+		res = newInterceptorDecorator(name, res, cfg.Interceptors)
+	}
 	if cfg.Observable != nil {
 		res = newObservableDecorator(name, res, cfg.Observable)
 	}
@@ -43,6 +51,7 @@ type MultiCache[T any] interface {
 type multiCache[T any] struct {
 	caches []cache.Cache[T]
 	cfg    *Config[T]
+	stale  *staleStore[T]
 }
 
 // Get 按照一定流程从传入的 cache 中查询，并自动回写
@@ -51,6 +60,8 @@ type multiCache[T any] struct {
 //	1. 通过传入的 FetchPolicy 获取 val，失败的 cache
 //	2. 通过传入的 WriteBackCacheFilter 过滤出需要回写的失败的 cache
 //	3. 通过传入的 WriteBackFn 进行回写
+//
+// 开启 stale-if-error 时，如果 FetchPolicy 返回 error 且存在存储故障，会尝试返回旁路存储中的旧值
 func (m *multiCache[T]) Get(ctx context.Context, key string, opts ...cache.CallOption) (val T, err error) {
 	var zero T
 	var getCtx = FetchContext[T]{
@@ -60,13 +71,22 @@ func (m *multiCache[T]) Get(ctx context.Context, key string, opts ...cache.CallO
 	}
 	val, failedCaches, err := m.cfg.FetchPolicy(ctx, &getCtx)
 	if err != nil {
+		if stale, ok := m.serveStale(ctx, key, failedCaches, err); ok {
+			return stale, nil
+		}
 		return zero, err
 	}
 	getCtx.GotValue = val
+	m.stale.refresh(key, val)
 
 	writeBackCaches := m.cfg.WriteBackCacheFilter(ctx, &getCtx, failedCaches)
-	err = m.cfg.WriteBackFn(ctx, &getCtx, writeBackCaches)
+	wctx := ctx
+	if m.cfg.Background != nil {
+		wctx = lifecycle.ContextWithGroup(ctx, m.cfg.Background)
+	}
+	err = m.cfg.WriteBackFn(wctx, &getCtx, writeBackCaches)
 	if err != nil {
+		telemetry.AddCustomFields(ctx, map[string]string{"write_back": "fail"})
 		switch e := m.cfg.ErrorHandleMode; e {
 		case ErrorHandleStrict:
 			return zero, err
@@ -90,11 +110,17 @@ func (m *multiCache[T]) Set(ctx context.Context, key string, val T, ttl time.Dur
 			return c.Set(ctx, key, val, ttl, opts...)
 		})
 	}
-	return p.Wait()
+	if err := p.Wait(); err != nil {
+		return err
+	}
+	// 只有全部写入成功的值才能作为旧值兜底
+	m.stale.put(key, val)
+	return nil
 }
 
 // 串行执行
 func (m *multiCache[T]) Delete(ctx context.Context, key string, opts ...cache.CallOption) (err error) {
+	m.stale.delete(key)
 	var errs []error
 	for _, c := range m.caches {
 		err := c.Delete(ctx, key, opts...)
@@ -107,6 +133,7 @@ func (m *multiCache[T]) Delete(ctx context.Context, key string, opts ...cache.Ca
 
 // 串行执行
 func (m *multiCache[T]) Clear(ctx context.Context) (err error) {
+	m.stale.clear()
 	var errs []error
 	for _, cache := range m.caches {
 		err := cache.Clear(ctx)
@@ -111,3 +111,85 @@ func TestMultiCacheGetWriteBackErrorHandling(t *testing.T) {
 		require.Empty(t, v)
 	})
 }
+
+type staleMetrics struct {
+	mockMetrics
+	stale int
+}
+
+func (s *staleMetrics) RecordStaleServed(context.Context) { s.stale++ }
+
+func TestMultiCacheStaleIfError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	storeErr := errors.New("redis down")
+	loaderErr := errors.New("db down")
+	loaderFail := false
+
+	metrics := &staleMetrics{}
+	c1 := mocks.NewMockCache[string](ctrl)
+	mc, err := New("cache", Config[string]{
+		LoaderFn: func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			if loaderFail {
+				return "", loaderErr
+			}
+			return "loaded", nil
+		},
+		FetchPolicy:          FetchPolicySequential[string],
+		WriteBackCacheFilter: MissedCacheFilter[string],
+		WriteBackFn:          WriteBackParallel[string](time.Minute),
+		Observable:           &telemetry.Observable{Metrics: metrics, Logger: telemetry.SlogLogger()},
+		StaleIfError:         &StaleIfErrorConfig{Grace: time.Minute},
+	}, c1)
+	require.NoError(t, err)
+
+	c1.EXPECT().Get(gomock.Any(), "k").Return("v1", nil)
+	v, err := mc.Get(ctx, "k")
+	require.NoError(t, err)
+	require.Equal(t, "v1", v)
+
+	loaderFail = true
+
+	t.Run("miss and loader failed returns error", func(t *testing.T) {
+		c1.EXPECT().Get(gomock.Any(), "k").Return("", cache.ErrNotFound)
+		_, err := mc.Get(ctx, "k")
+		require.ErrorIs(t, err, loaderErr)
+	})
+
+	t.Run("store and loader failed returns stale", func(t *testing.T) {
+		c1.EXPECT().Get(gomock.Any(), "k").Return("", storeErr)
+		v, err := mc.Get(ctx, "k")
+		require.NoError(t, err)
+		require.Equal(t, "v1", v)
+		require.Equal(t, 1, metrics.stale)
+
+		last := metrics.events[len(metrics.events)-1]
+		require.Equal(t, "true", last.FrozenCustomFields()["stale"])
+	})
+
+	t.Run("deleted key is never served stale", func(t *testing.T) {
+		c1.EXPECT().Delete(gomock.Any(), "k").Return(nil)
+		require.NoError(t, mc.Delete(ctx, "k"))
+
+		c1.EXPECT().Get(gomock.Any(), "k").Return("", storeErr)
+		_, err := mc.Get(ctx, "k")
+		require.ErrorIs(t, err, loaderErr)
+	})
+
+	t.Run("failed set is never served stale", func(t *testing.T) {
+		c1.EXPECT().Set(gomock.Any(), "k", "v2", time.Minute).Return(storeErr)
+		require.ErrorIs(t, mc.Set(ctx, "k", "v2", time.Minute), storeErr)
+
+		c1.EXPECT().Get(gomock.Any(), "k").Return("", storeErr)
+		_, err := mc.Get(ctx, "k")
+		require.ErrorIs(t, err, loaderErr)
+
+		c1.EXPECT().Set(gomock.Any(), "k", "v3", time.Minute).Return(nil)
+		require.NoError(t, mc.Set(ctx, "k", "v3", time.Minute))
+		c1.EXPECT().Get(gomock.Any(), "k").Return("", storeErr)
+		v, err := mc.Get(ctx, "k")
+		require.NoError(t, err)
+		require.Equal(t, "v3", v)
+	})
+}
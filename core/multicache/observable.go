@@ -25,10 +25,12 @@ func newObservableDecorator[T any](name string, inner MultiCache[T], ob *telemet
 
 func (d *observableDecorator[T]) Get(ctx context.Context, key string, opts ...cache.CallOption) (val T, err error) {
 	startTime := time.Now()
+	ctx, listener := telemetry.TakeEventListener(ctx)
 	var evt = &telemetry.Event{
 		Op:        telemetry.OpGet,
 		CacheName: d.name,
 	}
+	telemetry.RecordKey(evt, d.ob.KeyRedactor, key)
 	defer func() {
 		evt.Result = internal.ResultFromErr(err)
 		evt.Error = err
@@ -36,6 +38,9 @@ func (d *observableDecorator[T]) Get(ctx context.Context, key string, opts ...ca
 		if recordErr := d.ob.Metrics.Record(ctx, evt); recordErr != nil {
 			d.ob.Logger.ErrorContext(ctx, "[observableDecorator.Get] Record Metrics Failed.", "err", recordErr.Error())
 		}
+		if listener != nil {
+			listener(ctx, evt)
+		}
 	}()
 	ctx = telemetry.ContextWithEvent(ctx, evt)
 
@@ -44,16 +49,21 @@ func (d *observableDecorator[T]) Get(ctx context.Context, key string, opts ...ca
 
 func (d *observableDecorator[T]) Set(ctx context.Context, key string, val T, ttl time.Duration, opts ...cache.CallOption) (err error) {
 	startTime := time.Now()
+	ctx, listener := telemetry.TakeEventListener(ctx)
 	var evt = &telemetry.Event{
 		Op:        telemetry.OpSet,
 		CacheName: d.name,
 	}
+	telemetry.RecordKey(evt, d.ob.KeyRedactor, key)
 	defer func() {
 		evt.Error = err
 		evt.Latency = time.Since(startTime)
 		if recordErr := d.ob.Metrics.Record(ctx, evt); recordErr != nil {
 			d.ob.Logger.ErrorContext(ctx, "[observableDecorator.Set] Record Metrics Failed.", "err", recordErr.Error())
 		}
+		if listener != nil {
+			listener(ctx, evt)
+		}
 	}()
 	ctx = telemetry.ContextWithEvent(ctx, evt)
 
@@ -62,16 +72,21 @@ func (d *observableDecorator[T]) Set(ctx context.Context, key string, val T, ttl
 
 func (d *observableDecorator[T]) Delete(ctx context.Context, key string, opts ...cache.CallOption) (err error) {
 	startTime := time.Now()
+	ctx, listener := telemetry.TakeEventListener(ctx)
 	var evt = &telemetry.Event{
 		Op:        telemetry.OpDelete,
 		CacheName: d.name,
 	}
+	telemetry.RecordKey(evt, d.ob.KeyRedactor, key)
 	defer func() {
 		evt.Error = err
 		evt.Latency = time.Since(startTime)
 		if recordErr := d.ob.Metrics.Record(ctx, evt); recordErr != nil {
 			d.ob.Logger.ErrorContext(ctx, "[observableDecorator.Delete] Record Metrics Failed.", "err", recordErr.Error())
 		}
+		if listener != nil {
+			listener(ctx, evt)
+		}
 	}()
 	ctx = telemetry.ContextWithEvent(ctx, evt)
 
@@ -80,6 +95,7 @@ func (d *observableDecorator[T]) Delete(ctx context.Context, key string, opts ..
 
 func (d *observableDecorator[T]) Clear(ctx context.Context) (err error) {
 	startTime := time.Now()
+	ctx, listener := telemetry.TakeEventListener(ctx)
 	var evt = &telemetry.Event{
 		Op:        telemetry.OpClear,
 		CacheName: d.name,
@@ -90,6 +106,9 @@ func (d *observableDecorator[T]) Clear(ctx context.Context) (err error) {
 		if recordErr := d.ob.Metrics.Record(ctx, evt); recordErr != nil {
 			d.ob.Logger.ErrorContext(ctx, "[observableDecorator.Clear] Record Metrics Failed.", "err", recordErr.Error())
 		}
+		if listener != nil {
+			listener(ctx, evt)
+		}
 	}()
 	ctx = telemetry.ContextWithEvent(ctx, evt)
 
@@ -98,10 +117,12 @@ func (d *observableDecorator[T]) Clear(ctx context.Context) (err error) {
 
 func (d *observableDecorator[T]) FetchByLoader(ctx context.Context, key string, opts ...cache.CallOption) (val T, err error) {
 	startTime := time.Now()
+	ctx, listener := telemetry.TakeEventListener(ctx)
 	var evt = &telemetry.Event{
-		Op:        "fetch_by_loader",
+		Op:        telemetry.OpFetchByLoader,
 		CacheName: d.name,
 	}
+	telemetry.RecordKey(evt, d.ob.KeyRedactor, key)
 	defer func() {
 		evt.Result = internal.ResultFromErr(err)
 		evt.Error = err
@@ -109,6 +130,9 @@ func (d *observableDecorator[T]) FetchByLoader(ctx context.Context, key string,
 		if recordErr := d.ob.Metrics.Record(ctx, evt); recordErr != nil {
 			d.ob.Logger.ErrorContext(ctx, "[observableDecorator.FetchByLoader] Record Metrics Failed.", "err", recordErr.Error())
 		}
+		if listener != nil {
+			listener(ctx, evt)
+		}
 	}()
 	ctx = telemetry.ContextWithEvent(ctx, evt)
 
@@ -0,0 +1,118 @@
+package multicache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/telemetry"
+	"github.com/yikakia/cachalot/internal/lru"
+)
+
+// StaleIfErrorConfig stale-if-error 配置
+//
+// 开启后，MultiCache 会在本地旁路存储中保留最近读到或成功写入的值 Grace 时间。
+// 读到的值只在旁路存储中的旧值剩余时间不足 Grace/2 时才会刷新，避免每次读取都争用旁路存储的写锁。
+// 仅当某一级缓存返回了非 cache.ErrNotFound 的错误（存储故障），且回源也失败时，才会返回旁路存储中的旧值。
+// 正常读取不会使用旁路存储。
+type StaleIfErrorConfig struct {
+	// 旧值在旁路存储中保留的时间
+	Grace time.Duration
+	// 旁路存储最多保留的 key 数量，超过后按 LRU 淘汰 默认 10000
+	MaxEntries int
+}
+
+// StaleMetrics 如果 telemetry.Metrics 实现了该接口，则会上报返回旧值的次数
+type StaleMetrics interface {
+	RecordStaleServed(ctx context.Context)
+}
+
+type staleStore[T any] struct {
+	grace time.Duration
+	store *lru.Cache[T]
+}
+
+func newStaleStore[T any](cfg *StaleIfErrorConfig) *staleStore[T] {
+	if cfg == nil || cfg.Grace <= 0 {
+		return nil
+	}
+	maxEntries := cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = 10000
+	}
+	return &staleStore[T]{
+		grace: cfg.Grace,
+		store: lru.New[T](maxEntries),
+	}
+}
+
+func (s *staleStore[T]) put(key string, val T) {
+	if s == nil {
+		return
+	}
+	s.store.Set(key, val, s.grace)
+}
+
+// refresh 读取成功后调用，旁路存储中的值剩余时间超过 Grace/2 时不更新
+func (s *staleStore[T]) refresh(key string, val T) {
+	if s == nil {
+		return
+	}
+	if _, ttl, ok := s.store.Peek(key); ok && ttl > s.grace/2 {
+		return
+	}
+	s.store.Set(key, val, s.grace)
+}
+
+func (s *staleStore[T]) delete(key string) {
+	if s == nil {
+		return
+	}
+	s.store.Delete(key)
+}
+
+func (s *staleStore[T]) clear() {
+	if s == nil {
+		return
+	}
+	s.store.Clear()
+}
+
+func (s *staleStore[T]) get(key string) (T, bool) {
+	var zero T
+	if s == nil {
+		return zero, false
+	}
+	v, _, ok := s.store.Get(key)
+	return v, ok
+}
+
+// 存在非 miss 的缓存错误时才认为是存储故障
+func hasStoreFailure[T any](failedCaches []FailedCache[T]) bool {
+	for _, fc := range failedCaches {
+		if fc.Err != nil && !errors.Is(fc.Err, cache.ErrNotFound) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *multiCache[T]) serveStale(ctx context.Context, key string, failedCaches []FailedCache[T], fetchErr error) (T, bool) {
+	var zero T
+	if m.stale == nil || !hasStoreFailure(failedCaches) {
+		return zero, false
+	}
+	val, ok := m.stale.get(key)
+	if !ok {
+		return zero, false
+	}
+	telemetry.AddCustomFields(ctx, map[string]string{"stale": "true"})
+	if ob := m.cfg.Observable; ob != nil {
+		for _, sm := range telemetry.MetricsAs[StaleMetrics](ob.Metrics) {
+			sm.RecordStaleServed(ctx)
+		}
+		ob.WarnContext(ctx, "[multiCache] store and loader both failed, serve stale value", "key", key, "error", fetchErr.Error())
+	}
+	return val, true
+}
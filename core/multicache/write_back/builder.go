@@ -6,6 +6,7 @@ import (
 
 	"github.com/sourcegraph/conc/panics"
 	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/lifecycle"
 	"github.com/yikakia/cachalot/core/multicache"
 )
 
@@ -28,6 +29,10 @@ type Builder[T any] struct {
 	CustomWriteBack multicache.WriteBackFn[T]
 	// 异步执行
 	Async bool
+	// 异步执行时跟踪写回任务，关闭后不再发起写回，而是以 lifecycle.ErrClosed 调用 ErrCallback。
+	// 为空时使用 ctx 中的任务组（见 lifecycle.ContextWithGroup），MultiBuilder.WithManager 会自动携带 Manager.Background()；
+	// 两者都不存在时不跟踪
+	Group *lifecycle.Group
 
 	// 执行出错时回调
 	ErrCallback ErrCallback[T]
@@ -45,7 +50,7 @@ func (w Builder[T]) Build() multicache.WriteBackFn[T] {
 }
 
 func (w Builder[T]) wrapAsync(fn multicache.WriteBackFn[T], cb ErrCallback[T]) multicache.WriteBackFn[T] {
-	return asyncDecorator(fn, cb)
+	return asyncDecorator(fn, cb, w.Group)
 }
 
 func (w Builder[T]) buildBasicFn() multicache.WriteBackFn[T] {
@@ -77,14 +82,25 @@ func (w Builder[T]) wrapErrCallback(fn multicache.WriteBackFn[T], cb ErrCallback
 }
 
 // 并发装饰
-func asyncDecorator[T any](f multicache.WriteBackFn[T], cb ErrCallback[T]) multicache.WriteBackFn[T] {
+func asyncDecorator[T any](f multicache.WriteBackFn[T], cb ErrCallback[T], group *lifecycle.Group) multicache.WriteBackFn[T] {
 	return func(ctx context.Context, getCtx *multicache.FetchContext[T], caches []cache.Cache[T]) error {
-		go func() {
+		task := func() {
 			panics.Try(func() {
 				err := f(ctx, getCtx, caches)
 				cb(ctx, getCtx, caches, err)
 			})
-		}()
+		}
+		g := group
+		if g == nil {
+			g = lifecycle.GroupFromContext(ctx)
+		}
+		if g == nil {
+			go task()
+			return nil
+		}
+		if err := g.Go(task); err != nil {
+			cb(ctx, getCtx, caches, err)
+		}
 		return nil
 	}
 }
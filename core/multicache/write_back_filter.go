@@ -10,11 +10,11 @@ import (
 // WriteBackCacheFilter 回写缓存筛选（筛选需要回写的缓存）
 type WriteBackCacheFilter[T any] func(ctx context.Context, getCtx *FetchContext[T], failedCaches []FailedCache[T]) []cache.Cache[T]
 
-// 缓存不存在的才需要回源
+// 缓存不存在的才需要回写，值由更高 schema 版本写入（cache.ErrNewerSchema）的缓存不回写
 func MissedCacheFilter[T any](_ context.Context, _ *FetchContext[T], failedCaches []FailedCache[T]) []cache.Cache[T] {
 	var ret []cache.Cache[T]
 	for _, failedCache := range failedCaches {
-		if errors.Is(failedCache.Err, cache.ErrNotFound) {
+		if errors.Is(failedCache.Err, cache.ErrNotFound) && !errors.Is(failedCache.Err, cache.ErrNewerSchema) {
 			ret = append(ret, failedCache.Cache)
 		}
 	}
@@ -0,0 +1,15 @@
+package stats
+
+// 统计依赖的自定义字段，由各装饰器通过 telemetry.AddCustomFields 写入
+const (
+	// 值的来源 回源时为 loader
+	fieldSource  = "source"
+	sourceLoader = "loader"
+
+	// 回写结果 失败时为 fail
+	fieldWriteBack = "write_back"
+	writeBackFail  = "fail"
+
+	// singleflight 是否共享了其他请求的结果
+	fieldShared = "shared"
+)
@@ -0,0 +1,96 @@
+package stats
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// 每个 2 的幂区间再平分为 histogramSubBuckets 份，相对误差不超过 1/histogramSubBuckets
+const (
+	histogramSubBits    = 2
+	histogramSubBuckets = 1 << histogramSubBits
+	histogramBuckets    = (64 - histogramSubBits + 1) * histogramSubBuckets
+)
+
+// histogram 无锁的对数分桶直方图，用于估算耗时分位数
+type histogram struct {
+	count   atomic.Uint64
+	sum     atomic.Int64
+	max     atomic.Int64
+	buckets [histogramBuckets]atomic.Uint64
+}
+
+func (h *histogram) observe(d time.Duration) {
+	ns := int64(d)
+	if ns < 0 {
+		ns = 0
+	}
+	h.buckets[bucketOf(uint64(ns))].Add(1)
+	h.count.Add(1)
+	h.sum.Add(ns)
+	for {
+		cur := h.max.Load()
+		if ns <= cur || h.max.CompareAndSwap(cur, ns) {
+			return
+		}
+	}
+}
+
+func (h *histogram) reset() {
+	for i := range h.buckets {
+		h.buckets[i].Store(0)
+	}
+	h.count.Store(0)
+	h.sum.Store(0)
+	h.max.Store(0)
+}
+
+// quantiles 返回各分位数所在分桶的上界，结果不会超过观测到的最大值
+func (h *histogram) quantiles(qs ...float64) []time.Duration {
+	var counts [histogramBuckets]uint64
+	var total uint64
+	for i := range h.buckets {
+		counts[i] = h.buckets[i].Load()
+		total += counts[i]
+	}
+	res := make([]time.Duration, len(qs))
+	if total == 0 {
+		return res
+	}
+	maxNs := h.max.Load()
+	for qi, q := range qs {
+		rank := uint64(q * float64(total))
+		if rank >= total {
+			rank = total - 1
+		}
+		var seen uint64
+		for i, c := range counts {
+			seen += c
+			if seen > rank {
+				res[qi] = time.Duration(min(int64(upperBoundOf(i)), maxNs))
+				break
+			}
+		}
+	}
+	return res
+}
+
+func bucketOf(v uint64) int {
+	if v < histogramSubBuckets {
+		return int(v)
+	}
+	exp := bits.Len64(v) - 1
+	sub := (v >> (exp - histogramSubBits)) & (histogramSubBuckets - 1)
+	return (exp-histogramSubBits+1)*histogramSubBuckets + int(sub)
+}
+
+func upperBoundOf(idx int) uint64 {
+	if idx < histogramSubBuckets {
+		return uint64(idx)
+	}
+	exp := idx/histogramSubBuckets + histogramSubBits - 1
+	sub := uint64(idx % histogramSubBuckets)
+	lower := (histogramSubBuckets + sub) << (exp - histogramSubBits)
+	return lower + (1 << (exp - histogramSubBits)) - 1
+}
@@ -0,0 +1,310 @@
+// Package stats 提供进程内的统计聚合，用于在没有外部指标系统时直接拉取缓存的运行数据
+package stats
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/telemetry"
+)
+
+// Reporter 开启统计的缓存会实现该接口
+type Reporter interface {
+	Stats() Snapshot
+	ResetStats()
+}
+
+// Collector 基于观测事件聚合统计数据，实现了 telemetry.Metrics、decorator.LogicTTLMetrics、decorator.SchemaMetrics、decorator.CompressionMetrics、decorator.SizeGuardMetrics、decorator.BloomGuardMetrics 与 encrypt.Metrics
+//
+// 所有计数均为原子操作，Record 不会加锁；Reset 与 Snapshot 之间不保证多个计数的强一致
+type Collector struct {
+	hits                   atomic.Uint64
+	misses                 atomic.Uint64
+	fails                  atomic.Uint64
+	loaderCalls            atomic.Uint64
+	writeBackErrors        atomic.Uint64
+	logicExpireRefreshes   atomic.Uint64
+	singleflightShared     atomic.Uint64
+	schemaUpgraded         atomic.Uint64
+	schemaDiscarded        atomic.Uint64
+	compressionApplied     atomic.Uint64
+	compressionSkipped     atomic.Uint64
+	compressionRawBytes    atomic.Uint64
+	compressionStored      atomic.Uint64
+	decryptTampered        atomic.Uint64
+	decryptUnknownKey      atomic.Uint64
+	oversizeRejected       atomic.Uint64
+	oversizeSkipped        atomic.Uint64
+	oversizeChunked        atomic.Uint64
+	bloomRejected          atomic.Uint64
+	bloomFalsePositives    atomic.Uint64
+	bloomFalsePositiveRate atomic.Uint64 // math.Float64bits
+
+	ops     sync.Map // telemetry.Op -> *opStats
+	resetAt atomic.Int64
+}
+
+type opStats struct {
+	errors  atomic.Uint64
+	latency histogram
+}
+
+var _ telemetry.Metrics = (*Collector)(nil)
+var _ decorator.BloomGuardMetrics = (*Collector)(nil)
+
+func NewCollector() *Collector {
+	c := &Collector{}
+	c.resetAt.Store(time.Now().UnixNano())
+	return c
+}
+
+func (c *Collector) Record(ctx context.Context, evt *telemetry.Event) error {
+	if evt.Op == telemetry.OpGet || evt.Op == telemetry.OpGetWithTTL {
+		switch evt.Result {
+		case telemetry.ResultHit:
+			c.hits.Add(1)
+		case telemetry.ResultMiss:
+			c.misses.Add(1)
+		case telemetry.ResultFail:
+			c.fails.Add(1)
+		}
+	}
+
+	fields := evt.FrozenCustomFields()
+	if fields[fieldSource] == sourceLoader {
+		c.loaderCalls.Add(1)
+	}
+	if fields[fieldWriteBack] == writeBackFail {
+		c.writeBackErrors.Add(1)
+	}
+	if fields[fieldShared] == "true" {
+		c.singleflightShared.Add(1)
+	}
+
+	s := c.opStats(evt.Op)
+	if evt.Error != nil {
+		s.errors.Add(1)
+	}
+	s.latency.observe(evt.Latency)
+	return nil
+}
+
+// RecordLogicExpire 逻辑过期触发一次刷新
+func (c *Collector) RecordLogicExpire(ctx context.Context) {
+	c.logicExpireRefreshes.Add(1)
+}
+
+func (c *Collector) opStats(op telemetry.Op) *opStats {
+	if v, ok := c.ops.Load(op); ok {
+		return v.(*opStats)
+	}
+	v, _ := c.ops.LoadOrStore(op, &opStats{})
+	return v.(*opStats)
+}
+
+// RecordSchemaUpgraded 旧版本的值升级到当前版本
+func (c *Collector) RecordSchemaUpgraded(ctx context.Context, from, to uint32) {
+	c.schemaUpgraded.Add(1)
+}
+
+// RecordSchemaDiscarded 旧版本的值无法升级，按未命中处理
+func (c *Collector) RecordSchemaDiscarded(ctx context.Context, version, current uint32) {
+	c.schemaDiscarded.Add(1)
+}
+
+// RecordCompression 自适应压缩的一次写入，未压缩时按原始长度计入存储长度
+func (c *Collector) RecordCompression(ctx context.Context, outcome decorator.CompressionOutcome, raw, compressed int) {
+	stored := raw
+	if outcome == decorator.CompressionApplied {
+		stored = compressed
+		c.compressionApplied.Add(1)
+	} else {
+		c.compressionSkipped.Add(1)
+	}
+	c.compressionRawBytes.Add(uint64(raw))
+	c.compressionStored.Add(uint64(stored))
+}
+
+// RecordTampered 加密值认证失败，按未命中处理
+func (c *Collector) RecordTampered(ctx context.Context, keyID uint32) {
+	c.decryptTampered.Add(1)
+}
+
+// RecordUnknownKey 加密值使用的密钥已退役，按未命中处理
+func (c *Collector) RecordUnknownKey(ctx context.Context, keyID uint32) {
+	c.decryptUnknownKey.Add(1)
+}
+
+// RecordOversize 写入的值超过长度限制
+func (c *Collector) RecordOversize(ctx context.Context, policy decorator.OversizePolicy, size int) {
+	switch policy {
+	case decorator.OversizeReject:
+		c.oversizeRejected.Add(1)
+	case decorator.OversizeSkip:
+		c.oversizeSkipped.Add(1)
+	case decorator.OversizeChunk:
+		c.oversizeChunked.Add(1)
+	}
+}
+
+// RecordBloomRejected 布隆过滤器判定 key 一定不存在
+func (c *Collector) RecordBloomRejected(ctx context.Context) {
+	c.bloomRejected.Add(1)
+}
+
+// RecordBloomFalsePositive 布隆过滤器放行但下游未命中
+func (c *Collector) RecordBloomFalsePositive(ctx context.Context) {
+	c.bloomFalsePositives.Add(1)
+}
+
+// RecordBloomFalsePositiveRate 记录当前代过滤器的误判率，过滤器轮换后由装饰器重新统计
+func (c *Collector) RecordBloomFalsePositiveRate(ctx context.Context, rate float64) {
+	c.bloomFalsePositiveRate.Store(math.Float64bits(rate))
+}
+
+// Snapshot 获取当前的统计快照
+func (c *Collector) Snapshot() Snapshot {
+	s := Snapshot{
+		Since:                  time.Unix(0, c.resetAt.Load()),
+		Hits:                   c.hits.Load(),
+		Misses:                 c.misses.Load(),
+		Fails:                  c.fails.Load(),
+		LoaderCalls:            c.loaderCalls.Load(),
+		WriteBackErrors:        c.writeBackErrors.Load(),
+		LogicExpireRefreshes:   c.logicExpireRefreshes.Load(),
+		SingleflightShared:     c.singleflightShared.Load(),
+		SchemaUpgraded:         c.schemaUpgraded.Load(),
+		SchemaDiscarded:        c.schemaDiscarded.Load(),
+		CompressionApplied:     c.compressionApplied.Load(),
+		CompressionSkipped:     c.compressionSkipped.Load(),
+		DecryptTampered:        c.decryptTampered.Load(),
+		DecryptUnknownKey:      c.decryptUnknownKey.Load(),
+		OversizeRejected:       c.oversizeRejected.Load(),
+		OversizeSkipped:        c.oversizeSkipped.Load(),
+		OversizeChunked:        c.oversizeChunked.Load(),
+		BloomRejected:          c.bloomRejected.Load(),
+		BloomFalsePositives:    c.bloomFalsePositives.Load(),
+		BloomFalsePositiveRate: math.Float64frombits(c.bloomFalsePositiveRate.Load()),
+		Ops:                    map[telemetry.Op]OpSnapshot{},
+	}
+	if total := s.Hits + s.Misses + s.Fails; total > 0 {
+		s.HitRatio = float64(s.Hits) / float64(total)
+	}
+	if raw := c.compressionRawBytes.Load(); raw > 0 {
+		s.CompressionRatio = float64(c.compressionStored.Load()) / float64(raw)
+	}
+
+	c.ops.Range(func(key, value any) bool {
+		o := value.(*opStats)
+		count := o.latency.count.Load()
+		if count == 0 {
+			return true
+		}
+		qs := o.latency.quantiles(0.5, 0.9, 0.99)
+		s.Ops[key.(telemetry.Op)] = OpSnapshot{
+			Count:  count,
+			Errors: o.errors.Load(),
+			Mean:   time.Duration(o.latency.sum.Load() / int64(count)),
+			P50:    qs[0],
+			P90:    qs[1],
+			P99:    qs[2],
+			Max:    time.Duration(o.latency.max.Load()),
+		}
+		return true
+	})
+	return s
+}
+
+// Reset 清空所有统计
+func (c *Collector) Reset() {
+	c.hits.Store(0)
+	c.misses.Store(0)
+	c.fails.Store(0)
+	c.loaderCalls.Store(0)
+	c.writeBackErrors.Store(0)
+	c.logicExpireRefreshes.Store(0)
+	c.singleflightShared.Store(0)
+	c.schemaUpgraded.Store(0)
+	c.schemaDiscarded.Store(0)
+	c.compressionApplied.Store(0)
+	c.compressionSkipped.Store(0)
+	c.compressionRawBytes.Store(0)
+	c.compressionStored.Store(0)
+	c.decryptTampered.Store(0)
+	c.decryptUnknownKey.Store(0)
+	c.oversizeRejected.Store(0)
+	c.oversizeSkipped.Store(0)
+	c.oversizeChunked.Store(0)
+	c.bloomRejected.Store(0)
+	c.bloomFalsePositives.Store(0)
+	c.bloomFalsePositiveRate.Store(0)
+	c.ops.Range(func(_, value any) bool {
+		o := value.(*opStats)
+		o.errors.Store(0)
+		o.latency.reset()
+		return true
+	})
+	c.resetAt.Store(time.Now().UnixNano())
+}
+
+// Snapshot 某一时刻的统计数据，可直接 JSON 编码用于健康检查等接口
+type Snapshot struct {
+	// 统计起始时间，创建或上一次 Reset 的时间
+	Since time.Time `json:"since"`
+	// 读操作（get/get_with_ttl）的结果
+	Hits     uint64  `json:"hits"`
+	Misses   uint64  `json:"misses"`
+	Fails    uint64  `json:"fails"`
+	HitRatio float64 `json:"hit_ratio"`
+	// 回源次数
+	LoaderCalls uint64 `json:"loader_calls"`
+	// 回写失败次数
+	WriteBackErrors uint64 `json:"write_back_errors"`
+	// 逻辑过期触发的刷新次数
+	LogicExpireRefreshes uint64 `json:"logic_expire_refreshes"`
+	// singleflight 共享他人结果的次数
+	SingleflightShared uint64 `json:"singleflight_shared"`
+	// 旧版本的值升级到当前版本的次数
+	SchemaUpgraded uint64 `json:"schema_upgraded"`
+	// 旧版本的值无法升级、按未命中处理的次数
+	SchemaDiscarded uint64 `json:"schema_discarded"`
+	// 自适应压缩以压缩形式存储的次数
+	CompressionApplied uint64 `json:"compression_applied"`
+	// 自适应压缩因小于阈值或节省不足而原样存储的次数
+	CompressionSkipped uint64 `json:"compression_skipped"`
+	// 自适应压缩写入的存储长度与原始长度之比
+	CompressionRatio float64 `json:"compression_ratio"`
+	// 加密值认证失败、按未命中处理的次数
+	DecryptTampered uint64 `json:"decrypt_tampered"`
+	// 加密值的密钥已退役、按未命中处理的次数
+	DecryptUnknownKey uint64 `json:"decrypt_unknown_key"`
+	// 超过长度限制被拒绝写入的次数
+	OversizeRejected uint64 `json:"oversize_rejected"`
+	// 超过长度限制被跳过、未写入缓存的次数
+	OversizeSkipped uint64 `json:"oversize_skipped"`
+	// 超过长度限制被分块写入的次数
+	OversizeChunked uint64 `json:"oversize_chunked"`
+	// 布隆过滤器判定 key 一定不存在而拦截的次数
+	BloomRejected uint64 `json:"bloom_rejected"`
+	// 布隆过滤器放行但下游未命中的次数
+	BloomFalsePositives uint64 `json:"bloom_false_positives"`
+	// 当前代布隆过滤器观测到的误判率，过滤器轮换后重新统计
+	BloomFalsePositiveRate float64 `json:"bloom_false_positive_rate"`
+	// 按操作类型聚合的耗时
+	Ops map[telemetry.Op]OpSnapshot `json:"ops"`
+}
+
+// OpSnapshot 单个操作类型的耗时汇总，分位数为近似值，相对误差不超过 25%
+type OpSnapshot struct {
+	Count  uint64        `json:"count"`
+	Errors uint64        `json:"errors"`
+	Mean   time.Duration `json:"mean_ns"`
+	P50    time.Duration `json:"p50_ns"`
+	P90    time.Duration `json:"p90_ns"`
+	P99    time.Duration `json:"p99_ns"`
+	Max    time.Duration `json:"max_ns"`
+}
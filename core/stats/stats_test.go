@@ -0,0 +1,111 @@
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot/core/telemetry"
+)
+
+func record(t *testing.T, c *Collector, evt *telemetry.Event, fields map[string]string) {
+	t.Helper()
+	telemetry.AddCustomFields(telemetry.ContextWithEvent(context.Background(), evt), fields)
+	require.NoError(t, c.Record(context.Background(), evt))
+}
+
+func TestCollectorSnapshot(t *testing.T) {
+	c := NewCollector()
+
+	record(t, c, &telemetry.Event{Op: telemetry.OpGet, Result: telemetry.ResultHit, Latency: time.Millisecond}, nil)
+	record(t, c, &telemetry.Event{Op: telemetry.OpGet, Result: telemetry.ResultHit, Latency: time.Millisecond},
+		map[string]string{fieldShared: "true"})
+	record(t, c, &telemetry.Event{Op: telemetry.OpGet, Result: telemetry.ResultMiss, Latency: 3 * time.Millisecond},
+		map[string]string{fieldSource: sourceLoader, fieldWriteBack: writeBackFail})
+	record(t, c, &telemetry.Event{Op: telemetry.OpGetWithTTL, Result: telemetry.ResultFail, Error: errors.New("boom")}, nil)
+	record(t, c, &telemetry.Event{Op: telemetry.OpSet, Latency: time.Microsecond}, nil)
+	c.RecordLogicExpire(context.Background())
+	c.RecordBloomRejected(context.Background())
+	c.RecordBloomFalsePositive(context.Background())
+	c.RecordBloomFalsePositiveRate(context.Background(), 0.25)
+
+	s := c.Snapshot()
+	require.Equal(t, uint64(2), s.Hits)
+	require.Equal(t, uint64(1), s.Misses)
+	require.Equal(t, uint64(1), s.Fails)
+	require.InDelta(t, 0.5, s.HitRatio, 1e-9)
+	require.Equal(t, uint64(1), s.LoaderCalls)
+	require.Equal(t, uint64(1), s.WriteBackErrors)
+	require.Equal(t, uint64(1), s.SingleflightShared)
+	require.Equal(t, uint64(1), s.LogicExpireRefreshes)
+	require.Equal(t, uint64(1), s.BloomRejected)
+	require.Equal(t, uint64(1), s.BloomFalsePositives)
+	require.Equal(t, 0.25, s.BloomFalsePositiveRate)
+
+	get := s.Ops[telemetry.OpGet]
+	require.Equal(t, uint64(3), get.Count)
+	require.Equal(t, 3*time.Millisecond, get.Max)
+	require.Equal(t, 3*time.Millisecond, get.P99)
+	require.InEpsilon(t, float64(time.Millisecond), float64(get.P50), 0.25)
+	require.Equal(t, uint64(1), s.Ops[telemetry.OpGetWithTTL].Errors)
+	require.Equal(t, uint64(1), s.Ops[telemetry.OpSet].Count)
+
+	raw, err := json.Marshal(s)
+	require.NoError(t, err)
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	require.EqualValues(t, 2, decoded["hits"])
+	require.Contains(t, decoded["ops"], "get")
+}
+
+func TestCollectorReset(t *testing.T) {
+	c := NewCollector()
+	record(t, c, &telemetry.Event{Op: telemetry.OpGet, Result: telemetry.ResultHit, Latency: time.Millisecond}, nil)
+	c.RecordBloomFalsePositiveRate(context.Background(), 0.5)
+	before := c.Snapshot().Since
+
+	c.Reset()
+
+	s := c.Snapshot()
+	require.Zero(t, s.Hits)
+	require.Zero(t, s.BloomFalsePositiveRate)
+	require.Empty(t, s.Ops)
+	require.False(t, s.Since.Before(before))
+}
+
+func TestCollectorConcurrentRecord(t *testing.T) {
+	c := NewCollector()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				_ = c.Record(context.Background(), &telemetry.Event{
+					Op: telemetry.OpGet, Result: telemetry.ResultHit, Latency: time.Duration(j) * time.Microsecond,
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	s := c.Snapshot()
+	require.Equal(t, uint64(8000), s.Hits)
+	require.Equal(t, uint64(8000), s.Ops[telemetry.OpGet].Count)
+	require.InEpsilon(t, float64(500*time.Microsecond), float64(s.Ops[telemetry.OpGet].P50), 0.25)
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	for _, v := range []uint64{0, 1, 3, 4, 7, 8, 9, 1000, 1 << 40, 1<<63 - 1} {
+		idx := bucketOf(v)
+		require.Less(t, idx, histogramBuckets)
+		require.GreaterOrEqual(t, upperBoundOf(idx), v)
+		if idx > 0 {
+			require.Less(t, upperBoundOf(idx-1), v)
+		}
+	}
+}
@@ -11,6 +11,52 @@ func ContextWithEvent(ctx context.Context, evt *Event) context.Context {
 	return context.WithValue(ctx, eventKey{}, evt)
 }
 
+// EventFromContext 获取当前上下文中正在记录的事件
+func EventFromContext(ctx context.Context) (*Event, bool) {
+	e, ok := ctx.Value(eventKey{}).(*Event)
+	return e, ok && e != nil
+}
+
+type eventListenerKey struct{}
+
+// EventListener 在观测层记录完事件后回调，用于把事件内容同步给 tracing 等外部系统
+type EventListener func(ctx context.Context, evt *Event)
+
+// ContextWithEventListener 注册事件监听，由内层最近的一个观测层在记录完事件后触发
+func ContextWithEventListener(ctx context.Context, fn EventListener) context.Context {
+	return context.WithValue(ctx, eventListenerKey{}, fn)
+}
+
+// TakeEventListener 取出上下文中的事件监听
+//
+// 返回的上下文不再携带该监听，避免被更内层的观测层（如多级缓存中的各级缓存）重复触发
+func TakeEventListener(ctx context.Context) (context.Context, EventListener) {
+	fn, ok := ctx.Value(eventListenerKey{}).(EventListener)
+	if !ok || fn == nil {
+		return ctx, nil
+	}
+	return context.WithValue(ctx, eventListenerKey{}, EventListener(nil)), fn
+}
+
+type writeBackHookKey struct{}
+
+// WriteBackHook 在 Get 过程中把回源结果写回缓存前回调，返回用于本次写回的上下文，写回结束后调用 end
+type WriteBackHook func(ctx context.Context) (_ context.Context, end func(err error))
+
+// ContextWithWriteBackHook 注册回写监听，用于 tracing 等外部系统区分 Get 过程中的写回
+func ContextWithWriteBackHook(ctx context.Context, fn WriteBackHook) context.Context {
+	return context.WithValue(ctx, writeBackHookKey{}, fn)
+}
+
+// StartWriteBack 触发上下文中的回写监听，没有注册时原样返回 ctx
+func StartWriteBack(ctx context.Context) (context.Context, func(err error)) {
+	fn, ok := ctx.Value(writeBackHookKey{}).(WriteBackHook)
+	if !ok || fn == nil {
+		return ctx, func(error) {}
+	}
+	return fn(ctx)
+}
+
 func AddCustomFields(ctx context.Context, fields map[string]string) {
 	if len(fields) == 0 {
 		return
@@ -41,6 +87,40 @@ type Event struct {
 	mu           sync.Mutex
 	fieldOnce    sync.Once
 	customFields map[string]string
+	details      Details
+}
+
+// Details 事件的可选明细，由链路中的各装饰器按需填充，未填充的字段为零值
+type Details struct {
+	// 经过 KeyRedactor 处理后的 key，未配置 KeyRedactor 或未被采样时为空
+	Key string
+	// 编码后、压缩前的字节数，由 CodecDecorator 与 CompressionDecorator 填充
+	EncodedBytes int
+	// 压缩后的字节数，由 CompressionDecorator 填充
+	CompressedBytes int
+	// 多级缓存中提供值的层级，从 0 开始，HasTier 为 false 时无意义
+	Tier    int
+	HasTier bool
+	// 本次操作是否执行了回源
+	LoaderRan bool
+}
+
+// Details 获取事件明细的副本
+func (e *Event) Details() Details {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.details
+}
+
+// UpdateDetails 修改当前上下文中正在记录的事件明细，上下文中没有事件时不做任何事
+func UpdateDetails(ctx context.Context, fn func(d *Details)) {
+	e, ok := EventFromContext(ctx)
+	if !ok {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	fn(&e.details)
 }
 
 func (e *Event) getOrInitCustomFields() map[string]string {
@@ -65,6 +145,8 @@ const (
 	OpGetWithTTL Op = "get_with_ttl"
 	OpDelete     Op = "delete"
 	OpClear      Op = "clear"
+	// 仅多级缓存
+	OpFetchByLoader Op = "fetch_by_loader"
 )
 
 type Result string
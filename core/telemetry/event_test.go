@@ -34,3 +34,25 @@ func TestAddCustomFields(t *testing.T) {
 		t.Errorf("expected %d fields, got %d", count, len(fields))
 	}
 }
+
+func TestTakeEventListener(t *testing.T) {
+	t.Parallel()
+
+	var called int
+	ctx := ContextWithEventListener(context.Background(), func(ctx context.Context, evt *Event) {
+		called++
+	})
+
+	inner, listener := TakeEventListener(ctx)
+	if listener == nil {
+		t.Fatal("expected listener")
+	}
+	listener(inner, &Event{})
+
+	if _, again := TakeEventListener(inner); again != nil {
+		t.Error("listener should only be taken once")
+	}
+	if called != 1 {
+		t.Errorf("expected listener called once, got %d", called)
+	}
+}
@@ -0,0 +1,61 @@
+package telemetry
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// KeyRedactor 决定事件中记录的 key
+//
+// 返回 false 表示本次不记录 key，可用于脱敏、哈希、按 key 族聚合或采样
+type KeyRedactor func(key string) (string, bool)
+
+// PlainKey 原样记录 key
+func PlainKey() KeyRedactor {
+	return func(key string) (string, bool) {
+		return key, true
+	}
+}
+
+// HashKey 记录 key 的 FNV-1a 64 位哈希，避免敏感信息进入观测系统
+func HashKey() KeyRedactor {
+	return func(key string) (string, bool) {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(key))
+		return strconv.FormatUint(h.Sum64(), 16), true
+	}
+}
+
+// KeyFamily 只记录第一个分隔符之前的前缀，如 user:42 记为 user，便于按 key 族分析命中率
+func KeyFamily(sep string) KeyRedactor {
+	return func(key string) (string, bool) {
+		family, _, _ := strings.Cut(key, sep)
+		return family, true
+	}
+}
+
+// SampleKeys 按 rate 的比例采样，被采样的 key 再交给 next 处理
+func SampleKeys(rate float64, next KeyRedactor) KeyRedactor {
+	return func(key string) (string, bool) {
+		if rate <= 0 || (rate < 1 && rand.Float64() >= rate) {
+			return "", false
+		}
+		return next(key)
+	}
+}
+
+// RecordKey 按 redactor 将 key 写入事件明细，redactor 为空时不记录
+func RecordKey(evt *Event, redactor KeyRedactor, key string) {
+	if redactor == nil {
+		return
+	}
+	redacted, ok := redactor(key)
+	if !ok {
+		return
+	}
+	evt.mu.Lock()
+	defer evt.mu.Unlock()
+	evt.details.Key = redacted
+}
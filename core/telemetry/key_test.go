@@ -0,0 +1,57 @@
+package telemetry
+
+import "testing"
+
+func TestKeyRedactors(t *testing.T) {
+	t.Parallel()
+
+	if got, ok := PlainKey()("user:42"); !ok || got != "user:42" {
+		t.Errorf("PlainKey got %q %v", got, ok)
+	}
+
+	h1, _ := HashKey()("user:42")
+	h2, _ := HashKey()("user:42")
+	h3, _ := HashKey()("user:43")
+	if h1 != h2 || h1 == h3 || h1 == "user:42" {
+		t.Errorf("HashKey should be stable and hide the key, got %q %q %q", h1, h2, h3)
+	}
+
+	if got, _ := KeyFamily(":")("user:42:profile"); got != "user" {
+		t.Errorf("KeyFamily got %q", got)
+	}
+	if got, _ := KeyFamily(":")("plain"); got != "plain" {
+		t.Errorf("KeyFamily without separator got %q", got)
+	}
+
+	if _, ok := SampleKeys(0, PlainKey())("k"); ok {
+		t.Error("rate 0 should never sample")
+	}
+	if got, ok := SampleKeys(1, KeyFamily(":"))("a:b"); !ok || got != "a" {
+		t.Errorf("rate 1 should always sample, got %q %v", got, ok)
+	}
+}
+
+func TestRecordKeyAndDetails(t *testing.T) {
+	t.Parallel()
+
+	evt := &Event{}
+	RecordKey(evt, nil, "k")
+	if evt.Details().Key != "" {
+		t.Error("nil redactor should not record key")
+	}
+	RecordKey(evt, KeyFamily(":"), "user:1")
+
+	ctx := ContextWithEvent(t.Context(), evt)
+	UpdateDetails(ctx, func(d *Details) {
+		d.EncodedBytes = 10
+		d.LoaderRan = true
+	})
+	UpdateDetails(t.Context(), func(d *Details) {
+		t.Error("should not be called without event")
+	})
+
+	d := evt.Details()
+	if d.Key != "user" || d.EncodedBytes != 10 || !d.LoaderRan {
+		t.Errorf("unexpected details %+v", d)
+	}
+}
@@ -15,3 +15,21 @@ func NoopMetrics() Metrics {
 type noopMetrics struct{}
 
 func (n *noopMetrics) Record(ctx context.Context, event *Event) error { return nil }
+
+// MetricsAs 返回 m 中实现可选指标接口 M 的部分
+//
+// m 实现 Unwrap() []Metrics 时（如同时上报到多个 Metrics）逐个展开，
+// 因此组合多个 Metrics 时只需实现 Unwrap，无需转发每个可选接口
+func MetricsAs[M any](m Metrics) []M {
+	if u, ok := m.(interface{ Unwrap() []Metrics }); ok {
+		var res []M
+		for _, inner := range u.Unwrap() {
+			res = append(res, MetricsAs[M](inner)...)
+		}
+		return res
+	}
+	if v, ok := m.(M); ok {
+		return []M{v}
+	}
+	return nil
+}
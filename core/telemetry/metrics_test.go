@@ -0,0 +1,42 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+)
+
+type countMetrics struct {
+	noopMetrics
+	n int
+}
+
+func (c *countMetrics) Count() { c.n++ }
+
+type fanout []Metrics
+
+func (f fanout) Record(context.Context, *Event) error { return nil }
+func (f fanout) Unwrap() []Metrics                    { return f }
+
+func TestMetricsAs(t *testing.T) {
+	t.Parallel()
+	type counter interface{ Count() }
+
+	a, b := &countMetrics{}, &countMetrics{}
+	if got := len(MetricsAs[counter](a)); got != 1 {
+		t.Errorf("MetricsAs single got %d", got)
+	}
+	if got := len(MetricsAs[counter](NoopMetrics())); got != 0 {
+		t.Errorf("MetricsAs noop got %d", got)
+	}
+	if got := len(MetricsAs[counter](nil)); got != 0 {
+		t.Errorf("MetricsAs nil got %d", got)
+	}
+
+	// 嵌套的组合逐层展开，只返回实现了接口的部分
+	for _, m := range MetricsAs[counter](fanout{a, NoopMetrics(), fanout{b}}) {
+		m.Count()
+	}
+	if a.n != 1 || b.n != 1 {
+		t.Errorf("MetricsAs fanout counts %d %d", a.n, b.n)
+	}
+}
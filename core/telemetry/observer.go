@@ -3,6 +3,8 @@ package telemetry
 type Observable struct {
 	Metrics
 	Logger
+	// 为空时事件中不记录 key
+	KeyRedactor KeyRedactor
 }
 
 func DefaultObservable() *Observable {
@@ -0,0 +1,178 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Level 日志级别
+type Level int
+
+const (
+	// LevelDefault 未配置，使用方按各自的默认级别处理
+	LevelDefault Level = iota
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	// LevelOff 不输出
+	LevelOff
+)
+
+// LogAt 按级别输出日志
+func LogAt(ctx context.Context, logger Logger, level Level, msg string, args ...any) {
+	switch level {
+	case LevelDebug:
+		logger.DebugContext(ctx, msg, args...)
+	case LevelInfo:
+		logger.InfoContext(ctx, msg, args...)
+	case LevelWarn:
+		logger.WarnContext(ctx, msg, args...)
+	case LevelError:
+		logger.ErrorContext(ctx, msg, args...)
+	}
+}
+
+// ThrottleConfig 日志限流配置
+type ThrottleConfig struct {
+	// 每个维度在 Interval 内最多输出 Burst 条，超出的被抑制并计数
+	Interval time.Duration
+	Burst    int
+}
+
+// Throttle 按维度限流，各维度的窗口独立加锁
+type Throttle struct {
+	cfg     ThrottleConfig
+	windows sync.Map // key -> *throttleWindow
+}
+
+type throttleWindow struct {
+	mu         sync.Mutex
+	start      time.Time
+	count      int
+	suppressed int
+	total      uint64
+}
+
+// NewThrottle Interval 或 Burst 不大于 0 时不限流
+func NewThrottle(cfg ThrottleConfig) *Throttle {
+	return &Throttle{cfg: cfg}
+}
+
+// Allow 判断 key 维度本次是否放行，key 需要可比较。
+// 放行时返回上次放行以来被抑制的条数
+func (t *Throttle) Allow(key any) (suppressed int, ok bool) {
+	if t.cfg.Interval <= 0 || t.cfg.Burst <= 0 {
+		return 0, true
+	}
+	v, ok := t.windows.Load(key)
+	if !ok {
+		v, _ = t.windows.LoadOrStore(key, &throttleWindow{start: time.Now()})
+	}
+	w := v.(*throttleWindow)
+	now := time.Now()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if now.Sub(w.start) >= t.cfg.Interval {
+		w.start = now
+		w.count = 0
+	}
+	if w.count >= t.cfg.Burst {
+		w.suppressed++
+		w.total++
+		return 0, false
+	}
+	w.count++
+	suppressed, w.suppressed = w.suppressed, 0
+	return suppressed, true
+}
+
+// Suppressed 返回各维度累计被抑制的条数
+func (t *Throttle) Suppressed() map[any]uint64 {
+	res := map[any]uint64{}
+	t.windows.Range(func(key, v any) bool {
+		w := v.(*throttleWindow)
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if w.total > 0 {
+			res[key] = w.total
+		}
+		return true
+	})
+	return res
+}
+
+// WithSuppressed 有被抑制的日志时在 args 末尾追加 suppressed 字段，不修改原切片
+func WithSuppressed(args []any, suppressed int) []any {
+	if suppressed == 0 {
+		return args
+	}
+	return append(args[:len(args):len(args)], "suppressed", suppressed)
+}
+
+// ThrottledLogger 按级别与消息限流的 Logger
+//
+// 同一级别的同一条消息在窗口内超过 Burst 后被抑制，下一次放行时附带 suppressed 字段说明期间被抑制的条数。
+// 以消息文本为维度，消息中不应拼接 key 等变量，变量通过 args 传入
+type ThrottledLogger struct {
+	next     Logger
+	throttle *Throttle
+}
+
+type logThrottleKey struct {
+	level Level
+	msg   string
+}
+
+var _ Logger = (*ThrottledLogger)(nil)
+
+// NewThrottledLogger Interval 或 Burst 不大于 0 时不限流
+func NewThrottledLogger(next Logger, cfg ThrottleConfig) *ThrottledLogger {
+	return &ThrottledLogger{
+		next:     next,
+		throttle: NewThrottle(cfg),
+	}
+}
+
+func (l *ThrottledLogger) DebugContext(ctx context.Context, msg string, args ...any) {
+	if args, ok := l.allow(LevelDebug, msg, args); ok {
+		l.next.DebugContext(ctx, msg, args...)
+	}
+}
+
+func (l *ThrottledLogger) InfoContext(ctx context.Context, msg string, args ...any) {
+	if args, ok := l.allow(LevelInfo, msg, args); ok {
+		l.next.InfoContext(ctx, msg, args...)
+	}
+}
+
+func (l *ThrottledLogger) WarnContext(ctx context.Context, msg string, args ...any) {
+	if args, ok := l.allow(LevelWarn, msg, args); ok {
+		l.next.WarnContext(ctx, msg, args...)
+	}
+}
+
+func (l *ThrottledLogger) ErrorContext(ctx context.Context, msg string, args ...any) {
+	if args, ok := l.allow(LevelError, msg, args); ok {
+		l.next.ErrorContext(ctx, msg, args...)
+	}
+}
+
+// Suppressed 返回各消息累计被抑制的条数，不同级别的同一消息合并计数
+func (l *ThrottledLogger) Suppressed() map[string]uint64 {
+	res := map[string]uint64{}
+	for key, n := range l.throttle.Suppressed() {
+		res[key.(logThrottleKey).msg] += n
+	}
+	return res
+}
+
+func (l *ThrottledLogger) allow(level Level, msg string, args []any) ([]any, bool) {
+	suppressed, ok := l.throttle.Allow(logThrottleKey{level: level, msg: msg})
+	if !ok {
+		return nil, false
+	}
+	return WithSuppressed(args, suppressed), true
+}
@@ -0,0 +1,98 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type captureLogger struct {
+	mu      sync.Mutex
+	entries []captureEntry
+}
+
+type captureEntry struct {
+	level Level
+	msg   string
+	args  []any
+}
+
+func (c *captureLogger) add(level Level, msg string, args []any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, captureEntry{level: level, msg: msg, args: args})
+}
+
+func (c *captureLogger) DebugContext(_ context.Context, msg string, args ...any) {
+	c.add(LevelDebug, msg, args)
+}
+func (c *captureLogger) InfoContext(_ context.Context, msg string, args ...any) {
+	c.add(LevelInfo, msg, args)
+}
+func (c *captureLogger) WarnContext(_ context.Context, msg string, args ...any) {
+	c.add(LevelWarn, msg, args)
+}
+func (c *captureLogger) ErrorContext(_ context.Context, msg string, args ...any) {
+	c.add(LevelError, msg, args)
+}
+
+func TestThrottledLogger(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	capture := &captureLogger{}
+	l := NewThrottledLogger(capture, ThrottleConfig{Interval: 50 * time.Millisecond, Burst: 2})
+
+	for i := 0; i < 5; i++ {
+		l.ErrorContext(ctx, "write back failed", "i", i)
+	}
+	l.WarnContext(ctx, "other message")
+
+	if len(capture.entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(capture.entries))
+	}
+	if got := l.Suppressed()["write back failed"]; got != 3 {
+		t.Errorf("expected 3 suppressed, got %d", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	l.ErrorContext(ctx, "write back failed", "i", 5)
+
+	last := capture.entries[len(capture.entries)-1]
+	if n := len(last.args); n != 4 || last.args[2] != "suppressed" || last.args[3] != 3 {
+		t.Errorf("expected suppressed counter on first entry of new window, got %v", last.args)
+	}
+}
+
+func TestThrottledLoggerLevels(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	capture := &captureLogger{}
+	l := NewThrottledLogger(capture, ThrottleConfig{Interval: time.Hour, Burst: 1})
+
+	// 同一消息在不同级别下分别限流
+	l.DebugContext(ctx, "msg")
+	l.DebugContext(ctx, "msg")
+	l.ErrorContext(ctx, "msg")
+	if len(capture.entries) != 2 || capture.entries[1].level != LevelError {
+		t.Fatalf("expected debug and error entries, got %v", capture.entries)
+	}
+	if got := l.Suppressed()["msg"]; got != 1 {
+		t.Errorf("expected 1 suppressed, got %d", got)
+	}
+}
+
+func TestThrottledLoggerDisabled(t *testing.T) {
+	t.Parallel()
+
+	capture := &captureLogger{}
+	l := NewThrottledLogger(capture, ThrottleConfig{})
+	for i := 0; i < 100; i++ {
+		l.InfoContext(context.Background(), "msg")
+	}
+	if len(capture.entries) != 100 {
+		t.Errorf("expected no throttling, got %d entries", len(capture.entries))
+	}
+}
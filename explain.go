@@ -0,0 +1,342 @@
+package cachalot
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/multicache"
+	"github.com/yikakia/cachalot/internal"
+)
+
+// LayerKind 装配计划中一层所处的阶段
+type LayerKind string
+
+const (
+	// LayerDecorator 作用于 T 的装饰器
+	LayerDecorator LayerKind = "decorator"
+	// LayerFactory 由工厂创建的层，如逻辑过期、多级缓存本身
+	LayerFactory LayerKind = "factory"
+	// LayerAdapter T（开启逻辑过期时为 LogicTTLValue[T]）与 []byte 之间的转换
+	LayerAdapter LayerKind = "adapter"
+	// LayerByte 字节级转换
+	LayerByte LayerKind = "byte"
+	// LayerLoader 回源函数及其包裹
+	LayerLoader LayerKind = "loader"
+	// LayerStore Store 及其包装
+	LayerStore LayerKind = "store"
+)
+
+// Layer 装配计划中的一层
+type Layer struct {
+	// 层的名称，如 singleflight、missed_loader、codec
+	Name string
+	Kind LayerKind
+	// 影响该层行为的配置，如 TTL、编解码类型，没有配置时为空
+	Config map[string]string
+}
+
+func (l Layer) String() string {
+	var sb strings.Builder
+	sb.WriteString(l.Name)
+	sb.WriteString("(")
+	sb.WriteString(string(l.Kind))
+	sb.WriteString(")")
+	if len(l.Config) > 0 {
+		sb.WriteString(" ")
+		sb.WriteString(l.configString())
+	}
+	return sb.String()
+}
+
+func (l Layer) configString() string {
+	kvs := make([]string, 0, len(l.Config))
+	for _, k := range slices.Sorted(maps.Keys(l.Config)) {
+		kvs = append(kvs, k+"="+l.Config[k])
+	}
+	return strings.Join(kvs, " ")
+}
+
+// Plan 缓存由外到内的装配计划，调用依次经过 Layers 中的各层
+type Plan struct {
+	Name   string
+	Kind   CacheKind
+	Layers []Layer
+}
+
+// Names 由外到内的层名称
+func (p Plan) Names() []string {
+	names := make([]string, 0, len(p.Layers))
+	for _, l := range p.Layers {
+		names = append(names, l.Name)
+	}
+	return names
+}
+
+// Index 返回第一个名为 name 的层的位置，越小越靠外，不存在时返回 -1
+func (p Plan) Index(name string) int {
+	return slices.IndexFunc(p.Layers, func(l Layer) bool { return l.Name == name })
+}
+
+// String 每层一行，由外到内
+func (p Plan) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %q\n", p.Kind, p.Name)
+	w := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	for i, l := range p.Layers {
+		if len(l.Config) == 0 {
+			fmt.Fprintf(w, "  %d.\t%s\t%s\n", i+1, l.Name, l.Kind)
+			continue
+		}
+		fmt.Fprintf(w, "  %d.\t%s\t%s\t%s\n", i+1, l.Name, l.Kind, l.configString())
+	}
+	_ = w.Flush()
+	return sb.String()
+}
+
+// Describer 由 Builder / MultiBuilder 构建的缓存实现，返回构建时的装配计划
+type Describer interface {
+	Describe() Plan
+}
+
+// PlanOf 获取通过 Builder / MultiBuilder 构建的缓存的装配计划
+func PlanOf(c any) (Plan, bool) {
+	d, ok := c.(Describer)
+	if !ok {
+		return Plan{}, false
+	}
+	return d.Describe(), true
+}
+
+// Explain 返回按当前配置 Build 时的装配计划，不会修改 Builder
+//
+// 同时返回已知的配置错误，此时计划仅供参考
+func (b *Builder[T]) Explain() (Plan, error) {
+	_, placementErr := b.resolveStages()
+	err := errors.Join(b.err, b.validateStages(), placementErr)
+	if err != nil {
+		err = fmt.Errorf("builder configs wrong: %w", err)
+	}
+	return b.plan(), err
+}
+
+// Explain 返回按当前配置 Build 时的装配计划，不会修改 MultiBuilder
+func (b *MultiBuilder[T]) Explain() (Plan, error) {
+	return b.plan(), b.err
+}
+
+func layer(name string, kind LayerKind, kvs ...string) Layer {
+	l := Layer{Name: name, Kind: kind}
+	if len(kvs) > 0 {
+		l.Config = make(map[string]string, len(kvs)/2)
+		for i := 0; i+1 < len(kvs); i += 2 {
+			l.Config[kvs[i]] = kvs[i+1]
+		}
+	}
+	return l
+}
+
+func (b *Builder[T]) plan() Plan {
+	p := Plan{Name: b.cacheName, Kind: KindCache}
+	add := func(l Layer) { p.Layers = append(p.Layers, l) }
+
+	if b.features.stats {
+		add(layer("stats", LayerDecorator))
+	}
+	if len(b.options) > 0 {
+		add(layer("options", LayerDecorator, "count", strconv.Itoa(len(b.options))))
+	}
+	stages, _ := b.resolveStages()
+	for _, ref := range stages {
+		switch {
+		case ref.placed >= 0:
+			add(layer(string(ref.name), LayerDecorator, "placement", b.placed[ref.placed].at.String()))
+		case ref.legacy >= 0:
+			add(layer("decorator", LayerDecorator, "index", strconv.Itoa(ref.legacy)))
+		case ref.name != StageFactory:
+			add(b.stageLayer(ref.name))
+		}
+	}
+	p.Layers = append(p.Layers, b.factoryPlan()...)
+	if b.negativeCacheEnabled() {
+		add(layer("negative_cache_store", LayerStore))
+	}
+	if cfg := b.features.watchdog.store; cfg != nil {
+		add(layer("store_watchdog", LayerStore, "soft_deadline", cfg.SoftDeadline.String()))
+	}
+	add(layer("store", LayerStore, "name", b.store.StoreName()))
+	return p
+}
+
+func (b *Builder[T]) stageLayer(stage Stage) Layer {
+	switch stage {
+	case StageObservable:
+		if b.observeCustomized {
+			return layer(string(stage), LayerDecorator, "custom", "true")
+		}
+	case StageInterceptors:
+		return layer(string(stage), LayerDecorator, "count", strconv.Itoa(len(b.features.interceptors)), "logging", strconv.FormatBool(b.features.logging != nil))
+	case StageHotKey:
+		return layer(string(stage), LayerDecorator, "promote_ttl", b.features.hotKey.promoteTTL.String())
+	case StageBloomGuard:
+		return layer(string(stage), LayerDecorator, "fail_open", strconv.FormatBool(b.features.bloomGuard.failOpen))
+	case StageNilCache:
+		return layer(string(stage), LayerDecorator, "write_back_ttl", b.features.nilCache.defaultWriteBackTTL.String())
+	case StageMissedLoader:
+		return b.missLoaderLayer()
+	}
+	return layer(string(stage), LayerDecorator)
+}
+
+func (b *Builder[T]) missLoaderLayer() Layer {
+	l := layer("missed_loader", LayerDecorator, "write_back_ttl", b.features.missLoader.defaultWriteBackTTL.String())
+	if ttl := b.features.missLoader.negativeTTL; ttl > 0 {
+		l.Config["negative_ttl"] = ttl.String()
+	}
+	if memo := b.features.missLoader.failureMemo; memo != nil {
+		l.Config["backoff"] = memo.InitialBackoff.String() + ".." + memo.MaxBackoff.String()
+		if memo.MaxStale > 0 {
+			l.Config["max_stale"] = memo.MaxStale.String()
+		}
+	}
+	if b.features.missLoader.corruptionAsMiss {
+		l.Config["corruption_as_miss"] = "true"
+	}
+	if cfg := b.features.watchdog.loader; cfg != nil {
+		l.Config["loader_watchdog"] = cfg.SoftDeadline.String()
+	}
+	return l
+}
+
+// factoryPlan 与 compileStages 选择的装配计划保持一致
+func (b *Builder[T]) factoryPlan() []Layer {
+	if b.factoryCustomized {
+		return []Layer{layer("custom_factory", LayerFactory)}
+	}
+	valueType := reflect.TypeFor[T]().String()
+	var layers []Layer
+	if b.features.logicExpire.enabled {
+		valueType = reflect.TypeFor[decorator.LogicTTLValue[T]]().String()
+		layers = append(layers, layer("logic_expire", LayerFactory,
+			"logic_ttl", b.features.logicExpire.defaultLogicTTL.String(),
+			"write_back_ttl", b.features.logicExpire.defaultWriteBackTTL.String(),
+			"loader", strconv.FormatBool(b.features.logicExpire.loadFn != nil),
+		))
+	}
+	if !b.requiresBytePath() {
+		return append(layers, layer("base", LayerFactory, "type", valueType))
+	}
+
+	layers = append(layers, b.adapterLayer(valueType))
+	for _, stage := range slices.Backward(b.features.byteTransforms) {
+		layers = append(layers, layer(stage.name, LayerByte, stage.config...))
+	}
+	if cfg := b.features.sizeGuard; cfg != nil {
+		l := layer("size_guard", LayerByte, "max_size", strconv.Itoa(cfg.MaxSize), "policy", string(cfg.Policy))
+		if cfg.Policy == decorator.OversizeChunk && cfg.ChunkSize > 0 {
+			l.Config["chunk_size"] = strconv.Itoa(cfg.ChunkSize)
+		}
+		layers = append(layers, l)
+	}
+	return append(layers, layer("base", LayerFactory, "type", "[]uint8"))
+}
+
+// adapterLayer 与 adaptBytesToType、adaptBytesToLogicWire 的选择顺序保持一致
+func (b *Builder[T]) adapterLayer(valueType string) Layer {
+	logic := b.features.logicExpire.enabled
+	switch {
+	case b.features.typeAdapter != nil && !logic:
+		return layer("type_adapter", LayerAdapter, "type", valueType)
+	case b.features.envelope != nil:
+		l := layer("envelope", LayerAdapter, "type", valueType, "write", b.features.envelope.Write.String())
+		if legacy := b.features.envelope.Legacy; legacy != nil {
+			l.Config["legacy"] = legacy.String()
+		}
+		return l
+	case b.features.codec != nil:
+		return layer("codec", LayerAdapter, "type", valueType, "codec", fmt.Sprintf("%T", b.features.codec))
+	case !internal.IsBytesType[T]():
+		return layer("missing_adapter", LayerAdapter, "type", valueType)
+	case logic:
+		return layer("logic_bytes_adapter", LayerAdapter, "type", valueType, "enabled", strconv.FormatBool(b.features.logicExpire.enableBytesAdapter))
+	default:
+		return layer("bytes_passthrough", LayerAdapter, "type", valueType)
+	}
+}
+
+func (b *MultiBuilder[T]) plan() Plan {
+	p := Plan{Name: b.name, Kind: KindMultiCache}
+	add := func(l Layer) { p.Layers = append(p.Layers, l) }
+
+	add(layer("observable", LayerDecorator))
+	if n := len(b.cfg.Interceptors); n > 0 || b.logging != nil {
+		add(layer("interceptors", LayerDecorator, "count", strconv.Itoa(n), "logging", strconv.FormatBool(b.logging != nil)))
+	}
+	if b.cfg.HotKey != nil {
+		add(layer("hot_key", LayerDecorator, "promote_ttl", b.cfg.HotKey.PromoteTTL.String()))
+	}
+	add(layer("multicache", LayerFactory, "tiers", strconv.Itoa(len(b.caches)), "error_handling", errorHandleModeName(b.cfg.ErrorHandleMode)))
+	if cfg := b.cfg.StaleIfError; cfg != nil {
+		add(layer("stale_if_error", LayerFactory, "grace", cfg.Grace.String(), "max_entries", strconv.Itoa(cfg.MaxEntries)))
+	}
+	if b.cfg.LoaderFn != nil {
+		if b.singleFlight {
+			add(layer("singleflight", LayerLoader))
+		}
+		if b.watchdog != nil {
+			add(layer("loader_watchdog", LayerLoader, "soft_deadline", b.watchdog.SoftDeadline.String()))
+		}
+		add(layer("loader", LayerLoader))
+	}
+	return p
+}
+
+func errorHandleModeName(mode multicache.ErrorHandleMode) string {
+	switch mode {
+	case multicache.ErrorHandleStrict:
+		return "strict"
+	case multicache.ErrorHandleTolerant:
+		return "tolerant"
+	default:
+		return strconv.Itoa(int(mode))
+	}
+}
+
+// describedCache Build 返回的最外层，暴露装配计划
+type describedCache[T any] struct {
+	cache.Cache[T]
+	describe func() Plan
+	// 开启 WithBloomGuard 时非空
+	bloomGuard *decorator.BloomGuardDecorator[T]
+}
+
+var _ Describer = (*describedCache[any])(nil)
+
+func (c *describedCache[T]) Describe() Plan {
+	return c.describe()
+}
+
+func (c *describedCache[T]) bloomFalsePositiveRate() (float64, bool) {
+	if c.bloomGuard == nil {
+		return 0, false
+	}
+	return c.bloomGuard.FalsePositiveRate(), true
+}
+
+type describedMultiCache[T any] struct {
+	multicache.MultiCache[T]
+	plan Plan
+}
+
+var _ Describer = (*describedMultiCache[any])(nil)
+
+func (c *describedMultiCache[T]) Describe() Plan {
+	return c.plan
+}
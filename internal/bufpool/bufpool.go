@@ -0,0 +1,70 @@
+// Package bufpool 提供字节层共用的缓冲池
+package bufpool
+
+import (
+	"io"
+	"sync"
+)
+
+// 超过该容量的缓冲区不放回池中，避免偶发的大值长期占用内存
+const maxPooledCap = 1 << 20
+
+var pool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 4<<10)
+		return &b
+	},
+}
+
+// Get 取出一个长度为 0 的缓冲区，使用完毕后调用 Put 归还
+func Get() *[]byte {
+	return pool.Get().(*[]byte)
+}
+
+// Put 归还缓冲区，调用后不能再使用 b 及其底层数组
+func Put(b *[]byte) {
+	if cap(*b) > maxPooledCap {
+		return
+	}
+	*b = (*b)[:0]
+	pool.Put(b)
+}
+
+// Writer 将写入的数据追加到 B
+type Writer struct {
+	B []byte
+}
+
+// ReadFrom 直接读入 B 的剩余容量，避免 io.Copy 分配中间缓冲区
+func (w *Writer) ReadFrom(r io.Reader) (int64, error) {
+	var n int64
+	for {
+		if len(w.B) == cap(w.B) {
+			w.B = append(w.B, 0)[:len(w.B)]
+		}
+		m, err := r.Read(w.B[len(w.B):cap(w.B)])
+		w.B = w.B[:len(w.B)+m]
+		n += int64(m)
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.B = append(w.B, p...)
+	return len(p), nil
+}
+
+func (w *Writer) WriteByte(c byte) error {
+	w.B = append(w.B, c)
+	return nil
+}
+
+func (w *Writer) WriteString(s string) (int, error) {
+	w.B = append(w.B, s...)
+	return len(s), nil
+}
@@ -0,0 +1,142 @@
+package lru
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache 并发安全、带过期时间的 LRU
+// 用于库内部的小容量本地缓存（例如 stale 兜底、热点 key 本地提升）
+type Cache[V any] struct {
+	capacity int
+	now      func() time.Time
+
+	mu      sync.RWMutex
+	ll      *list.List
+	entries map[string]*list.Element
+}
+
+type entry[V any] struct {
+	key      string
+	val      V
+	expireAt time.Time
+}
+
+// New capacity <= 0 时不限制容量
+func New[V any](capacity int) *Cache[V] {
+	return &Cache[V]{
+		capacity: capacity,
+		now:      time.Now,
+		ll:       list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get 返回未过期的值与剩余存活时间，永不过期时剩余时间为 0
+func (c *Cache[V]) Get(key string) (V, time.Duration, bool) {
+	var zero V
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return zero, 0, false
+	}
+	e := elem.Value.(*entry[V])
+	var ttl time.Duration
+	if !e.expireAt.IsZero() {
+		ttl = e.expireAt.Sub(c.now())
+		if ttl <= 0 {
+			c.removeElement(elem)
+			return zero, 0, false
+		}
+	}
+	c.ll.MoveToFront(elem)
+	return e.val, ttl, true
+}
+
+// Peek 与 Get 相同，但只持有读锁，不更新最近使用顺序，也不清理过期的值
+func (c *Cache[V]) Peek(key string) (V, time.Duration, bool) {
+	var zero V
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return zero, 0, false
+	}
+	e := elem.Value.(*entry[V])
+	var ttl time.Duration
+	if !e.expireAt.IsZero() {
+		ttl = e.expireAt.Sub(c.now())
+		if ttl <= 0 {
+			return zero, 0, false
+		}
+	}
+	return e.val, ttl, true
+}
+
+// Set ttl <= 0 表示永不过期
+func (c *Cache[V]) Set(key string, val V, ttl time.Duration) {
+	var expireAt time.Time
+	if ttl > 0 {
+		expireAt = c.now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		e := elem.Value.(*entry[V])
+		e.val = val
+		e.expireAt = expireAt
+		c.ll.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.ll.PushFront(&entry[V]{key: key, val: val, expireAt: expireAt})
+	for c.capacity > 0 && c.ll.Len() > c.capacity {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+func (c *Cache[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+func (c *Cache[V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.entries = make(map[string]*list.Element)
+}
+
+// Keys 返回当前所有未过期的 key，按最近使用排序
+func (c *Cache[V]) Keys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	now := c.now()
+	keys := make([]string, 0, c.ll.Len())
+	for elem := c.ll.Front(); elem != nil; elem = elem.Next() {
+		e := elem.Value.(*entry[V])
+		if e.expireAt.IsZero() || e.expireAt.After(now) {
+			keys = append(keys, e.key)
+		}
+	}
+	return keys
+}
+
+func (c *Cache[V]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ll.Len()
+}
+
+func (c *Cache[V]) removeElement(elem *list.Element) {
+	c.ll.Remove(elem)
+	delete(c.entries, elem.Value.(*entry[V]).key)
+}
@@ -0,0 +1,63 @@
+package lru
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Now()
+	c := New[int](2)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1, time.Minute)
+	c.Set("b", 2, 0)
+	v, ttl, ok := c.Get("a")
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+	require.Equal(t, time.Minute, ttl)
+
+	// a 最近被访问，淘汰 b
+	c.Set("c", 3, 0)
+	_, _, ok = c.Get("b")
+	require.False(t, ok)
+	require.Equal(t, []string{"c", "a"}, c.Keys())
+
+	now = now.Add(2 * time.Minute)
+	_, _, ok = c.Get("a")
+	require.False(t, ok)
+	require.Equal(t, 1, c.Len())
+
+	c.Delete("c")
+	require.Equal(t, 0, c.Len())
+
+	c.Set("d", 4, 0)
+	c.Clear()
+	require.Equal(t, 0, c.Len())
+}
+
+func TestCachePeek(t *testing.T) {
+	now := time.Now()
+	c := New[int](2)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1, time.Minute)
+	c.Set("b", 2, 0)
+	v, ttl, ok := c.Peek("a")
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+	require.Equal(t, time.Minute, ttl)
+
+	// Peek 不更新最近使用顺序，淘汰 a
+	c.Set("c", 3, 0)
+	_, _, ok = c.Peek("a")
+	require.False(t, ok)
+
+	now = now.Add(2 * time.Minute)
+	c.Set("d", 4, time.Minute)
+	now = now.Add(2 * time.Minute)
+	_, _, ok = c.Peek("d")
+	require.False(t, ok)
+}
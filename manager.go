@@ -0,0 +1,234 @@
+package cachalot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/lifecycle"
+	"github.com/yikakia/cachalot/core/multicache"
+	"github.com/yikakia/cachalot/core/stats"
+)
+
+// ErrManagerClosed Manager 关闭后注册缓存或再次关闭时返回
+var ErrManagerClosed = errors.New("cachalot: manager closed")
+
+// CacheKind 注册到 Manager 的缓存类型
+type CacheKind string
+
+const (
+	KindCache      CacheKind = "cache"
+	KindMultiCache CacheKind = "multi_cache"
+)
+
+// CacheInfo 注册到 Manager 的缓存的描述
+type CacheInfo struct {
+	Name string
+	Kind CacheKind
+	// 由外到内的装配计划，与 Describe 的返回值相同
+	Plan Plan
+	// 多级缓存的各层，由前到后，已注册的缓存使用其名称，否则为 tier[i]
+	Tiers []string
+	// 是否通过 WithStats 开启了统计
+	StatsEnabled bool
+}
+
+type managedEntry struct {
+	info  CacheInfo
+	cache any
+	stats stats.Reporter
+}
+
+// Manager 按名称登记通过 Builder / MultiBuilder 构建的缓存，并统一管理后台任务的关闭
+//
+// 通过 Builder.WithManager、MultiBuilder.WithManager 注册，名称在同一个 Manager 内唯一。
+// Close 依次：拒绝新的注册与后台任务，等待 Background 中的异步写回、刷新等任务结束，
+// 最后按注册的逆序执行 OnClose 注册的关闭函数
+type Manager struct {
+	mu      sync.Mutex
+	entries map[string]*managedEntry
+	order   []string
+	closers []func(ctx context.Context) error
+	closed  bool
+
+	background lifecycle.Group
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		entries: make(map[string]*managedEntry),
+	}
+}
+
+// Background 返回 Manager 跟踪的后台任务组，如传给 write_back.Builder.Group，Close 时等待其中的任务结束
+func (m *Manager) Background() *lifecycle.Group {
+	return &m.background
+}
+
+// OnClose 注册在后台任务结束后执行的关闭函数，如停止布隆过滤器轮换、关闭 Store 客户端
+//
+// 按注册的逆序执行，Manager 已关闭时不再执行
+func (m *Manager) OnClose(fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.closed {
+		m.closers = append(m.closers, fn)
+	}
+}
+
+// List 按注册顺序返回所有缓存的描述
+func (m *Manager) List() []CacheInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	infos := make([]CacheInfo, 0, len(m.order))
+	for _, name := range m.order {
+		infos = append(infos, m.entries[name].info)
+	}
+	return infos
+}
+
+// Info 返回指定名称的缓存的描述
+func (m *Manager) Info(name string) (CacheInfo, bool) {
+	e, ok := m.entry(name)
+	if !ok {
+		return CacheInfo{}, false
+	}
+	return e.info, true
+}
+
+// Stats 返回指定名称的缓存的统计快照，缓存不存在或未开启统计时返回 false
+func (m *Manager) Stats(name string) (stats.Snapshot, bool) {
+	e, ok := m.entry(name)
+	if !ok || e.stats == nil {
+		return stats.Snapshot{}, false
+	}
+	return e.stats.Stats(), true
+}
+
+// Lookup 按名称获取单缓存，名称不存在或类型不匹配时返回 false
+func Lookup[T any](m *Manager, name string) (cache.Cache[T], bool) {
+	e, ok := m.entry(name)
+	if !ok {
+		return nil, false
+	}
+	c, ok := e.cache.(cache.Cache[T])
+	return c, ok && e.info.Kind == KindCache
+}
+
+// LookupMulti 按名称获取多级缓存，名称不存在或类型不匹配时返回 false
+func LookupMulti[T any](m *Manager, name string) (multicache.MultiCache[T], bool) {
+	e, ok := m.entry(name)
+	if !ok {
+		return nil, false
+	}
+	c, ok := e.cache.(multicache.MultiCache[T])
+	return c, ok && e.info.Kind == KindMultiCache
+}
+
+// Close 关闭 Manager，ctx 结束时不再等待后台任务，但仍会执行关闭函数
+//
+// 返回后台任务等待超时与各关闭函数的错误，再次调用返回 ErrManagerClosed
+func (m *Manager) Close(ctx context.Context) error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return ErrManagerClosed
+	}
+	m.closed = true
+	closers := m.closers
+	m.closers = nil
+	m.mu.Unlock()
+
+	var errs []error
+	if err := m.background.Close(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("drain background tasks: %w", err))
+	}
+	for _, fn := range slices.Backward(closers) {
+		errs = append(errs, fn(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Manager) entry(name string) (*managedEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[name]
+	return e, ok
+}
+
+func (m *Manager) register(e *managedEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrManagerClosed
+	}
+	if _, ok := m.entries[e.info.Name]; ok {
+		return fmt.Errorf("cache [%s] already registered", e.info.Name)
+	}
+	m.entries[e.info.Name] = e
+	m.order = append(m.order, e.info.Name)
+	return nil
+}
+
+// tierName 已注册的缓存返回其名称，调用方需持有锁
+func (m *Manager) tierName(c any, i int) string {
+	for _, name := range m.order {
+		if sameCache(m.entries[name].cache, c) {
+			return name
+		}
+	}
+	return fmt.Sprintf("tier[%d]", i)
+}
+
+// 动态类型不可比较时直接比较接口值会 panic
+func sameCache(a, b any) bool {
+	t := reflect.TypeOf(a)
+	if t == nil || t != reflect.TypeOf(b) || !t.Comparable() {
+		return false
+	}
+	return a == b
+}
+
+func registerCache[T any](m *Manager, b *Builder[T], c cache.Cache[T]) error {
+	e := &managedEntry{
+		info: CacheInfo{
+			Name:         b.cacheName,
+			Kind:         KindCache,
+			Plan:         c.(Describer).Describe(),
+			StatsEnabled: b.features.stats,
+		},
+		cache: c,
+	}
+	if b.features.stats {
+		e.stats, _ = c.(stats.Reporter)
+	}
+	return m.register(e)
+}
+
+func registerMultiCache[T any](m *Manager, b *MultiBuilder[T], c multicache.MultiCache[T]) error {
+	m.mu.Lock()
+	tiers := make([]string, 0, len(b.caches))
+	for i, tier := range b.caches {
+		tiers = append(tiers, m.tierName(tier, i))
+	}
+	m.mu.Unlock()
+
+	e := &managedEntry{
+		info: CacheInfo{
+			Name:         b.name,
+			Kind:         KindMultiCache,
+			Plan:         c.(Describer).Describe(),
+			Tiers:        tiers,
+			StatsEnabled: b.stats,
+		},
+		cache: c,
+	}
+	if b.stats {
+		e.stats, _ = c.(stats.Reporter)
+	}
+	return m.register(e)
+}
@@ -2,11 +2,15 @@ package cachalot
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/yikakia/cachalot/core/cache"
 	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/hotkey"
+	"github.com/yikakia/cachalot/core/interceptor"
 	"github.com/yikakia/cachalot/core/multicache"
+	"github.com/yikakia/cachalot/core/stats"
 	"github.com/yikakia/cachalot/core/telemetry"
 )
 
@@ -18,8 +22,13 @@ type MultiBuilder[T any] struct {
 	needLoaderFnNilCheck bool
 
 	singleFlight bool
+	stats        bool
 	metrics      telemetry.Metrics
 	logger       telemetry.Logger
+	keyRedactor  telemetry.KeyRedactor
+	logging      *interceptor.LoggingConfig
+	watchdog     *decorator.WatchdogConfig
+	manager      *Manager
 	cfg          multicache.Config[T]
 }
 
@@ -87,6 +96,67 @@ func (b *MultiBuilder[T]) WithErrorHandling(mode multicache.ErrorHandleMode) *Mu
 	return b
 }
 
+// WithStaleIfError 开启 stale-if-error
+// 在本地旁路存储中保留最近读到或写入的值 grace 时间，仅当存储故障且回源也失败时返回旧值
+// maxEntries <= 0 时默认为 10000
+func (b *MultiBuilder[T]) WithStaleIfError(grace time.Duration, maxEntries int) *MultiBuilder[T] {
+	if grace <= 0 {
+		b.err = errors.Join(b.err, fmt.Errorf("stale-if-error grace require > 0, but got: %v", grace))
+		return b
+	}
+	b.cfg.StaleIfError = &multicache.StaleIfErrorConfig{
+		Grace:      grace,
+		MaxEntries: maxEntries,
+	}
+	return b
+}
+
+// WithHotKeyDetection 开启热点 key 探测
+// promoteTTL > 0 时会将热点 key 提升到进程内的本地缓存，存活 promoteTTL
+func (b *MultiBuilder[T]) WithHotKeyDetection(detector *hotkey.Detector, promoteTTL time.Duration) *MultiBuilder[T] {
+	if detector == nil {
+		b.err = errors.Join(b.err, errors.New("hot key detector is required"))
+		return b
+	}
+	b.cfg.HotKey = &multicache.HotKeyConfig{
+		Detector:   detector,
+		PromoteTTL: promoteTTL,
+	}
+	return b
+}
+
+// WithInterceptors 追加操作拦截器，按声明顺序串联，先声明的位于外层，位于观测层的内层
+func (b *MultiBuilder[T]) WithInterceptors(interceptors ...interceptor.Interceptor) *MultiBuilder[T] {
+	b.cfg.Interceptors = append(b.cfg.Interceptors, interceptors...)
+	return b
+}
+
+// WithLogging 开启访问日志，位于所有拦截器外层
+//
+// WithLogger 传入的 Logger 会按 cfg.Throttle 限流，多级缓存自身的错误日志与访问日志共用同一个限流器
+func (b *MultiBuilder[T]) WithLogging(cfg interceptor.LoggingConfig) *MultiBuilder[T] {
+	b.logging = &cfg
+	return b
+}
+
+// WithStats 开启进程内统计，与 WithMetrics 传入的指标同时上报，通过 StatsOfMulti 获取
+func (b *MultiBuilder[T]) WithStats(enabled bool) *MultiBuilder[T] {
+	b.stats = enabled
+	return b
+}
+
+// WithLoaderWatchdog 监控回源调用，超过 cfg.SoftDeadline 仍未返回时告警，不会取消调用
+//
+// watchdog 位于 singleflight 内层，只对实际执行的回源告警
+func (b *MultiBuilder[T]) WithLoaderWatchdog(cfg decorator.WatchdogConfig) *MultiBuilder[T] {
+	if cfg.SoftDeadline <= 0 {
+		b.err = errors.Join(b.err, fmt.Errorf("watchdog soft deadline require > 0, but got: %v", cfg.SoftDeadline))
+		return b
+	}
+	b.watchdog = &cfg
+	return b
+}
+
 // WithSingleflight LoaderFn 的 singleflight 封装 默认开启
 func (b *MultiBuilder[T]) WithSingleflight(enabled bool) *MultiBuilder[T] {
 	b.singleFlight = enabled
@@ -103,6 +173,12 @@ func (b *MultiBuilder[T]) WithMetrics(metrics telemetry.Metrics) *MultiBuilder[T
 	return b
 }
 
+// WithKeyRedactor 在观测事件中记录 key，redactor 决定记录的内容，如 telemetry.HashKey、telemetry.KeyFamily
+func (b *MultiBuilder[T]) WithKeyRedactor(redactor telemetry.KeyRedactor) *MultiBuilder[T] {
+	b.keyRedactor = redactor
+	return b
+}
+
 // WithRequiredLoader 设置是否必须提供 LoaderFn。
 // 当策略不依赖 LoaderFn 时可设置为 false。
 func (b *MultiBuilder[T]) WithRequiredLoader(enabled bool) *MultiBuilder[T] {
@@ -110,6 +186,16 @@ func (b *MultiBuilder[T]) WithRequiredLoader(enabled bool) *MultiBuilder[T] {
 	return b
 }
 
+// WithManager Build 成功后以缓存名称注册到 m，名称已存在或 m 已关闭时 Build 返回错误
+//
+// 已注册到同一个 m 的单缓存在 CacheInfo.Tiers 中以其名称展示。
+// 未指定 Group 的异步写回（write_back.Builder{Async: true}）会提交到 m.Background()，m.Close 时等待其结束；
+// 自定义的 WriteBackFn 需要自行使用 lifecycle.GroupFromContext 或 m.Background() 跟踪后台任务
+func (b *MultiBuilder[T]) WithManager(m *Manager) *MultiBuilder[T] {
+	b.manager = m
+	return b
+}
+
 // Build 构建 MultiCache
 func (b *MultiBuilder[T]) Build() (multicache.MultiCache[T], error) {
 	if b.err != nil {
@@ -121,14 +207,54 @@ func (b *MultiBuilder[T]) Build() (multicache.MultiCache[T], error) {
 	}
 
 	finalCfg := b.cfg
-	if b.singleFlight {
-		finalCfg.LoaderFn = decorator.SingleflightWrapper(finalCfg.LoaderFn)
+	logger := b.logger
+	if b.logging != nil {
+		logger = telemetry.NewThrottledLogger(b.logger, b.logging.Throttle)
+		cfg := *b.logging
+		cfg.Logger = b.logger
+		if b.keyRedactor != nil {
+			cfg.KeyRedactor = b.keyRedactor
+		}
+		// 访问日志位于最外层，记录的耗时包含其他拦截器
+		finalCfg.Interceptors = append([]interceptor.Interceptor{interceptor.Logging(cfg)}, finalCfg.Interceptors...)
+	}
+
+	metrics := b.metrics
+	var collector *stats.Collector
+	if b.stats {
+		collector = stats.NewCollector()
+		metrics = teeMetrics{b.metrics, collector}
+	}
+
+	if b.manager != nil {
+		finalCfg.Background = b.manager.Background()
 	}
 
 	finalCfg.Observable = &telemetry.Observable{
-		Metrics: b.metrics,
-		Logger:  b.logger,
+		Metrics:     metrics,
+		Logger:      logger,
+		KeyRedactor: b.keyRedactor,
 	}
 
-	return multicache.New(b.name, finalCfg, b.caches...)
+	if b.watchdog != nil && finalCfg.LoaderFn != nil {
+		finalCfg.LoaderFn = decorator.WatchLoader(decorator.NewWatchdog(*b.watchdog, finalCfg.Observable), b.name, finalCfg.LoaderFn)
+	}
+	if b.singleFlight {
+		finalCfg.LoaderFn = decorator.SingleflightWrapper(finalCfg.LoaderFn)
+	}
+
+	c, err := multicache.New(b.name, finalCfg, b.caches...)
+	if err != nil {
+		return nil, err
+	}
+	c = &describedMultiCache[T]{MultiCache: c, plan: b.plan()}
+	if collector != nil {
+		c = &statsMultiCache[T]{describedMultiCache: c.(*describedMultiCache[T]), collector: collector}
+	}
+	if b.manager != nil {
+		if err := registerMultiCache(b.manager, b, c); err != nil {
+			return nil, fmt.Errorf("register multi cache [%s] failed: %w", b.name, err)
+		}
+	}
+	return c, nil
 }
@@ -0,0 +1,127 @@
+// Package otel 提供基于 OpenTelemetry 的指标与链路追踪适配
+package otel
+
+import (
+	"context"
+
+	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ScopeName 默认的 instrumentation scope 名称
+const ScopeName = "github.com/yikakia/cachalot/observability/otel"
+
+const (
+	attrCache  = attribute.Key("cachalot.cache")
+	attrStore  = attribute.Key("cachalot.store")
+	attrOp     = attribute.Key("cachalot.op")
+	attrResult = attribute.Key("cachalot.result")
+)
+
+// 非查询类操作没有 hit/miss 语义，按是否出错区分
+const (
+	resultOK   = "ok"
+	resultFail = "fail"
+)
+
+type metricsConfig struct {
+	latencyBuckets []float64
+}
+
+type MetricsOption func(*metricsConfig)
+
+// WithLatencyBuckets 自定义耗时直方图的分桶边界 单位为秒
+func WithLatencyBuckets(buckets ...float64) MetricsOption {
+	return func(c *metricsConfig) {
+		c.latencyBuckets = buckets
+	}
+}
+
+// Metrics 将观测事件转换为 OpenTelemetry 指标
+//
+//	cachalot.operations         操作次数，按 cache/store/op/result 打标
+//	cachalot.operation.duration 操作耗时直方图（秒），标签同上
+//	cachalot.logic_expire       逻辑过期次数，按 cache/store 打标
+type Metrics struct {
+	operations  metric.Int64Counter
+	latency     metric.Float64Histogram
+	logicExpire metric.Int64Counter
+}
+
+var _ telemetry.Metrics = (*Metrics)(nil)
+var _ decorator.LogicTTLMetrics = (*Metrics)(nil)
+
+func NewMetrics(meter metric.Meter, opts ...MetricsOption) (*Metrics, error) {
+	cfg := &metricsConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	operations, err := meter.Int64Counter("cachalot.operations",
+		metric.WithDescription("Number of cache operations."),
+		metric.WithUnit("{operation}"))
+	if err != nil {
+		return nil, err
+	}
+
+	latencyOpts := []metric.Float64HistogramOption{
+		metric.WithDescription("Duration of cache operations."),
+		metric.WithUnit("s"),
+	}
+	if len(cfg.latencyBuckets) > 0 {
+		latencyOpts = append(latencyOpts, metric.WithExplicitBucketBoundaries(cfg.latencyBuckets...))
+	}
+	latency, err := meter.Float64Histogram("cachalot.operation.duration", latencyOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	logicExpire, err := meter.Int64Counter("cachalot.logic_expire",
+		metric.WithDescription("Number of logically expired values served."),
+		metric.WithUnit("{value}"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Metrics{
+		operations:  operations,
+		latency:     latency,
+		logicExpire: logicExpire,
+	}, nil
+}
+
+func (m *Metrics) Record(ctx context.Context, evt *telemetry.Event) error {
+	attrs := metric.WithAttributes(
+		attrCache.String(evt.CacheName),
+		attrStore.String(evt.StoreName),
+		attrOp.String(string(evt.Op)),
+		attrResult.String(resultOf(evt)),
+	)
+	m.operations.Add(ctx, 1, attrs)
+	m.latency.Record(ctx, evt.Latency.Seconds(), attrs)
+	return nil
+}
+
+// RecordLogicExpire 从上下文中的观测事件获取 cache 与 store 标签
+func (m *Metrics) RecordLogicExpire(ctx context.Context) {
+	var cacheName, storeName string
+	if evt, ok := telemetry.EventFromContext(ctx); ok {
+		cacheName, storeName = evt.CacheName, evt.StoreName
+	}
+	m.logicExpire.Add(ctx, 1, metric.WithAttributes(
+		attrCache.String(cacheName),
+		attrStore.String(storeName),
+	))
+}
+
+func resultOf(evt *telemetry.Event) string {
+	if evt.Result != "" {
+		return string(evt.Result)
+	}
+	if evt.Error != nil {
+		return resultFail
+	}
+	return resultOK
+}
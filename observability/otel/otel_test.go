@@ -0,0 +1,195 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot"
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/multicache"
+	"github.com/yikakia/cachalot/stores/storetests"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMetricsRecord(t *testing.T) {
+	ctx := context.Background()
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	metrics, err := NewMetrics(provider.Meter(ScopeName), WithLatencyBuckets(0.001, 0.01, 0.1))
+	require.NoError(t, err)
+
+	builder, err := cachalot.NewBuilder[string]("users", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	c, err := builder.WithMetrics(metrics).Build()
+	require.NoError(t, err)
+
+	require.NoError(t, c.Set(ctx, "k", "v", time.Minute))
+	_, err = c.Get(ctx, "k")
+	require.NoError(t, err)
+	_, err = c.Get(ctx, "k404")
+	require.ErrorIs(t, err, cache.ErrNotFound)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	counts := map[string]int64{}
+	var histogramCount uint64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				require.Equal(t, "cachalot.operations", m.Name)
+				for _, dp := range data.DataPoints {
+					cacheName, _ := dp.Attributes.Value(attrCache)
+					storeName, _ := dp.Attributes.Value(attrStore)
+					require.Equal(t, "users", cacheName.AsString())
+					require.Equal(t, "memory", storeName.AsString())
+					op, _ := dp.Attributes.Value(attrOp)
+					result, _ := dp.Attributes.Value(attrResult)
+					counts[op.AsString()+"/"+result.AsString()] += dp.Value
+				}
+			case metricdata.Histogram[float64]:
+				require.Equal(t, "cachalot.operation.duration", m.Name)
+				for _, dp := range data.DataPoints {
+					require.Equal(t, []float64{0.001, 0.01, 0.1}, dp.Bounds)
+					histogramCount += dp.Count
+				}
+			}
+		}
+	}
+
+	require.Equal(t, map[string]int64{
+		"set/ok":   1,
+		"get/hit":  1,
+		"get/miss": 1,
+	}, counts)
+	require.Equal(t, uint64(3), histogramCount)
+}
+
+func spanByName(t *testing.T, spans []sdktrace.ReadOnlySpan, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	for _, s := range spans {
+		if s.Name() == name {
+			return s
+		}
+	}
+	t.Fatalf("span %s not found", name)
+	return nil
+}
+
+func attrsOf(s sdktrace.ReadOnlySpan) map[attribute.Key]string {
+	res := map[attribute.Key]string{}
+	for _, kv := range s.Attributes() {
+		res[kv.Key] = kv.Value.Emit()
+	}
+	return res
+}
+
+func TestTracingDecorator(t *testing.T) {
+	ctx := context.Background()
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer(ScopeName)
+
+	builder, err := cachalot.NewBuilder[string]("users", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	c, err := builder.
+		WithCacheMissLoader(TraceLoader(tracer, func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			return "loaded-" + key, nil
+		})).
+		WithOptions(cache.WithDecorator(NewTracingDecorator[string](tracer))).
+		Build()
+	require.NoError(t, err)
+
+	val, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	require.Equal(t, "loaded-k", val)
+
+	spans := recorder.Ended()
+	root := spanByName(t, spans, "cachalot.get")
+	require.False(t, root.Parent().IsValid())
+	attrs := attrsOf(root)
+	require.Equal(t, "users", attrs[attrCache])
+	require.Equal(t, "memory", attrs[attrStore])
+	require.Equal(t, "hit", attrs[attrResult])
+	require.Equal(t, "false", attrs["cachalot.shared"])
+	require.Equal(t, "true", attrs["cachalot.loader_ran"])
+
+	loader := spanByName(t, spans, spanLoader)
+	require.Equal(t, root.SpanContext().SpanID(), loader.Parent().SpanID())
+	// 只装配一次，回写是 get 的子 span
+	writeBack := spanByName(t, spans, spanWriteBack)
+	require.Equal(t, root.SpanContext().SpanID(), writeBack.Parent().SpanID())
+	require.Len(t, spans, 3)
+}
+
+func TestTracingDecoratorRecordsError(t *testing.T) {
+	ctx := context.Background()
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer(ScopeName)
+
+	loadErr := errors.New("db down")
+	builder, err := cachalot.NewBuilder[string]("users", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	c, err := builder.
+		WithCacheMissLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			return "", loadErr
+		}).
+		WithOptions(cache.WithDecorator(NewTracingDecorator[string](tracer))).
+		Build()
+	require.NoError(t, err)
+
+	_, err = c.Get(ctx, "k")
+	require.ErrorIs(t, err, loadErr)
+
+	span := spanByName(t, recorder.Ended(), "cachalot.get")
+	require.Equal(t, "Error", span.Status().Code.String())
+	require.Equal(t, "fail", attrsOf(span)[attrResult])
+}
+
+func TestTracingMultiCache(t *testing.T) {
+	ctx := context.Background()
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer(ScopeName)
+
+	newCache := func(name string) cache.Cache[string] {
+		b, err := cachalot.NewBuilder[string](name, storetests.NewMemoryStore())
+		require.NoError(t, err)
+		c, err := b.Build()
+		require.NoError(t, err)
+		return c
+	}
+
+	mc, err := cachalot.NewMultiBuilder[string]("multi", newCache("l1"), newCache("l2")).
+		WithLoader(TraceLoader(tracer, func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			return "loaded-" + key, nil
+		})).
+		WithWriteBack(TraceWriteBack(tracer, multicache.WriteBackParallel[string](time.Minute))).
+		Build()
+	require.NoError(t, err)
+	mc = NewTracingMultiCache(mc, tracer)
+
+	val, err := mc.Get(ctx, "k")
+	require.NoError(t, err)
+	require.Equal(t, "loaded-k", val)
+
+	spans := recorder.Ended()
+	root := spanByName(t, spans, "cachalot.get")
+	attrs := attrsOf(root)
+	require.Equal(t, "multi", attrs[attrCache])
+	require.Equal(t, "loader", attrs["cachalot.source"])
+	require.Equal(t, "true", attrs["cachalot.loader_ran"])
+
+	writeBack := spanByName(t, spans, spanWriteBack)
+	require.Equal(t, root.SpanContext().SpanID(), writeBack.Parent().SpanID())
+	require.Equal(t, "2", attrsOf(writeBack)["cachalot.write_back.caches"])
+	loader := spanByName(t, spans, spanLoader)
+	require.Equal(t, root.SpanContext().TraceID(), loader.SpanContext().TraceID())
+}
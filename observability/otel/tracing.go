@@ -0,0 +1,205 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/multicache"
+	"github.com/yikakia/cachalot/core/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	spanPrefix       = "cachalot."
+	spanLoader       = "cachalot.loader"
+	spanWriteBack    = "cachalot.write_back"
+	customAttrPrefix = "cachalot."
+)
+
+// NewTracingDecorator 为每次缓存操作创建一个 span
+//
+// 通过 Builder.WithOptions(cache.WithDecorator(...)) 装配在观测层外侧时，
+// 观测层记录完事件后会把 store、result 以及 shared、source 等自定义字段写入 span。
+// Get 过程中回源结果的写回（包括负缓存的墓碑）记录为名为 cachalot.write_back 的子 span，无需在内层重复装配
+// 多级缓存的回写见 TraceWriteBack
+func NewTracingDecorator[T any](tracer trace.Tracer) cache.Decorator[T] {
+	return func(c cache.Cache[T], _ *telemetry.Observable) (cache.Cache[T], error) {
+		return &tracingDecorator[T]{cache: c, tracer: tracer}, nil
+	}
+}
+
+type tracingDecorator[T any] struct {
+	cache  cache.Cache[T]
+	tracer trace.Tracer
+}
+
+var _ cache.Cache[any] = (*tracingDecorator[any])(nil)
+
+func (d *tracingDecorator[T]) Get(ctx context.Context, key string, opts ...cache.CallOption) (_ T, err error) {
+	ctx, span := startSpan(ctx, d.tracer, telemetry.OpGet)
+	defer func() { endSpan(span, err) }()
+
+	return d.cache.Get(ctx, key, opts...)
+}
+
+func (d *tracingDecorator[T]) Set(ctx context.Context, key string, val T, ttl time.Duration, opts ...cache.CallOption) (err error) {
+	ctx, span := startSpan(ctx, d.tracer, telemetry.OpSet)
+	defer func() { endSpan(span, err) }()
+
+	return d.cache.Set(ctx, key, val, ttl, opts...)
+}
+
+func (d *tracingDecorator[T]) GetWithTTL(ctx context.Context, key string, opts ...cache.CallOption) (_ T, _ time.Duration, err error) {
+	ctx, span := startSpan(ctx, d.tracer, telemetry.OpGetWithTTL)
+	defer func() { endSpan(span, err) }()
+
+	return d.cache.GetWithTTL(ctx, key, opts...)
+}
+
+func (d *tracingDecorator[T]) Delete(ctx context.Context, key string, opts ...cache.CallOption) (err error) {
+	ctx, span := startSpan(ctx, d.tracer, telemetry.OpDelete)
+	defer func() { endSpan(span, err) }()
+
+	return d.cache.Delete(ctx, key, opts...)
+}
+
+func (d *tracingDecorator[T]) Clear(ctx context.Context) (err error) {
+	ctx, span := startSpan(ctx, d.tracer, telemetry.OpClear)
+	defer func() { endSpan(span, err) }()
+
+	return d.cache.Clear(ctx)
+}
+
+// NewTracingMultiCache 为多级缓存的每次操作创建一个 span，自定义字段的写入方式与 NewTracingDecorator 一致
+func NewTracingMultiCache[T any](mc multicache.MultiCache[T], tracer trace.Tracer) multicache.MultiCache[T] {
+	return &tracingMultiCache[T]{MultiCache: mc, tracer: tracer}
+}
+
+type tracingMultiCache[T any] struct {
+	multicache.MultiCache[T]
+	tracer trace.Tracer
+}
+
+func (m *tracingMultiCache[T]) Get(ctx context.Context, key string, opts ...cache.CallOption) (_ T, err error) {
+	ctx, span := startSpan(ctx, m.tracer, telemetry.OpGet)
+	defer func() { endSpan(span, err) }()
+
+	return m.MultiCache.Get(ctx, key, opts...)
+}
+
+func (m *tracingMultiCache[T]) Set(ctx context.Context, key string, val T, ttl time.Duration, opts ...cache.CallOption) (err error) {
+	ctx, span := startSpan(ctx, m.tracer, telemetry.OpSet)
+	defer func() { endSpan(span, err) }()
+
+	return m.MultiCache.Set(ctx, key, val, ttl, opts...)
+}
+
+func (m *tracingMultiCache[T]) Delete(ctx context.Context, key string, opts ...cache.CallOption) (err error) {
+	ctx, span := startSpan(ctx, m.tracer, telemetry.OpDelete)
+	defer func() { endSpan(span, err) }()
+
+	return m.MultiCache.Delete(ctx, key, opts...)
+}
+
+func (m *tracingMultiCache[T]) Clear(ctx context.Context) (err error) {
+	ctx, span := startSpan(ctx, m.tracer, telemetry.OpClear)
+	defer func() { endSpan(span, err) }()
+
+	return m.MultiCache.Clear(ctx)
+}
+
+// FetchByLoader 直接调用时的操作 span，Get 中的回源不经过该方法，需要通过 TraceLoader 为回源函数创建子 span
+func (m *tracingMultiCache[T]) FetchByLoader(ctx context.Context, key string, opts ...cache.CallOption) (_ T, err error) {
+	ctx, span := startSpan(ctx, m.tracer, telemetry.OpFetchByLoader)
+	defer func() { endSpan(span, err) }()
+
+	return m.MultiCache.FetchByLoader(ctx, key, opts...)
+}
+
+// TraceLoader 为回源函数创建子 span
+func TraceLoader[T any](tracer trace.Tracer, fn decorator.LoaderFn[T]) decorator.LoaderFn[T] {
+	return func(ctx context.Context, key string, opts ...cache.CallOption) (_ T, err error) {
+		ctx, span := tracer.Start(ctx, spanLoader)
+		defer func() { endSpan(span, err) }()
+
+		return fn(ctx, key, opts...)
+	}
+}
+
+// TraceWriteBack 为多级缓存的回写函数创建子 span
+func TraceWriteBack[T any](tracer trace.Tracer, fn multicache.WriteBackFn[T]) multicache.WriteBackFn[T] {
+	return func(ctx context.Context, getCtx *multicache.FetchContext[T], caches []cache.Cache[T]) (err error) {
+		ctx, span := tracer.Start(ctx, spanWriteBack,
+			trace.WithAttributes(attribute.Int("cachalot.write_back.caches", len(caches))))
+		defer func() { endSpan(span, err) }()
+
+		return fn(ctx, getCtx, caches)
+	}
+}
+
+func startSpan(ctx context.Context, tracer trace.Tracer, op telemetry.Op) (context.Context, trace.Span) {
+	ctx, span := tracer.Start(ctx, spanPrefix+string(op), trace.WithAttributes(attrOp.String(string(op))))
+	if !span.IsRecording() {
+		return ctx, span
+	}
+	if op == telemetry.OpGet || op == telemetry.OpGetWithTTL {
+		ctx = telemetry.ContextWithWriteBackHook(ctx, func(ctx context.Context) (context.Context, func(error)) {
+			ctx, span := tracer.Start(ctx, spanWriteBack)
+			return ctx, func(err error) { endSpan(span, err) }
+		})
+	}
+	return telemetry.ContextWithEventListener(ctx, func(_ context.Context, evt *telemetry.Event) {
+		annotateSpan(span, evt)
+	}), span
+}
+
+func annotateSpan(span trace.Span, evt *telemetry.Event) {
+	attrs := []attribute.KeyValue{
+		attrCache.String(evt.CacheName),
+		attrResult.String(resultOf(evt)),
+	}
+	if evt.StoreName != "" {
+		attrs = append(attrs, attrStore.String(evt.StoreName))
+	}
+
+	d := evt.Details()
+	if d.Key != "" {
+		attrs = append(attrs, attribute.String("cachalot.key", d.Key))
+	}
+	if d.EncodedBytes > 0 {
+		attrs = append(attrs, attribute.Int("cachalot.payload.encoded_bytes", d.EncodedBytes))
+	}
+	if d.CompressedBytes > 0 {
+		attrs = append(attrs, attribute.Int("cachalot.payload.compressed_bytes", d.CompressedBytes))
+	}
+	if d.HasTier {
+		attrs = append(attrs, attribute.Int("cachalot.tier", d.Tier))
+	}
+	attrs = append(attrs, attribute.Bool("cachalot.loader_ran", d.LoaderRan))
+
+	fields := evt.FrozenCustomFields()
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		attrs = append(attrs, attribute.String(customAttrPrefix+k, fields[k]))
+	}
+	span.SetAttributes(attrs...)
+}
+
+// 未命中属于正常结果，不标记为错误
+func endSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, cache.ErrNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,465 @@
+// Package prometheus 提供基于 Prometheus client 的 telemetry.Metrics 实现
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/encrypt"
+	"github.com/yikakia/cachalot/core/telemetry"
+)
+
+const (
+	labelCache   = "cache"
+	labelStore   = "store"
+	labelOp      = "op"
+	labelResult  = "result"
+	labelOutcome = "outcome"
+	labelReason  = "reason"
+	labelPolicy  = "policy"
+)
+
+// 非查询类操作没有 hit/miss 语义，按是否出错区分
+const (
+	resultOK   = "ok"
+	resultFail = "fail"
+)
+
+// singleflight 装饰器写入的自定义字段
+const fieldShared = "shared"
+
+var ratioBuckets = prometheus.LinearBuckets(0.1, 0.1, 10)
+
+type config struct {
+	namespace   string
+	registerer  prometheus.Registerer
+	buckets     []float64
+	constLabels prometheus.Labels
+}
+
+type Option func(*config)
+
+// WithNamespace 指标名前缀 默认为 cachalot
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithRegisterer 指定注册的 Registerer 默认为 prometheus.DefaultRegisterer
+func WithRegisterer(registerer prometheus.Registerer) Option {
+	return func(c *config) {
+		c.registerer = registerer
+	}
+}
+
+// WithLatencyBuckets 自定义耗时直方图的分桶边界 单位为秒 默认为 prometheus.DefBuckets
+func WithLatencyBuckets(buckets ...float64) Option {
+	return func(c *config) {
+		c.buckets = buckets
+	}
+}
+
+// WithConstLabels 为所有指标附加固定标签
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(c *config) {
+		c.constLabels = labels
+	}
+}
+
+// Metrics 将观测事件转换为 Prometheus 指标
+//
+//	<namespace>_operations_total               操作次数，按 cache/store/op/result 打标
+//	<namespace>_operation_duration_seconds     操作耗时直方图，按 cache/store/op 打标
+//	<namespace>_singleflight_requests_total    经过 singleflight 的回源请求数，按 cache/store 打标
+//	<namespace>_singleflight_shared_total      其中共享了其他请求结果的请求数，按 cache/store 打标
+//	<namespace>_singleflight_shared_ratio      进程启动以来共享请求占 singleflight 请求的比例，按 cache/store 打标
+//	<namespace>_logic_expire_total             逻辑过期次数，按 cache/store 打标
+//	<namespace>_schema_upgraded_total          旧版本值升级次数，按 cache/store 打标
+//	<namespace>_schema_discarded_total         旧版本值按未命中丢弃的次数，按 cache/store 打标
+//	<namespace>_compression_total              自适应压缩的写入次数，按 cache/store/outcome 打标
+//	<namespace>_compression_ratio              尝试压缩时压缩后与原始长度之比，按 cache/store 打标
+//	<namespace>_decrypt_failures_total         解密失败按未命中处理的次数，按 cache/store/reason 打标
+//	<namespace>_oversize_total                 超过长度限制的写入次数，按 cache/store/policy 打标
+//	<namespace>_bloom_rejected_total           布隆过滤器拦截的请求数，按 cache/store 打标
+//	<namespace>_bloom_false_positive_total     布隆过滤器放行但未命中的请求数，按 cache/store 打标
+//	<namespace>_bloom_false_positive_ratio     当前代布隆过滤器观测到的误判率，过滤器轮换后重新统计，按 cache/store 打标
+//
+// singleflight_shared_ratio 是累计值，需要按时间窗口观察时通过 PromQL 计算，
+// 例如 rate(cachalot_singleflight_shared_total[5m]) / rate(cachalot_singleflight_requests_total[5m])
+//
+// 多个缓存可以共用同一个 Metrics，也可以在同一个 Registerer 上多次 New，已注册的指标会被复用；
+// 复用时标签、说明或直方图分桶与已注册的指标不一致会返回错误
+type Metrics struct {
+	operations  *prometheus.CounterVec
+	latency     *bucketedHistogram
+	sfRequests  *prometheus.CounterVec
+	sfShared    *prometheus.CounterVec
+	sfRatio     *sharedRatio
+	logicExpire *prometheus.CounterVec
+	upgraded    *prometheus.CounterVec
+	discarded   *prometheus.CounterVec
+	compression *prometheus.CounterVec
+	ratio       *bucketedHistogram
+	decrypt     *prometheus.CounterVec
+	oversize    *prometheus.CounterVec
+	bloomReject *prometheus.CounterVec
+	bloomFP     *prometheus.CounterVec
+	bloomFPRate *prometheus.GaugeVec
+}
+
+var _ telemetry.Metrics = (*Metrics)(nil)
+var _ decorator.LogicTTLMetrics = (*Metrics)(nil)
+var _ decorator.SchemaMetrics = (*Metrics)(nil)
+var _ decorator.CompressionMetrics = (*Metrics)(nil)
+var _ decorator.SizeGuardMetrics = (*Metrics)(nil)
+var _ decorator.BloomGuardMetrics = (*Metrics)(nil)
+var _ encrypt.Metrics = (*Metrics)(nil)
+
+// New 创建并注册指标，可直接传给 Builder.WithMetrics 或 MultiBuilder.WithMetrics
+func New(opts ...Option) (*Metrics, error) {
+	cfg := &config{
+		namespace:  "cachalot",
+		registerer: prometheus.DefaultRegisterer,
+		buckets:    prometheus.DefBuckets,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	m := &Metrics{
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "operations_total",
+			Help:        "Number of cache operations by result.",
+			ConstLabels: cfg.constLabels,
+		}, []string{labelCache, labelStore, labelOp, labelResult}),
+		latency: newBucketedHistogram(prometheus.HistogramOpts{
+			Namespace:   cfg.namespace,
+			Name:        "operation_duration_seconds",
+			Help:        "Duration of cache operations.",
+			ConstLabels: cfg.constLabels,
+			Buckets:     cfg.buckets,
+		}, []string{labelCache, labelStore, labelOp}),
+		sfRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "singleflight_requests_total",
+			Help:        "Number of loader requests that went through singleflight.",
+			ConstLabels: cfg.constLabels,
+		}, []string{labelCache, labelStore}),
+		sfShared: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "singleflight_shared_total",
+			Help:        "Number of singleflight requests that shared another caller's result.",
+			ConstLabels: cfg.constLabels,
+		}, []string{labelCache, labelStore}),
+		sfRatio: newSharedRatio(prometheus.NewDesc(
+			prometheus.BuildFQName(cfg.namespace, "", "singleflight_shared_ratio"),
+			"Ratio of singleflight requests that shared another caller's result since start.",
+			[]string{labelCache, labelStore}, cfg.constLabels,
+		)),
+		logicExpire: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "logic_expire_total",
+			Help:        "Number of logically expired values served.",
+			ConstLabels: cfg.constLabels,
+		}, []string{labelCache, labelStore}),
+		upgraded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "schema_upgraded_total",
+			Help:        "Number of cached values upgraded from an older schema version.",
+			ConstLabels: cfg.constLabels,
+		}, []string{labelCache, labelStore}),
+		discarded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "schema_discarded_total",
+			Help:        "Number of cached values discarded as misses due to schema version mismatch.",
+			ConstLabels: cfg.constLabels,
+		}, []string{labelCache, labelStore}),
+		compression: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "compression_total",
+			Help:        "Number of adaptive compression writes by outcome.",
+			ConstLabels: cfg.constLabels,
+		}, []string{labelCache, labelStore, labelOutcome}),
+		ratio: newBucketedHistogram(prometheus.HistogramOpts{
+			Namespace:   cfg.namespace,
+			Name:        "compression_ratio",
+			Help:        "Compressed to raw size ratio of attempted adaptive compressions.",
+			ConstLabels: cfg.constLabels,
+			Buckets:     ratioBuckets,
+		}, []string{labelCache, labelStore}),
+		decrypt: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "decrypt_failures_total",
+			Help:        "Number of encrypted values treated as misses by failure reason.",
+			ConstLabels: cfg.constLabels,
+		}, []string{labelCache, labelStore, labelReason}),
+		oversize: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "oversize_total",
+			Help:        "Number of writes exceeding the size limit by policy.",
+			ConstLabels: cfg.constLabels,
+		}, []string{labelCache, labelStore, labelPolicy}),
+		bloomReject: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "bloom_rejected_total",
+			Help:        "Number of requests rejected by the bloom guard.",
+			ConstLabels: cfg.constLabels,
+		}, []string{labelCache, labelStore}),
+		bloomFP: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "bloom_false_positive_total",
+			Help:        "Number of requests passed by the bloom guard that still missed.",
+			ConstLabels: cfg.constLabels,
+		}, []string{labelCache, labelStore}),
+		bloomFPRate: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   cfg.namespace,
+			Name:        "bloom_false_positive_ratio",
+			Help:        "Observed false positive ratio of the current bloom filter generation.",
+			ConstLabels: cfg.constLabels,
+		}, []string{labelCache, labelStore}),
+	}
+
+	var err error
+	if m.operations, err = register(cfg.registerer, m.operations); err != nil {
+		return nil, err
+	}
+	if m.latency, err = registerHistogram(cfg.registerer, m.latency); err != nil {
+		return nil, err
+	}
+	if m.sfRequests, err = register(cfg.registerer, m.sfRequests); err != nil {
+		return nil, err
+	}
+	if m.sfShared, err = register(cfg.registerer, m.sfShared); err != nil {
+		return nil, err
+	}
+	if m.sfRatio, err = register(cfg.registerer, m.sfRatio); err != nil {
+		return nil, err
+	}
+	if m.logicExpire, err = register(cfg.registerer, m.logicExpire); err != nil {
+		return nil, err
+	}
+	if m.upgraded, err = register(cfg.registerer, m.upgraded); err != nil {
+		return nil, err
+	}
+	if m.discarded, err = register(cfg.registerer, m.discarded); err != nil {
+		return nil, err
+	}
+	if m.compression, err = register(cfg.registerer, m.compression); err != nil {
+		return nil, err
+	}
+	if m.ratio, err = registerHistogram(cfg.registerer, m.ratio); err != nil {
+		return nil, err
+	}
+	if m.decrypt, err = register(cfg.registerer, m.decrypt); err != nil {
+		return nil, err
+	}
+	if m.oversize, err = register(cfg.registerer, m.oversize); err != nil {
+		return nil, err
+	}
+	if m.bloomReject, err = register(cfg.registerer, m.bloomReject); err != nil {
+		return nil, err
+	}
+	if m.bloomFP, err = register(cfg.registerer, m.bloomFP); err != nil {
+		return nil, err
+	}
+	if m.bloomFPRate, err = register(cfg.registerer, m.bloomFPRate); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// 已经注册过同名指标时复用已有的 collector，描述（名称、说明、标签）不一致时返回错误
+func register[C prometheus.Collector](registerer prometheus.Registerer, c C) (C, error) {
+	err := registerer.Register(c)
+	if err == nil {
+		return c, nil
+	}
+	var are prometheus.AlreadyRegisteredError
+	if !errors.As(err, &are) {
+		return c, err
+	}
+	existing, ok := are.ExistingCollector.(C)
+	if !ok {
+		return c, fmt.Errorf("prometheus: collector %T already registered as %T", c, are.ExistingCollector)
+	}
+	if want, got := describe(c), describe(existing); !slices.Equal(want, got) {
+		return c, fmt.Errorf("prometheus: collector already registered with different descriptors: want %v, got %v", want, got)
+	}
+	return existing, nil
+}
+
+// bucketedHistogram 直方图的描述中不包含分桶，随 collector 一起保存注册时使用的分桶用于比较，
+// 分桶的生命周期与注册在 Registerer 上的 collector 一致
+type bucketedHistogram struct {
+	*prometheus.HistogramVec
+	buckets []float64
+}
+
+func newBucketedHistogram(opts prometheus.HistogramOpts, labels []string) *bucketedHistogram {
+	return &bucketedHistogram{
+		HistogramVec: prometheus.NewHistogramVec(opts, labels),
+		buckets:      opts.Buckets,
+	}
+}
+
+func registerHistogram(registerer prometheus.Registerer, h *bucketedHistogram) (*bucketedHistogram, error) {
+	got, err := register(registerer, h)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Equal(got.buckets, h.buckets) {
+		return nil, fmt.Errorf("prometheus: histogram %s already registered with buckets %v, got %v", describe(h)[0], got.buckets, h.buckets)
+	}
+	return got, nil
+}
+
+// sharedRatio 按 cache/store 累计 singleflight 请求数与共享数，采集时输出两者之比
+type sharedRatio struct {
+	desc   *prometheus.Desc
+	counts sync.Map // [2]string -> *sharedCounts
+}
+
+type sharedCounts struct {
+	requests atomic.Uint64
+	shared   atomic.Uint64
+}
+
+func newSharedRatio(desc *prometheus.Desc) *sharedRatio {
+	return &sharedRatio{desc: desc}
+}
+
+func (r *sharedRatio) observe(cacheName, storeName string, shared bool) {
+	key := [2]string{cacheName, storeName}
+	v, ok := r.counts.Load(key)
+	if !ok {
+		v, _ = r.counts.LoadOrStore(key, &sharedCounts{})
+	}
+	c := v.(*sharedCounts)
+	if shared {
+		c.shared.Add(1)
+	}
+	c.requests.Add(1)
+}
+
+func (r *sharedRatio) Describe(ch chan<- *prometheus.Desc) {
+	ch <- r.desc
+}
+
+func (r *sharedRatio) Collect(ch chan<- prometheus.Metric) {
+	r.counts.Range(func(k, v any) bool {
+		key, c := k.([2]string), v.(*sharedCounts)
+		requests := c.requests.Load()
+		if requests == 0 {
+			return true
+		}
+		// 两个计数分别读取，并发累加时可能短暂超过 1
+		ratio := min(float64(c.shared.Load())/float64(requests), 1)
+		ch <- prometheus.MustNewConstMetric(r.desc, prometheus.GaugeValue, ratio, key[0], key[1])
+		return true
+	})
+}
+
+func describe(c prometheus.Collector) []string {
+	ch := make(chan *prometheus.Desc, 1)
+	go func() {
+		c.Describe(ch)
+		close(ch)
+	}()
+	var descs []string
+	for d := range ch {
+		descs = append(descs, d.String())
+	}
+	slices.Sort(descs)
+	return descs
+}
+
+func (m *Metrics) Record(ctx context.Context, evt *telemetry.Event) error {
+	m.operations.WithLabelValues(evt.CacheName, evt.StoreName, string(evt.Op), resultOf(evt)).Inc()
+	m.latency.WithLabelValues(evt.CacheName, evt.StoreName, string(evt.Op)).Observe(evt.Latency.Seconds())
+
+	if shared, ok := evt.FrozenCustomFields()[fieldShared]; ok {
+		m.recordShared(evt.CacheName, evt.StoreName, shared == "true")
+	}
+	return nil
+}
+
+// RecordLogicExpire 从上下文中的观测事件获取 cache 与 store 标签
+func (m *Metrics) RecordLogicExpire(ctx context.Context) {
+	m.logicExpire.WithLabelValues(labelsFromContext(ctx)...).Inc()
+}
+
+func (m *Metrics) RecordSchemaUpgraded(ctx context.Context, _, _ uint32) {
+	m.upgraded.WithLabelValues(labelsFromContext(ctx)...).Inc()
+}
+
+func (m *Metrics) RecordSchemaDiscarded(ctx context.Context, _, _ uint32) {
+	m.discarded.WithLabelValues(labelsFromContext(ctx)...).Inc()
+}
+
+func (m *Metrics) RecordCompression(ctx context.Context, outcome decorator.CompressionOutcome, raw, compressed int) {
+	labels := labelsFromContext(ctx)
+	m.compression.WithLabelValues(append(labels, string(outcome))...).Inc()
+	if compressed > 0 && raw > 0 {
+		m.ratio.WithLabelValues(labels...).Observe(float64(compressed) / float64(raw))
+	}
+}
+
+func (m *Metrics) RecordTampered(ctx context.Context, _ uint32) {
+	m.decrypt.WithLabelValues(append(labelsFromContext(ctx), "tampered")...).Inc()
+}
+
+func (m *Metrics) RecordUnknownKey(ctx context.Context, _ uint32) {
+	m.decrypt.WithLabelValues(append(labelsFromContext(ctx), "unknown_key")...).Inc()
+}
+
+func (m *Metrics) RecordOversize(ctx context.Context, policy decorator.OversizePolicy, _ int) {
+	m.oversize.WithLabelValues(append(labelsFromContext(ctx), string(policy))...).Inc()
+}
+
+func (m *Metrics) RecordBloomRejected(ctx context.Context) {
+	m.bloomReject.WithLabelValues(labelsFromContext(ctx)...).Inc()
+}
+
+func (m *Metrics) RecordBloomFalsePositive(ctx context.Context) {
+	m.bloomFP.WithLabelValues(labelsFromContext(ctx)...).Inc()
+}
+
+func (m *Metrics) RecordBloomFalsePositiveRate(ctx context.Context, rate float64) {
+	m.bloomFPRate.WithLabelValues(labelsFromContext(ctx)...).Set(rate)
+}
+
+// labelsFromContext 从上下文中的观测事件获取 cache 与 store 标签
+func labelsFromContext(ctx context.Context) []string {
+	var cacheName, storeName string
+	if evt, ok := telemetry.EventFromContext(ctx); ok {
+		cacheName, storeName = evt.CacheName, evt.StoreName
+	}
+	return []string{cacheName, storeName}
+}
+
+func (m *Metrics) recordShared(cacheName, storeName string, shared bool) {
+	m.sfRequests.WithLabelValues(cacheName, storeName).Inc()
+	c := m.sfShared.WithLabelValues(cacheName, storeName)
+	if shared {
+		c.Inc()
+	}
+	m.sfRatio.observe(cacheName, storeName, shared)
+}
+
+func resultOf(evt *telemetry.Event) string {
+	if evt.Result != "" {
+		return string(evt.Result)
+	}
+	if evt.Error != nil {
+		return resultFail
+	}
+	return resultOK
+}
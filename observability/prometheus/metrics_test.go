@@ -0,0 +1,171 @@
+package prometheus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"github.com/yikakia/cachalot"
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/decorator"
+	"github.com/yikakia/cachalot/core/telemetry"
+	"github.com/yikakia/cachalot/stores/storetests"
+)
+
+func TestMetricsWithBuilder(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewRegistry()
+	metrics, err := New(WithRegisterer(reg), WithLatencyBuckets(0.001, 0.01))
+	require.NoError(t, err)
+
+	builder, err := cachalot.NewBuilder[string]("users", storetests.NewMemoryStore())
+	require.NoError(t, err)
+	c, err := builder.
+		WithCacheMissLoader(func(ctx context.Context, key string, opts ...cache.CallOption) (string, error) {
+			if key == "absent" {
+				return "", cache.ErrNotFound
+			}
+			return "loaded-" + key, nil
+		}).
+		WithMetrics(metrics).
+		Build()
+	require.NoError(t, err)
+
+	require.NoError(t, c.Set(ctx, "k", "v", time.Minute))
+	_, err = c.Get(ctx, "k")
+	require.NoError(t, err)
+	_, err = c.Get(ctx, "absent")
+	require.ErrorIs(t, err, cache.ErrNotFound)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.operations.WithLabelValues("users", "memory", "set", "ok")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.operations.WithLabelValues("users", "memory", "get", "hit")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.operations.WithLabelValues("users", "memory", "get", "miss")))
+	require.Equal(t, 2.0, testutil.ToFloat64(metrics.sfRequests.WithLabelValues("users", "memory")))
+	require.Equal(t, 0.0, testutil.ToFloat64(metrics.sfShared.WithLabelValues("users", "memory")))
+	require.Equal(t, 2, testutil.CollectAndCount(metrics.latency))
+}
+
+func TestMetricsSingleflightShared(t *testing.T) {
+	ctx := context.Background()
+	metrics, err := New(WithRegisterer(prometheus.NewRegistry()))
+	require.NoError(t, err)
+
+	record := func(shared string) {
+		evt := &telemetry.Event{Op: telemetry.OpGet, Result: telemetry.ResultHit, CacheName: "c", StoreName: "s"}
+		telemetry.AddCustomFields(telemetry.ContextWithEvent(ctx, evt), map[string]string{fieldShared: shared})
+		require.NoError(t, metrics.Record(ctx, evt))
+	}
+	record("true")
+	record("false")
+	record("false")
+	record("true")
+
+	require.Equal(t, 4.0, testutil.ToFloat64(metrics.sfRequests.WithLabelValues("c", "s")))
+	require.Equal(t, 2.0, testutil.ToFloat64(metrics.sfShared.WithLabelValues("c", "s")))
+	require.Equal(t, 0.5, testutil.ToFloat64(metrics.sfRatio))
+}
+
+func TestMetricsLogicExpire(t *testing.T) {
+	metrics, err := New(WithRegisterer(prometheus.NewRegistry()))
+	require.NoError(t, err)
+
+	ctx := telemetry.ContextWithEvent(context.Background(), &telemetry.Event{CacheName: "c", StoreName: "s"})
+	metrics.RecordLogicExpire(ctx)
+	metrics.RecordLogicExpire(ctx)
+
+	require.Equal(t, 2.0, testutil.ToFloat64(metrics.logicExpire.WithLabelValues("c", "s")))
+}
+
+func TestMetricsCompression(t *testing.T) {
+	metrics, err := New(WithRegisterer(prometheus.NewRegistry()))
+	require.NoError(t, err)
+
+	ctx := telemetry.ContextWithEvent(context.Background(), &telemetry.Event{CacheName: "c", StoreName: "s"})
+	metrics.RecordCompression(ctx, decorator.CompressionApplied, 100, 40)
+	metrics.RecordCompression(ctx, decorator.CompressionRejected, 100, 95)
+	metrics.RecordCompression(ctx, decorator.CompressionTooSmall, 10, 0)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.compression.WithLabelValues("c", "s", "applied")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.compression.WithLabelValues("c", "s", "too_small")))
+	// 未尝试压缩的写入不计入压缩率
+	var m dto.Metric
+	require.NoError(t, metrics.ratio.WithLabelValues("c", "s").(prometheus.Histogram).Write(&m))
+	require.Equal(t, uint64(2), m.GetHistogram().GetSampleCount())
+	require.InDelta(t, 1.35, m.GetHistogram().GetSampleSum(), 1e-9)
+}
+
+func TestMetricsDecryptFailures(t *testing.T) {
+	metrics, err := New(WithRegisterer(prometheus.NewRegistry()))
+	require.NoError(t, err)
+
+	ctx := telemetry.ContextWithEvent(context.Background(), &telemetry.Event{CacheName: "c", StoreName: "s"})
+	metrics.RecordTampered(ctx, 1)
+	metrics.RecordTampered(ctx, 1)
+	metrics.RecordUnknownKey(ctx, 1)
+
+	require.Equal(t, 2.0, testutil.ToFloat64(metrics.decrypt.WithLabelValues("c", "s", "tampered")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.decrypt.WithLabelValues("c", "s", "unknown_key")))
+}
+
+func TestMetricsOversize(t *testing.T) {
+	metrics, err := New(WithRegisterer(prometheus.NewRegistry()))
+	require.NoError(t, err)
+
+	ctx := telemetry.ContextWithEvent(context.Background(), &telemetry.Event{CacheName: "c", StoreName: "s"})
+	metrics.RecordOversize(ctx, decorator.OversizeReject, 1<<20)
+	metrics.RecordOversize(ctx, decorator.OversizeChunk, 1<<20)
+	metrics.RecordOversize(ctx, decorator.OversizeChunk, 1<<21)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.oversize.WithLabelValues("c", "s", "rejected")))
+	require.Equal(t, 2.0, testutil.ToFloat64(metrics.oversize.WithLabelValues("c", "s", "chunked")))
+}
+
+func TestMetricsBloomGuard(t *testing.T) {
+	metrics, err := New(WithRegisterer(prometheus.NewRegistry()))
+	require.NoError(t, err)
+
+	ctx := telemetry.ContextWithEvent(context.Background(), &telemetry.Event{CacheName: "c", StoreName: "s"})
+	metrics.RecordBloomRejected(ctx)
+	metrics.RecordBloomFalsePositive(ctx)
+	metrics.RecordBloomFalsePositiveRate(ctx, 0.5)
+	metrics.RecordBloomFalsePositiveRate(ctx, 0.25)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.bloomReject.WithLabelValues("c", "s")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.bloomFP.WithLabelValues("c", "s")))
+	require.Equal(t, 0.25, testutil.ToFloat64(metrics.bloomFPRate.WithLabelValues("c", "s")))
+}
+
+func TestNewReusesRegisteredCollectors(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	first, err := New(WithRegisterer(reg))
+	require.NoError(t, err)
+	second, err := New(WithRegisterer(reg))
+	require.NoError(t, err)
+
+	first.operations.WithLabelValues("c", "s", "get", "hit").Inc()
+	second.operations.WithLabelValues("c", "s", "get", "hit").Inc()
+	require.Equal(t, 2.0, testutil.ToFloat64(first.operations.WithLabelValues("c", "s", "get", "hit")))
+
+	first.recordShared("c", "s", true)
+	second.recordShared("c", "s", false)
+	require.Equal(t, 0.5, testutil.ToFloat64(first.sfRatio))
+}
+
+func TestNewRejectsConflictingCollectors(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	_, err := New(WithRegisterer(reg), WithLatencyBuckets(0.01, 0.1))
+	require.NoError(t, err)
+
+	_, err = New(WithRegisterer(reg), WithLatencyBuckets(0.01, 0.1))
+	require.NoError(t, err)
+	_, err = New(WithRegisterer(reg), WithLatencyBuckets(0.5, 1))
+	require.ErrorContains(t, err, "buckets")
+
+	// 分桶随 collector 保存，其他 Registerer 不受影响
+	_, err = New(WithRegisterer(prometheus.NewRegistry()), WithLatencyBuckets(0.5, 1))
+	require.NoError(t, err)
+}
@@ -6,6 +6,8 @@ set -e
 SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
 cd "$SCRIPT_DIR"
 
+# 子模块的 go.mod 依赖固定版本的根模块，通过根目录的 go.work 使用工作区中的代码
+
 # 颜色定义
 GREEN='\033[0;32m'
 RED='\033[0;31m'
@@ -16,7 +18,7 @@ echo "🚀 Starting full test suite..."
 echo ""
 
 # 主模块测试
-echo -e "${YELLOW}[1/4] Testing root modules...${NC}"
+echo -e "${YELLOW}[1/13] Testing root modules...${NC}"
 if go test -v -race ./...; then
     echo -e "${GREEN}✓ Root modules passed${NC}"
 else
@@ -26,7 +28,7 @@ fi
 echo ""
 
 # Redis 存储测试
-echo -e "${YELLOW}[2/4] Testing stores/redis...${NC}"
+echo -e "${YELLOW}[2/13] Testing stores/redis...${NC}"
 if (cd stores/redis && go test -v -race .); then
     echo -e "${GREEN}✓ Redis store passed${NC}"
 else
@@ -36,7 +38,7 @@ fi
 echo ""
 
 # Ristretto 存储测试
-echo -e "${YELLOW}[3/4] Testing stores/ristretto...${NC}"
+echo -e "${YELLOW}[3/13] Testing stores/ristretto...${NC}"
 if (cd stores/ristretto && go test -v -race .); then
     echo -e "${GREEN}✓ Ristretto store passed${NC}"
 else
@@ -46,7 +48,7 @@ fi
 echo ""
 
 # FreeCache 存储测试
-echo -e "${YELLOW}[4/4] Testing stores/freecache...${NC}"
+echo -e "${YELLOW}[4/13] Testing stores/freecache...${NC}"
 if (cd stores/freecache && go test -v -race .); then
     echo -e "${GREEN}✓ FreeCache store passed${NC}"
 else
@@ -55,4 +57,94 @@ else
 fi
 echo ""
 
+# OpenTelemetry 适配测试
+echo -e "${YELLOW}[5/13] Testing observability/otel...${NC}"
+if (cd observability/otel && go test -v -race .); then
+    echo -e "${GREEN}✓ OpenTelemetry adapter passed${NC}"
+else
+    echo -e "${RED}✗ OpenTelemetry adapter failed${NC}"
+    exit 1
+fi
+echo ""
+
+# Prometheus 适配测试
+echo -e "${YELLOW}[6/13] Testing observability/prometheus...${NC}"
+if (cd observability/prometheus && go test -v -race .); then
+    echo -e "${GREEN}✓ Prometheus adapter passed${NC}"
+else
+    echo -e "${RED}✗ Prometheus adapter failed${NC}"
+    exit 1
+fi
+echo ""
+
+# Protobuf codec 测试
+echo -e "${YELLOW}[7/13] Testing codecs/protobuf...${NC}"
+if (cd codecs/protobuf && go test -v -race .); then
+    echo -e "${GREEN}✓ Protobuf codec passed${NC}"
+else
+    echo -e "${RED}✗ Protobuf codec failed${NC}"
+    exit 1
+fi
+echo ""
+
+# MessagePack codec 测试
+echo -e "${YELLOW}[8/13] Testing codecs/msgpack...${NC}"
+if (cd codecs/msgpack && go test -v -race .); then
+    echo -e "${GREEN}✓ MessagePack codec passed${NC}"
+else
+    echo -e "${RED}✗ MessagePack codec failed${NC}"
+    exit 1
+fi
+echo ""
+
+# CBOR codec 测试
+echo -e "${YELLOW}[9/13] Testing codecs/cbor...${NC}"
+if (cd codecs/cbor && go test -v -race .); then
+    echo -e "${GREEN}✓ CBOR codec passed${NC}"
+else
+    echo -e "${RED}✗ CBOR codec failed${NC}"
+    exit 1
+fi
+echo ""
+
+# Zstd compression 测试
+echo -e "${YELLOW}[10/13] Testing compressions/zstd...${NC}"
+if (cd compressions/zstd && go test -v -race ./...); then
+    echo -e "${GREEN}✓ Zstd compression passed${NC}"
+else
+    echo -e "${RED}✗ Zstd compression failed${NC}"
+    exit 1
+fi
+echo ""
+
+# Snappy compression 测试
+echo -e "${YELLOW}[11/13] Testing compressions/snappy...${NC}"
+if (cd compressions/snappy && go test -v -race ./...); then
+    echo -e "${GREEN}✓ Snappy compression passed${NC}"
+else
+    echo -e "${RED}✗ Snappy compression failed${NC}"
+    exit 1
+fi
+echo ""
+
+# LZ4 compression 测试
+echo -e "${YELLOW}[12/13] Testing compressions/lz4...${NC}"
+if (cd compressions/lz4 && go test -v -race ./...); then
+    echo -e "${GREEN}✓ LZ4 compression passed${NC}"
+else
+    echo -e "${RED}✗ LZ4 compression failed${NC}"
+    exit 1
+fi
+echo ""
+
+# 声明式配置测试
+echo -e "${YELLOW}[13/13] Testing config...${NC}"
+if (cd config && go test -v -race ./...); then
+    echo -e "${GREEN}✓ Config passed${NC}"
+else
+    echo -e "${RED}✗ Config failed${NC}"
+    exit 1
+fi
+echo ""
+
 echo -e "${GREEN}✅ All tests passed!${NC}"
@@ -0,0 +1,204 @@
+package cachalot
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/yikakia/cachalot/core/cache"
+)
+
+// Stage Builder 装饰器链路中的命名阶段，取值与装配计划中的层名称相同
+//
+// 内置阶段由外到内依次为 StageObservable、StageInterceptors、StageHotKey、StageSingleflight、
+// StageBloomGuard、StageNilCache、StageMissedLoader、StageFactory，未开启的阶段不出现在链路中。
+// 通过 WithDecoratorAt 插入的装饰器以其名称作为阶段，可以作为其他装饰器的锚点
+type Stage string
+
+const (
+	StageObservable   Stage = "observable"
+	StageInterceptors Stage = "interceptors"
+	StageHotKey       Stage = "hot_key"
+	StageSingleflight Stage = "singleflight"
+	StageBloomGuard   Stage = "bloom_guard"
+	StageNilCache     Stage = "nil_cache"
+	StageMissedLoader Stage = "missed_loader"
+	// StageFactory 工厂创建的最内层，只能使用 Before 锚定
+	StageFactory Stage = "factory"
+)
+
+var builtinStages = []Stage{
+	StageObservable,
+	StageInterceptors,
+	StageHotKey,
+	StageSingleflight,
+	StageBloomGuard,
+	StageNilCache,
+	StageMissedLoader,
+	StageFactory,
+}
+
+// Placement 装饰器相对于某个阶段的位置，通过 Before、After 创建
+type Placement struct {
+	stage Stage
+	after bool
+}
+
+// Before 位于 stage 的外层，调用先经过该装饰器再进入 stage
+func Before(stage Stage) Placement {
+	return Placement{stage: stage}
+}
+
+// After 位于 stage 的内层，调用经过 stage 之后再进入该装饰器
+func After(stage Stage) Placement {
+	return Placement{stage: stage, after: true}
+}
+
+func (p Placement) String() string {
+	if p.after {
+		return "after " + string(p.stage)
+	}
+	return "before " + string(p.stage)
+}
+
+type placedDecorator[T any] struct {
+	name      Stage
+	at        Placement
+	decorator cache.Decorator[T]
+}
+
+// WithDecoratorAt 在 at 指定的位置插入名为 name 的装饰器
+//
+// 锚定同一阶段的多个装饰器按声明顺序由外到内排列。Build 时锚点阶段未开启、不存在或装饰器之间循环锚定会返回错误
+func (b *Builder[T]) WithDecoratorAt(name Stage, at Placement, d cache.Decorator[T]) *Builder[T] {
+	switch {
+	case name == "":
+		b.appendErr(errors.New("decorator stage name is required"))
+	case d == nil:
+		b.appendErr(fmt.Errorf("decorator [%s] is nil", name))
+	case slices.Contains(builtinStages, name):
+		b.appendErr(fmt.Errorf("decorator [%s] conflicts with builtin stage", name))
+	case slices.ContainsFunc(b.placed, func(p placedDecorator[T]) bool { return p.name == name }):
+		b.appendErr(fmt.Errorf("decorator [%s] already declared", name))
+	case at.stage == StageFactory && at.after:
+		b.appendErr(fmt.Errorf("decorator [%s] cannot be placed %s, use %s", name, at, Before(StageFactory)))
+	default:
+		b.placed = append(b.placed, placedDecorator[T]{name: name, at: at, decorator: d})
+	}
+	return b
+}
+
+// stageRef 装饰器链路中的一层，placed、legacy 分别为 WithDecoratorAt、WithDecorators 中的下标，都为 -1 时为内置阶段
+type stageRef struct {
+	name   Stage
+	placed int
+	legacy int
+}
+
+// enabledStages 由外到内列出已开启的内置阶段，与 decorate* 的开启条件保持一致
+func (b *Builder[T]) enabledStages() []stageRef {
+	enabled := map[Stage]bool{
+		StageObservable:   true,
+		StageInterceptors: b.features.logging != nil || len(b.features.interceptors) > 0,
+		StageHotKey:       b.features.hotKey.detector != nil,
+		StageSingleflight: b.features.singleFlight,
+		StageBloomGuard:   b.features.bloomGuard.filter != nil,
+		StageNilCache:     b.features.nilCache.protectionFn != nil,
+		StageMissedLoader: b.features.missLoader.loadFn != nil,
+	}
+	var refs []stageRef
+	for _, stage := range builtinStages {
+		if stage == StageFactory {
+			// WithDecorators 传入的装饰器紧邻工厂，先声明的位于内层
+			for i := range b.decorators {
+				refs = append(refs, stageRef{name: "decorator", placed: -1, legacy: len(b.decorators) - 1 - i})
+			}
+		}
+		if stage == StageFactory || enabled[stage] {
+			refs = append(refs, stageRef{name: stage, placed: -1, legacy: -1})
+		}
+	}
+	return refs
+}
+
+// resolveStages 由外到内返回完整的装饰器链路，最后一项为 StageFactory
+//
+// 无法放置的装饰器不出现在结果中，并在返回的错误中说明原因
+func (b *Builder[T]) resolveStages() ([]stageRef, error) {
+	refs := b.enabledStages()
+	indexOf := func(stage Stage) int {
+		return slices.IndexFunc(refs, func(r stageRef) bool { return r.name == stage && r.legacy < 0 })
+	}
+
+	pending := make([]int, 0, len(b.placed))
+	for i := range b.placed {
+		pending = append(pending, i)
+	}
+	// 锚点可能是之后声明的装饰器，逐轮放置直到没有进展
+	for len(pending) > 0 {
+		var next []int
+		for _, i := range pending {
+			p := b.placed[i]
+			at := indexOf(p.at.stage)
+			if at < 0 {
+				next = append(next, i)
+				continue
+			}
+			pos := at
+			if p.at.after {
+				// 跳过已锚定在同一阶段之后的装饰器，保持声明顺序
+				pos = at + 1
+				for pos < len(refs) && refs[pos].placed >= 0 && b.placed[refs[pos].placed].at == p.at {
+					pos++
+				}
+			}
+			refs = slices.Insert(refs, pos, stageRef{name: p.name, placed: i, legacy: -1})
+		}
+		if len(next) == len(pending) {
+			return refs, b.placementErrors(next)
+		}
+		pending = next
+	}
+	return refs, nil
+}
+
+func (b *Builder[T]) placementErrors(unresolved []int) error {
+	var errs []error
+	for _, i := range unresolved {
+		p := b.placed[i]
+		switch {
+		case slices.Contains(builtinStages, p.at.stage):
+			errs = append(errs, fmt.Errorf("decorator [%s] placed %s, but stage [%s] is not enabled", p.name, p.at, p.at.stage))
+		case slices.ContainsFunc(b.placed, func(o placedDecorator[T]) bool { return o.name == p.at.stage }):
+			errs = append(errs, fmt.Errorf("decorator [%s] placed %s, which forms a cycle", p.name, p.at))
+		default:
+			errs = append(errs, fmt.Errorf("decorator [%s] placed %s, but stage [%s] is unknown", p.name, p.at, p.at.stage))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// stageDecorators 由内到外返回 cache.New 需要的装饰器
+func (b *Builder[T]) stageDecorators(refs []stageRef) []cache.Option[T] {
+	opts := make([]cache.Option[T], 0, len(refs))
+	for _, ref := range slices.Backward(refs) {
+		switch {
+		case ref.placed >= 0:
+			opts = append(opts, cache.WithDecorator(b.placed[ref.placed].decorator))
+		case ref.legacy >= 0:
+			opts = append(opts, b.decorators[ref.legacy])
+		case ref.name == StageObservable:
+			opts = append(opts, b.obDecorators)
+		case b.stageOptions[ref.name] != nil:
+			opts = append(opts, b.stageOptions[ref.name])
+		}
+	}
+	return opts
+}
+
+func (b *Builder[T]) setStage(stage Stage, opt cache.Option[T]) {
+	if b.stageOptions == nil {
+		b.stageOptions = make(map[Stage]cache.Option[T])
+	}
+	b.stageOptions[stage] = opt
+}
@@ -0,0 +1,95 @@
+package cachalot
+
+import (
+	"context"
+	"errors"
+
+	"github.com/yikakia/cachalot/core/cache"
+	"github.com/yikakia/cachalot/core/multicache"
+	"github.com/yikakia/cachalot/core/stats"
+	"github.com/yikakia/cachalot/core/telemetry"
+)
+
+// StatsOf 获取通过 WithStats 开启统计的缓存的统计快照
+func StatsOf[T any](c cache.Cache[T]) (stats.Snapshot, bool) {
+	r, ok := c.(stats.Reporter)
+	if !ok {
+		return stats.Snapshot{}, false
+	}
+	return r.Stats(), true
+}
+
+// BloomFalsePositiveRateOf 获取通过 WithBloomGuard 开启布隆过滤器的缓存观测到的误判率
+//
+// 误判率为过滤器放行但下游未命中的请求占放行请求的比例，见 decorator.BloomGuardDecorator.FalsePositiveRate
+func BloomFalsePositiveRateOf[T any](c cache.Cache[T]) (float64, bool) {
+	d, ok := c.(interface {
+		bloomFalsePositiveRate() (float64, bool)
+	})
+	if !ok {
+		return 0, false
+	}
+	return d.bloomFalsePositiveRate()
+}
+
+// StatsOfMulti 获取通过 MultiBuilder.WithStats 开启统计的多级缓存的统计快照
+func StatsOfMulti[T any](mc multicache.MultiCache[T]) (stats.Snapshot, bool) {
+	r, ok := mc.(stats.Reporter)
+	if !ok {
+		return stats.Snapshot{}, false
+	}
+	return r.Stats(), true
+}
+
+// statsCache 在最外层暴露统计访问
+type statsCache[T any] struct {
+	*describedCache[T]
+	collector *stats.Collector
+}
+
+var _ stats.Reporter = (*statsCache[any])(nil)
+
+func (s *statsCache[T]) Stats() stats.Snapshot {
+	return s.collector.Snapshot()
+}
+
+func (s *statsCache[T]) ResetStats() {
+	s.collector.Reset()
+}
+
+// statsMultiCache 在多级缓存的最外层暴露统计访问
+type statsMultiCache[T any] struct {
+	*describedMultiCache[T]
+	collector *stats.Collector
+}
+
+var _ stats.Reporter = (*statsMultiCache[any])(nil)
+
+func (s *statsMultiCache[T]) Stats() stats.Snapshot {
+	return s.collector.Snapshot()
+}
+
+func (s *statsMultiCache[T]) ResetStats() {
+	s.collector.Reset()
+}
+
+// teeMetrics 同时向多个 Metrics 上报
+//
+// 各装饰器通过 telemetry.MetricsAs 展开 Unwrap 的结果断言可选指标接口，新增可选接口时无需在此转发
+type teeMetrics []telemetry.Metrics
+
+var _ interface{ Unwrap() []telemetry.Metrics } = teeMetrics(nil)
+
+func (t teeMetrics) Record(ctx context.Context, evt *telemetry.Event) error {
+	var errs []error
+	for _, m := range t {
+		if err := m.Record(ctx, evt); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t teeMetrics) Unwrap() []telemetry.Metrics {
+	return t
+}
@@ -0,0 +1,74 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// BitmapClient redis bitmap 布隆过滤器需要的客户端能力
+type BitmapClient interface {
+	BitField(ctx context.Context, key string, values ...any) *redis.IntSliceCmd
+	Del(ctx context.Context, keys ...string) *redis.IntCmd
+}
+
+// NewBloomBitmap 创建基于 redis bitmap 的位存储，实现了 bloom.BitSet
+// 多个进程共享同一个 key 即可共享同一个布隆过滤器
+//
+//	bits := redis.NewBloomBitmap(client, "user:bloom")
+//	filter, err := bloom.NewFilter(bits, m, k)
+func NewBloomBitmap(client BitmapClient, key string) *BloomBitmap {
+	return &BloomBitmap{
+		client: client,
+		key:    key,
+	}
+}
+
+// BloomBitmap 每次操作通过一条 BITFIELD 命令完成所有位的读写
+type BloomBitmap struct {
+	client BitmapClient
+	key    string
+}
+
+func (b *BloomBitmap) SetBits(ctx context.Context, positions []uint64) error {
+	if len(positions) == 0 {
+		return nil
+	}
+	args := make([]any, 0, len(positions)*4)
+	for _, p := range positions {
+		args = append(args, "SET", "u1", p, 1)
+	}
+	return b.client.BitField(ctx, b.key, args...).Err()
+}
+
+func (b *BloomBitmap) TestBits(ctx context.Context, positions []uint64) (bool, error) {
+	if len(positions) == 0 {
+		return true, nil
+	}
+	args := make([]any, 0, len(positions)*3)
+	for _, p := range positions {
+		args = append(args, "GET", "u1", p)
+	}
+	bits, err := b.client.BitField(ctx, b.key, args...).Result()
+	if err != nil {
+		return false, err
+	}
+	for _, bit := range bits {
+		if bit == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Clear 删除整个 bitmap，可在 bloom.Rotating 的 OnRotated 中清理上一代
+func (b *BloomBitmap) Clear(ctx context.Context) error {
+	return b.client.Del(ctx, b.key).Err()
+}
+
+// Key 返回 bitmap 所在的 redis key
+func (b *BloomBitmap) Key() string {
+	return b.key
+}
+
+var _ BitmapClient = (*redis.Client)(nil)
@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBitmapClient struct {
+	mu   sync.Mutex
+	bits map[string]map[uint64]int64
+}
+
+func (f *fakeBitmapClient) BitField(ctx context.Context, key string, values ...any) *goredis.IntSliceCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.bits == nil {
+		f.bits = map[string]map[uint64]int64{}
+	}
+	bm := f.bits[key]
+	if bm == nil {
+		bm = map[uint64]int64{}
+		f.bits[key] = bm
+	}
+
+	var ret []int64
+	for i := 0; i < len(values); {
+		switch values[i] {
+		case "SET":
+			pos := values[i+2].(uint64)
+			ret = append(ret, bm[pos])
+			bm[pos] = int64(values[i+3].(int))
+			i += 4
+		case "GET":
+			ret = append(ret, bm[values[i+2].(uint64)])
+			i += 3
+		default:
+			cmd := goredis.NewIntSliceCmd(ctx)
+			cmd.SetErr(fmt.Errorf("unexpected subcommand %v", values[i]))
+			return cmd
+		}
+	}
+	cmd := goredis.NewIntSliceCmd(ctx)
+	cmd.SetVal(ret)
+	return cmd
+}
+
+func (f *fakeBitmapClient) Del(ctx context.Context, keys ...string) *goredis.IntCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, k := range keys {
+		delete(f.bits, k)
+	}
+	return goredis.NewIntResult(int64(len(keys)), nil)
+}
+
+func TestBloomBitmap(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeBitmapClient{}
+	b := NewBloomBitmap(client, "bloom")
+	assert.Equal(t, "bloom", b.Key())
+
+	ok, err := b.TestBits(ctx, []uint64{1, 100})
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, b.SetBits(ctx, []uint64{1, 100}))
+	ok, err = b.TestBits(ctx, []uint64{1, 100})
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = b.TestBits(ctx, []uint64{1, 101})
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, b.Clear(ctx))
+	ok, err = b.TestBits(ctx, []uint64{1})
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,146 @@
+package storetests
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yikakia/cachalot/core/cache"
+)
+
+var _ cache.Store = (*MemoryStore)(nil)
+
+// MemoryStore 测试用的内存 Store，支持 TTL，可并发使用
+//
+// 各模块的测试与基准共用该实现，不需要各自维护一份 map 实现的 Store
+type MemoryStore struct {
+	name      string
+	copyBytes bool
+	now       func() time.Time
+
+	mu   sync.Mutex
+	data map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	val      any
+	expireAt time.Time
+}
+
+type MemoryStoreOption func(*MemoryStore)
+
+// WithStoreName 指定 StoreName 默认为 memory
+func WithStoreName(name string) MemoryStoreOption {
+	return func(m *MemoryStore) {
+		m.name = name
+	}
+}
+
+// WithCopyBytes 读写 []byte 时复制，模拟 Redis 等远端存储不会与调用方共享内存的行为
+func WithCopyBytes() MemoryStoreOption {
+	return func(m *MemoryStore) {
+		m.copyBytes = true
+	}
+}
+
+// WithClock 指定计算过期时间使用的时钟 默认为 time.Now
+func WithClock(now func() time.Time) MemoryStoreOption {
+	return func(m *MemoryStore) {
+		m.now = now
+	}
+}
+
+func NewMemoryStore(opts ...MemoryStoreOption) *MemoryStore {
+	m := &MemoryStore{
+		name: "memory",
+		now:  time.Now,
+		data: map[string]memoryEntry{},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+func (m *MemoryStore) Get(ctx context.Context, key string, opts ...cache.CallOption) (any, error) {
+	v, _, err := m.GetWithTTL(ctx, key, opts...)
+	return v, err
+}
+
+func (m *MemoryStore) GetWithTTL(_ context.Context, key string, _ ...cache.CallOption) (any, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.data[key]
+	if !ok {
+		return nil, 0, fmt.Errorf("key:%s %w", key, cache.ErrNotFound)
+	}
+	var ttl time.Duration
+	if !e.expireAt.IsZero() {
+		ttl = e.expireAt.Sub(m.now())
+		if ttl <= 0 {
+			delete(m.data, key)
+			return nil, 0, fmt.Errorf("key:%s %w", key, cache.ErrNotFound)
+		}
+	}
+	return m.clone(e.val), ttl, nil
+}
+
+func (m *MemoryStore) Set(_ context.Context, key string, val any, ttl time.Duration, _ ...cache.CallOption) error {
+	if ttl < 0 {
+		return cache.ErrInvalidTTL
+	}
+	e := memoryEntry{val: val}
+	if ttl > 0 {
+		e.expireAt = m.now().Add(ttl)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if b, ok := val.([]byte); ok && m.copyBytes {
+		// 复用旧值的空间，基准测试中不计入存储自身的分配
+		old, _ := m.data[key].val.([]byte)
+		e.val = append(old[:0], b...)
+	}
+	m.data[key] = e
+	return nil
+}
+
+func (m *MemoryStore) Delete(_ context.Context, key string, _ ...cache.CallOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, key)
+	return nil
+}
+
+func (m *MemoryStore) Clear(_ context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data = map[string]memoryEntry{}
+	return nil
+}
+
+func (m *MemoryStore) StoreName() string {
+	return m.name
+}
+
+// Raw 返回 key 对应的原始值，不检查过期时间，用于断言写入存储的内容
+func (m *MemoryStore) Raw(key string) (any, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.data[key]
+	return e.val, ok
+}
+
+// Put 直接写入原始值，永不过期，用于构造旧版本或损坏的数据
+func (m *MemoryStore) Put(key string, val any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = memoryEntry{val: val}
+}
+
+func (m *MemoryStore) clone(val any) any {
+	if b, ok := val.([]byte); ok && m.copyBytes {
+		return append([]byte(nil), b...)
+	}
+	return val
+}